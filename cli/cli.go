@@ -2,12 +2,13 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/alecthomas/kong"
 	"github.com/michimani/cfkvs/internal/commands"
-	"github.com/michimani/cfkvs/libs"
 )
 
 type CLI struct {
@@ -15,6 +16,12 @@ type CLI struct {
 
 	KVS  commands.KVSCmd  `cmd:"" help:"KeyValueStore operations."`
 	Item commands.ItemCmd `cmd:"item" help:"Items in specific KeyValueStore."`
+
+	Apply commands.ManifestApplyCmd `cmd:"" help:"Create, update and sync all KeyValueStores declared in the manifest file."`
+
+	Function commands.FunctionCmd `cmd:"" help:"CloudFront Functions that read KeyValueStore."`
+
+	Emulator commands.EmulatorCmd `cmd:"" help:"Run a local in-memory emulator of CloudFront KeyValueStore."`
 }
 
 var (
@@ -41,17 +48,34 @@ func Run(ctx context.Context) error {
 			"version": versionString,
 		})
 
-	if err := setClient(ctx, kctx.Args, &cli.Globals); err != nil {
+	if err := setClient(ctx, commandPath(kctx), &cli.Globals); err != nil {
 		return err
 	}
 
 	err = kctx.Run(&cli.Globals)
+	var exitErr *commands.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.Err != nil {
+			kctx.Errorf("%s", exitErr)
+		}
+		kctx.Exit(exitErr.Code)
+		return nil
+	}
 	kctx.FatalIfErrorf(err)
 
 	return nil
 }
 
+// commandPath returns the path of the selected command, like [kvs list].
+// The selected command is used instead of the raw arguments, because global flags can precede it.
+func commandPath(kctx *kong.Context) []string {
+	return strings.Fields(kctx.Command())
+}
+
+// needClientCommands are the commands that call AWS APIs.
+// function is not included, because it creates the clients only when it reads a key value store.
 var needClientCommands = []string{
+	"apply",
 	"item",
 	"kvs",
 }
@@ -73,32 +97,16 @@ func setClient(ctx context.Context, args []string, globals *commands.Globals) er
 		return nil
 	}
 
-	s3, err := libs.NewS3Client(ctx)
-	if err != nil {
-		return err
-	}
-	globals.S3Client = s3
-
-	cf, err := libs.NewCloudFrontClient(ctx)
-	if err != nil {
-		return err
-	}
-	globals.CloudFrontClient = cf
-
-	kvs, err := libs.NewCloudFrontKeyValueStoreClient(ctx)
-	if err != nil {
-		return err
-	}
-	globals.CloudFrontKeyValueStoreClient = kvs
-
-	return nil
+	return globals.LoadClients(ctx)
 }
 
 func NewCLI(ctx context.Context) (CLI, error) {
 	cli := CLI{
 		Globals: commands.Globals{
 			Version:      commands.VersionFlag(versionString),
+			InputSource:  os.Stdin,
 			OutputTarget: os.Stdout,
+			LogTarget:    os.Stderr,
 		},
 	}
 
@@ -4,8 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/alecthomas/kong"
 	"github.com/michimani/cfkvs/cli"
 	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -87,6 +89,27 @@ func Test_setClient(t *testing.T) {
 			},
 			wantSet: true,
 		},
+		{
+			name:    "ok: not want set client for function command, even with an invalid profile",
+			args:    []string{"function"},
+			globals: &commands.Globals{},
+			envs: map[string]string{
+				"AWS_PROFILE": "invalid",
+				"AWS_REGION":  "ap-northeast-1",
+			},
+			wantSet: false,
+		},
+		{
+			name:    "ok: want set client for apply command",
+			args:    []string{"apply"},
+			globals: &commands.Globals{},
+			envs: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "dummy_key_id",
+				"AWS_SECRET_ACCESS_KEY": "dummy_secret_key",
+				"AWS_REGION":            "ap-northeast-1",
+			},
+			wantSet: true,
+		},
 		{
 			name:    "ok: not want set client for other command",
 			args:    []string{"other"},
@@ -103,6 +126,26 @@ func Test_setClient(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name:    "error: profile in globals not found",
+			args:    []string{"kvs"},
+			globals: &commands.Globals{Profile: "invalid"},
+			envs: map[string]string{
+				"AWS_REGION": "ap-northeast-1",
+			},
+			wantError: true,
+		},
+		{
+			name:    "error: external id without role arn",
+			args:    []string{"kvs"},
+			globals: &commands.Globals{ExternalID: "external_id"},
+			envs: map[string]string{
+				"AWS_ACCESS_KEY_ID":     "dummy_key_id",
+				"AWS_SECRET_ACCESS_KEY": "dummy_secret_key",
+				"AWS_REGION":            "ap-northeast-1",
+			},
+			wantError: true,
+		},
 	}
 
 	for _, c := range cases {
@@ -132,3 +175,116 @@ func Test_setClient(t *testing.T) {
 		})
 	}
 }
+
+func Test_commandPath(t *testing.T) {
+	cases := []struct {
+		name    string
+		args    []string
+		expect  []string
+		wantSet bool
+	}{
+		{name: "command only", args: []string{"kvs", "list"}, expect: []string{"kvs", "list"}, wantSet: true},
+		{name: "global flags precede the command", args: []string{"--region", "x", "kvs", "list"}, expect: []string{"kvs", "list"}, wantSet: true},
+		{name: "global flags after the command", args: []string{"kvs", "list", "--region", "x"}, expect: []string{"kvs", "list"}, wantSet: true},
+		{name: "command without clients", args: []string{"--region", "x", "emulator"}, expect: []string{"emulator"}, wantSet: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			tt.Setenv("AWS_ACCESS_KEY_ID", "dummy_key_id")
+			tt.Setenv("AWS_SECRET_ACCESS_KEY", "dummy_secret_key")
+
+			var ac cli.CLI
+			parser, err := kong.New(&ac)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			kctx, err := parser.Parse(c.args)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			path := cli.Exported_commandPath(kctx)
+			asst.Equal(c.expect, path)
+
+			asst.NoError(cli.Exported_setClient(context.TODO(), path, &ac.Globals))
+			asst.Equal(c.wantSet, ac.CloudFrontClient != nil)
+			asst.Equal(c.wantSet, ac.CloudFrontKeyValueStoreClient != nil)
+		})
+	}
+}
+
+func Test_CLI_waitStatus(t *testing.T) {
+	cases := []struct {
+		name      string
+		args      []string
+		expect    string
+		wantError bool
+	}{
+		{name: "default", args: []string{"kvs", "wait", "--name", "kvs-name"}, expect: "READY"},
+		{name: "provisioning", args: []string{"kvs", "wait", "--name", "kvs-name", "--status", "PROVISIONING"}, expect: "PROVISIONING"},
+		{name: "error: unknown status", args: []string{"kvs", "wait", "--name", "kvs-name", "--status", "READDY"}, wantError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			var ac cli.CLI
+			parser, err := kong.New(&ac)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			_, err = parser.Parse(c.args)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, ac.KVS.Wait.Status)
+		})
+	}
+}
+
+func Test_CLI_output(t *testing.T) {
+	cases := []struct {
+		name      string
+		args      []string
+		expect    output.OutputType
+		wantError bool
+	}{
+		{name: "default", args: []string{"kvs", "list"}, expect: output.OutputTypeTable},
+		{name: "markdown", args: []string{"--output", "markdown", "kvs", "list"}, expect: output.OutputTypeMarkdown},
+		{name: "csv after the command", args: []string{"kvs", "list", "--output=csv"}, expect: output.OutputTypeCsv},
+		{name: "error: unknown output", args: []string{"--output", "xml", "kvs", "list"}, wantError: true},
+		{name: "query", args: []string{"--query", "[].arn", "kvs", "list"}, expect: output.OutputTypeTable},
+		{name: "error: invalid query", args: []string{"--query", "[?status==", "kvs", "list"}, wantError: true},
+		{name: "template", args: []string{"--output", "template", "--template", "{{.}}", "kvs", "list"}, expect: output.OutputTypeTemplate},
+		{name: "error: template without a template", args: []string{"--output", "template", "kvs", "list"}, wantError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			var ac cli.CLI
+			parser, err := kong.New(&ac)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			_, err = parser.Parse(c.args)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, ac.Output)
+		})
+	}
+}
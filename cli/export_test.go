@@ -3,7 +3,8 @@ package cli
 import "fmt"
 
 var (
-	Exported_setClient = setClient
+	Exported_setClient   = setClient
+	Exported_commandPath = commandPath
 )
 
 func SetVersion(v string) {
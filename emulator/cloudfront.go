@@ -0,0 +1,275 @@
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// CloudFront is an in-process client of the CloudFront API backed by the emulator.
+// It implements libs.CloudFrontClient.
+type CloudFront struct {
+	e *Emulator
+}
+
+func (c *CloudFront) ListKeyValueStores(ctx context.Context, params *cloudfront.ListKeyValueStoresInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListKeyValueStoresOutput, error) {
+	if params == nil {
+		params = &cloudfront.ListKeyValueStoresInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	maxItems := int32(defaultPageSize)
+	if params.MaxItems != nil && *params.MaxItems > 0 {
+		maxItems = *params.MaxItems
+	}
+
+	matched := []*store{}
+	for _, s := range e.stores {
+		e.refresh(s)
+		if params.Status != nil && *params.Status != s.status {
+			continue
+		}
+		matched = append(matched, s)
+	}
+
+	start := 0
+	if marker := aws.ToString(params.Marker); marker != "" {
+		start = -1
+		for i, s := range matched {
+			if s.id == marker {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return nil, &cfTypes.InvalidArgument{Message: aws.String(fmt.Sprintf("invalid marker: %s", marker))}
+		}
+	}
+
+	end := start + int(maxItems)
+	var nextMarker *string
+	if end < len(matched) {
+		nextMarker = aws.String(matched[end].id)
+	} else {
+		end = len(matched)
+	}
+
+	items := []cfTypes.KeyValueStore{}
+	for _, s := range matched[start:end] {
+		items = append(items, *s.toCloudFront())
+	}
+
+	return &cloudfront.ListKeyValueStoresOutput{
+		KeyValueStoreList: &cfTypes.KeyValueStoreList{
+			Items:      items,
+			MaxItems:   aws.Int32(maxItems),
+			NextMarker: nextMarker,
+			Quantity:   aws.Int32(int32(len(items))),
+		},
+	}, nil
+}
+
+func (c *CloudFront) CreateKeyValueStore(ctx context.Context, params *cloudfront.CreateKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.CreateKeyValueStoreOutput, error) {
+	if params == nil || !kvsNamePattern.MatchString(aws.ToString(params.Name)) {
+		return nil, &cfTypes.InvalidArgument{Message: aws.String("name must match the pattern ^[a-zA-Z0-9-_]{1,64}$")}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	name := aws.ToString(params.Name)
+	if e.findByName(name) != nil {
+		return nil, &cfTypes.EntityAlreadyExists{Message: aws.String(fmt.Sprintf("the key value store '%s' already exists", name))}
+	}
+	if len(e.stores) >= e.opts.Quotas.MaxKeyValueStores {
+		return nil, &cfTypes.EntityLimitExceeded{Message: aws.String(fmt.Sprintf("the number of key value stores exceeds the limit of %d", e.opts.Quotas.MaxKeyValueStores))}
+	}
+
+	now := e.opts.Now()
+	id := e.newID()
+	s := &store{
+		id:           id,
+		name:         name,
+		comment:      aws.ToString(params.Comment),
+		arn:          fmt.Sprintf("arn:aws:cloudfront::%s:key-value-store/%s", e.opts.AccountID, id),
+		status:       StatusReady,
+		created:      now,
+		lastModified: now,
+		readyAt:      now.Add(e.opts.ProvisioningDuration),
+		cfETag:       e.newCloudFrontETag(),
+		kvsETag:      e.newKeyValueStoreETag(),
+		items:        map[string]string{},
+	}
+	if e.opts.ProvisioningDuration > 0 {
+		s.status = StatusProvisioning
+	}
+
+	if params.ImportSource != nil {
+		if err := e.importItems(ctx, s, *params.ImportSource); err != nil {
+			s.status = StatusFailed
+			s.failureReason = err.Error()
+		}
+	}
+
+	e.stores = append(e.stores, s)
+
+	return &cloudfront.CreateKeyValueStoreOutput{
+		ETag:          aws.String(s.cfETag),
+		KeyValueStore: s.toCloudFront(),
+		Location:      aws.String(fmt.Sprintf("https://cloudfront.amazonaws.com/2020-05-31/key-value-store/%s", s.arn)),
+	}, nil
+}
+
+func (c *CloudFront) DescribeKeyValueStore(ctx context.Context, params *cloudfront.DescribeKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeKeyValueStoreOutput, error) {
+	if params == nil {
+		params = &cloudfront.DescribeKeyValueStoreInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.storeByName(aws.ToString(params.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &cloudfront.DescribeKeyValueStoreOutput{
+		ETag:          aws.String(s.cfETag),
+		KeyValueStore: s.toCloudFront(),
+	}, nil
+}
+
+func (c *CloudFront) UpdateKeyValueStore(ctx context.Context, params *cloudfront.UpdateKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.UpdateKeyValueStoreOutput, error) {
+	if params == nil {
+		params = &cloudfront.UpdateKeyValueStoreInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.storeByName(aws.ToString(params.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCloudFrontIfMatch(s, params.IfMatch); err != nil {
+		return nil, err
+	}
+
+	s.comment = aws.ToString(params.Comment)
+	s.lastModified = e.opts.Now()
+	s.cfETag = e.newCloudFrontETag()
+
+	return &cloudfront.UpdateKeyValueStoreOutput{
+		ETag:          aws.String(s.cfETag),
+		KeyValueStore: s.toCloudFront(),
+	}, nil
+}
+
+func (c *CloudFront) DeleteKeyValueStore(ctx context.Context, params *cloudfront.DeleteKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DeleteKeyValueStoreOutput, error) {
+	if params == nil {
+		params = &cloudfront.DeleteKeyValueStoreInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.storeByName(aws.ToString(params.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkCloudFrontIfMatch(s, params.IfMatch); err != nil {
+		return nil, err
+	}
+	if functions := e.associatedFunctions(s.arn); len(functions) > 0 {
+		return nil, &cfTypes.CannotDeleteEntityWhileInUse{Message: aws.String(fmt.Sprintf("the key value store '%s' is associated with functions: %s", s.name, strings.Join(functions, ", ")))}
+	}
+
+	for i := range e.stores {
+		if e.stores[i] == s {
+			e.stores = append(e.stores[:i], e.stores[i+1:]...)
+			break
+		}
+	}
+
+	return &cloudfront.DeleteKeyValueStoreOutput{}, nil
+}
+
+// storeByName returns the key value store that has the name.
+// The caller must hold e.mu.
+func (e *Emulator) storeByName(name string) (*store, error) {
+	s := e.findByName(name)
+	if s == nil {
+		return nil, &cfTypes.EntityNotFound{Message: aws.String(fmt.Sprintf("the key value store '%s' is not found", name))}
+	}
+
+	e.refresh(s)
+	return s, nil
+}
+
+// importItems loads the items of the import source into the key value store.
+// The caller must hold e.mu.
+func (e *Emulator) importItems(ctx context.Context, s *store, source cfTypes.ImportSource) error {
+	if e.opts.Import == nil {
+		return fmt.Errorf("importing from %s is not supported by the emulator", aws.ToString(source.SourceARN))
+	}
+
+	items, err := e.opts.Import(ctx, source)
+	if err != nil {
+		return err
+	}
+
+	imported := map[string]string{}
+	size := 0
+	for _, item := range items {
+		if err := e.validateItem(item.Key, &item.Value); err != nil {
+			return err
+		}
+		if old, ok := imported[item.Key]; ok {
+			size -= itemSize(item.Key, old)
+		}
+		imported[item.Key] = item.Value
+		size += itemSize(item.Key, item.Value)
+	}
+	if size > e.opts.Quotas.MaxKeyValueStoreSize {
+		return fmt.Errorf("the total size of the import source exceeds the limit of %d bytes", e.opts.Quotas.MaxKeyValueStoreSize)
+	}
+
+	s.items = imported
+	s.size = size
+
+	return nil
+}
+
+func checkCloudFrontIfMatch(s *store, ifMatch *string) error {
+	if ifMatch == nil || *ifMatch == "" {
+		return &cfTypes.InvalidIfMatchVersion{Message: aws.String("the If-Match version is missing or not valid")}
+	}
+	if *ifMatch != s.cfETag {
+		return &cfTypes.PreconditionFailed{Message: aws.String("the precondition in If-Match evaluated to false")}
+	}
+	return nil
+}
+
+func (s *store) toCloudFront() *cfTypes.KeyValueStore {
+	return &cfTypes.KeyValueStore{
+		ARN:              aws.String(s.arn),
+		Comment:          aws.String(s.comment),
+		Id:               aws.String(s.id),
+		LastModifiedTime: aws.Time(s.lastModified),
+		Name:             aws.String(s.name),
+		Status:           aws.String(s.status),
+	}
+}
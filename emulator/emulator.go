@@ -0,0 +1,189 @@
+// Package emulator provides a stateful, in-memory emulator of CloudFront KeyValueStore.
+// It also emulates CloudFront Functions as far as their associations with key value stores.
+//
+// The emulator can be used in two ways.
+//   - In-process: CloudFront and KeyValueStore implement libs.CloudFrontClient and libs.CloudFrontKeyValueStoreClient.
+//   - Over HTTP: Handler serves the CloudFront and CloudFront KeyValueStore APIs, so that cfkvs or the AWS SDK can target it with an endpoint override.
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/michimani/cfkvs/types"
+)
+
+const (
+	DefaultAccountID = "123456789012"
+
+	StatusProvisioning = "PROVISIONING"
+	StatusReady        = "READY"
+	StatusFailed       = "FAILED"
+
+	// defaultPageSize is the number of key value stores or keys returned in a page when the request does not specify it.
+	defaultPageSize = 50
+)
+
+var kvsNamePattern = regexp.MustCompile(`^[a-zA-Z0-9-_]{1,64}$`)
+
+// Quotas are the limits enforced by the emulator.
+type Quotas struct {
+	MaxKeyValueStores        int
+	MaxKeySize               int
+	MaxValueSize             int
+	MaxKeyValueStoreSize     int
+	MaxItemsPerUpdateKeys    int
+	MaxUpdateKeysRequestSize int
+}
+
+// DefaultQuotas returns the quotas of CloudFront KeyValueStore.
+func DefaultQuotas() Quotas {
+	return Quotas{
+		MaxKeyValueStores:        types.MaxKeyValueStores,
+		MaxKeySize:               types.MaxKeySize,
+		MaxValueSize:             types.MaxValueSize,
+		MaxKeyValueStoreSize:     types.MaxKeyValueStoreSize,
+		MaxItemsPerUpdateKeys:    types.MaxItemsPerUpdateKeys,
+		MaxUpdateKeysRequestSize: types.MaxUpdateKeysRequestSize,
+	}
+}
+
+type Options struct {
+	// AccountID is used to build ARNs of key value stores.
+	AccountID string
+
+	Quotas Quotas
+
+	// ProvisioningDuration is how long a created key value store stays in PROVISIONING status.
+	// Zero means that key value stores become READY immediately.
+	ProvisioningDuration time.Duration
+
+	// Import loads the items of the import source when a key value store is created with it.
+	// If it is nil or returns an error, the key value store becomes FAILED.
+	Import func(ctx context.Context, source cfTypes.ImportSource) ([]types.Item, error)
+
+	// Now returns the current time. It is used for timestamps and PROVISIONING status.
+	Now func() time.Time
+}
+
+// Emulator holds the state of key value stores in memory.
+// It is safe for concurrent use.
+type Emulator struct {
+	opts Options
+
+	mu        sync.Mutex
+	seq       int
+	stores    []*store
+	functions []*function
+}
+
+type store struct {
+	id            string
+	name          string
+	comment       string
+	arn           string
+	status        string
+	failureReason string
+	created       time.Time
+	lastModified  time.Time
+	readyAt       time.Time
+
+	// cfETag is the ETag of the CloudFront API, that changes when the key value store itself is updated.
+	cfETag string
+	// kvsETag is the ETag of the CloudFront KeyValueStore API, that changes when the items are updated.
+	kvsETag string
+
+	items map[string]string
+	size  int
+}
+
+// New returns an empty emulator.
+func New(optFns ...func(*Options)) *Emulator {
+	opts := Options{
+		AccountID: DefaultAccountID,
+		Quotas:    DefaultQuotas(),
+		Now:       time.Now,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &Emulator{opts: opts}
+}
+
+// CloudFront returns an in-process client of the CloudFront API backed by the emulator.
+func (e *Emulator) CloudFront() *CloudFront {
+	return &CloudFront{e: e}
+}
+
+// KeyValueStore returns an in-process client of the CloudFront KeyValueStore API backed by the emulator.
+func (e *Emulator) KeyValueStore() *KeyValueStore {
+	return &KeyValueStore{e: e}
+}
+
+func (e *Emulator) nextSeq() int {
+	e.seq++
+	return e.seq
+}
+
+func (e *Emulator) newCloudFrontETag() string {
+	return fmt.Sprintf("E%012X", e.nextSeq())
+}
+
+func (e *Emulator) newKeyValueStoreETag() string {
+	return fmt.Sprintf("KV%012X", e.nextSeq())
+}
+
+func (e *Emulator) newID() string {
+	n := e.nextSeq()
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", n, n)
+}
+
+// findByName returns the key value store that has the name, or nil.
+// The caller must hold e.mu.
+func (e *Emulator) findByName(name string) *store {
+	for _, s := range e.stores {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// findByARN returns the key value store that has the ARN, or nil.
+// The caller must hold e.mu.
+func (e *Emulator) findByARN(arn string) *store {
+	for _, s := range e.stores {
+		if s.arn == arn {
+			return s
+		}
+	}
+	return nil
+}
+
+// refresh updates the status of the key value store that has finished provisioning.
+// The caller must hold e.mu.
+func (e *Emulator) refresh(s *store) {
+	if s.status == StatusProvisioning && !e.opts.Now().Before(s.readyAt) {
+		s.status = StatusReady
+	}
+}
+
+// sortedKeys returns the keys of the key value store in lexicographic order.
+func (s *store) sortedKeys() []string {
+	keys := make([]string, 0, len(s.items))
+	for k := range s.items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func itemSize(key, value string) int {
+	return len(key) + len(value)
+}
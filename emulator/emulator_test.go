@@ -0,0 +1,555 @@
+package emulator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
+	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/michimani/cfkvs/emulator"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ libs.CloudFrontClient              = (*emulator.CloudFront)(nil)
+	_ libs.CloudFrontKeyValueStoreClient = (*emulator.KeyValueStore)(nil)
+)
+
+func createKVS(t *testing.T, e *emulator.Emulator, name string) string {
+	t.Helper()
+
+	out, err := e.CloudFront().CreateKeyValueStore(context.Background(), &cloudfront.CreateKeyValueStoreInput{
+		Name: aws.String(name),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return aws.ToString(out.KeyValueStore.ARN)
+}
+
+func Test_CloudFront_CreateKeyValueStore(t *testing.T) {
+	cases := []struct {
+		name       string
+		opts       func(o *emulator.Options)
+		existing   []string
+		input      *cloudfront.CreateKeyValueStoreInput
+		wantStatus string
+		wantErr    error
+	}{
+		{
+			name:       "ok",
+			input:      &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1")},
+			wantStatus: emulator.StatusReady,
+		},
+		{
+			name:       "ok: provisioning",
+			opts:       func(o *emulator.Options) { o.ProvisioningDuration = time.Minute },
+			input:      &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1")},
+			wantStatus: emulator.StatusProvisioning,
+		},
+		{
+			name: "ok: import source",
+			opts: func(o *emulator.Options) {
+				o.Import = func(ctx context.Context, source cfTypes.ImportSource) ([]types.Item, error) {
+					return []types.Item{{Key: "key1", Value: "value1"}}, nil
+				}
+			},
+			input: &cloudfront.CreateKeyValueStoreInput{
+				Name:         aws.String("kvs-1"),
+				ImportSource: &cfTypes.ImportSource{SourceARN: aws.String("arn:aws:s3:::bucket/key"), SourceType: cfTypes.ImportSourceTypeS3},
+			},
+			wantStatus: emulator.StatusReady,
+		},
+		{
+			name: "failed: import source is not supported",
+			input: &cloudfront.CreateKeyValueStoreInput{
+				Name:         aws.String("kvs-1"),
+				ImportSource: &cfTypes.ImportSource{SourceARN: aws.String("arn:aws:s3:::bucket/key"), SourceType: cfTypes.ImportSourceTypeS3},
+			},
+			wantStatus: emulator.StatusFailed,
+		},
+		{
+			name:    "error: invalid name",
+			input:   &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs 1")},
+			wantErr: &cfTypes.InvalidArgument{},
+		},
+		{
+			name:     "error: already exists",
+			existing: []string{"kvs-1"},
+			input:    &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1")},
+			wantErr:  &cfTypes.EntityAlreadyExists{},
+		},
+		{
+			name: "error: limit exceeded",
+			opts: func(o *emulator.Options) {
+				o.Quotas.MaxKeyValueStores = 1
+			},
+			existing: []string{"kvs-0"},
+			input:    &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1")},
+			wantErr:  &cfTypes.EntityLimitExceeded{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			optFns := []func(*emulator.Options){}
+			if c.opts != nil {
+				optFns = append(optFns, c.opts)
+			}
+			e := emulator.New(optFns...)
+			for _, name := range c.existing {
+				createKVS(tt, e, name)
+			}
+
+			out, err := e.CloudFront().CreateKeyValueStore(context.Background(), c.input)
+			if c.wantErr != nil {
+				asst.Error(err)
+				asst.IsType(c.wantErr, err)
+				asst.Nil(out)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.wantStatus, aws.ToString(out.KeyValueStore.Status))
+			asst.Equal(fmt.Sprintf("arn:aws:cloudfront::%s:key-value-store/%s", emulator.DefaultAccountID, aws.ToString(out.KeyValueStore.Id)), aws.ToString(out.KeyValueStore.ARN))
+			asst.NotEmpty(aws.ToString(out.ETag))
+		})
+	}
+}
+
+func Test_CloudFront_Provisioning(t *testing.T) {
+	asst := assert.New(t)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := emulator.New(func(o *emulator.Options) {
+		o.ProvisioningDuration = time.Minute
+		o.Now = func() time.Time { return now }
+	})
+	arn := createKVS(t, e, "kvs-1")
+
+	_, err := e.KeyValueStore().UpdateKeys(context.Background(), &kvs.UpdateKeysInput{
+		KvsARN:  aws.String(arn),
+		IfMatch: aws.String("any"),
+	})
+	asst.IsType(&kvsTypes.ConflictException{}, err)
+
+	now = now.Add(time.Minute)
+	out, err := e.CloudFront().DescribeKeyValueStore(context.Background(), &cloudfront.DescribeKeyValueStoreInput{Name: aws.String("kvs-1")})
+	asst.NoError(err)
+	asst.Equal(emulator.StatusReady, aws.ToString(out.KeyValueStore.Status))
+}
+
+func Test_CloudFront_ListKeyValueStores(t *testing.T) {
+	asst := assert.New(t)
+
+	e := emulator.New()
+	for i := 0; i < 5; i++ {
+		createKVS(t, e, fmt.Sprintf("kvs-%d", i))
+	}
+
+	names := []string{}
+	var marker *string
+	for {
+		out, err := e.CloudFront().ListKeyValueStores(context.Background(), &cloudfront.ListKeyValueStoresInput{
+			Marker:   marker,
+			MaxItems: aws.Int32(2),
+		})
+		asst.NoError(err)
+		for _, k := range out.KeyValueStoreList.Items {
+			names = append(names, aws.ToString(k.Name))
+		}
+		if out.KeyValueStoreList.NextMarker == nil {
+			break
+		}
+		marker = out.KeyValueStoreList.NextMarker
+	}
+
+	asst.Equal([]string{"kvs-0", "kvs-1", "kvs-2", "kvs-3", "kvs-4"}, names)
+}
+
+func Test_CloudFront_DeleteKeyValueStore(t *testing.T) {
+	cases := []struct {
+		name    string
+		kvsName string
+		ifMatch func(etag string) *string
+		wantErr error
+	}{
+		{
+			name:    "ok",
+			kvsName: "kvs-1",
+			ifMatch: func(etag string) *string { return aws.String(etag) },
+		},
+		{
+			name:    "error: not found",
+			kvsName: "not-found",
+			ifMatch: func(etag string) *string { return aws.String(etag) },
+			wantErr: &cfTypes.EntityNotFound{},
+		},
+		{
+			name:    "error: If-Match is missing",
+			kvsName: "kvs-1",
+			ifMatch: func(etag string) *string { return nil },
+			wantErr: &cfTypes.InvalidIfMatchVersion{},
+		},
+		{
+			name:    "error: If-Match does not match",
+			kvsName: "kvs-1",
+			ifMatch: func(etag string) *string { return aws.String("old") },
+			wantErr: &cfTypes.PreconditionFailed{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e := emulator.New()
+			cf := e.CloudFront()
+			created, err := cf.CreateKeyValueStore(context.Background(), &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1")})
+			asst.NoError(err)
+
+			_, err = cf.DeleteKeyValueStore(context.Background(), &cloudfront.DeleteKeyValueStoreInput{
+				Name:    aws.String(c.kvsName),
+				IfMatch: c.ifMatch(aws.ToString(created.ETag)),
+			})
+			if c.wantErr != nil {
+				asst.IsType(c.wantErr, err)
+				return
+			}
+
+			asst.NoError(err)
+			_, err = cf.DescribeKeyValueStore(context.Background(), &cloudfront.DescribeKeyValueStoreInput{Name: aws.String(c.kvsName)})
+			asst.IsType(&cfTypes.EntityNotFound{}, err)
+		})
+	}
+}
+
+func Test_CloudFront_Functions(t *testing.T) {
+	asst := assert.New(t)
+	ctx := context.Background()
+
+	e := emulator.New()
+	cf := e.CloudFront()
+	created, err := cf.CreateKeyValueStore(ctx, &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1")})
+	asst.NoError(err)
+	kvsARN := aws.ToString(created.KeyValueStore.ARN)
+
+	config := func(arns ...string) *cfTypes.FunctionConfig {
+		items := []cfTypes.KeyValueStoreAssociation{}
+		for _, arn := range arns {
+			items = append(items, cfTypes.KeyValueStoreAssociation{KeyValueStoreARN: aws.String(arn)})
+		}
+		return &cfTypes.FunctionConfig{
+			Runtime:                   cfTypes.FunctionRuntimeCloudfrontJs20,
+			KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{Quantity: aws.Int32(int32(len(items))), Items: items},
+		}
+	}
+
+	// create
+	fn, err := cf.CreateFunction(ctx, &cloudfront.CreateFunctionInput{Name: aws.String("fn-1"), FunctionConfig: config(kvsARN)})
+	asst.NoError(err)
+	asst.Equal(emulator.FunctionStatusUnpublished, aws.ToString(fn.FunctionSummary.Status))
+	asst.Equal(cfTypes.FunctionStageDevelopment, fn.FunctionSummary.FunctionMetadata.Stage)
+
+	_, err = cf.CreateFunction(ctx, &cloudfront.CreateFunctionInput{Name: aws.String("fn-1"), FunctionConfig: config()})
+	asst.IsType(&cfTypes.FunctionAlreadyExists{}, err)
+	_, err = cf.CreateFunction(ctx, &cloudfront.CreateFunctionInput{Name: aws.String("fn-2"), FunctionConfig: config("arn:aws:cloudfront::123456789012:key-value-store/none")})
+	asst.IsType(&cfTypes.EntityNotFound{}, err)
+	_, err = cf.CreateFunction(ctx, &cloudfront.CreateFunctionInput{Name: aws.String("fn-2"), FunctionConfig: config()})
+	asst.NoError(err)
+
+	// the LIVE stage does not exist until the function is published
+	_, err = cf.DescribeFunction(ctx, &cloudfront.DescribeFunctionInput{Name: aws.String("fn-1"), Stage: cfTypes.FunctionStageLive})
+	asst.IsType(&cfTypes.NoSuchFunctionExists{}, err)
+	_, err = cf.DescribeFunction(ctx, &cloudfront.DescribeFunctionInput{Name: aws.String("none")})
+	asst.IsType(&cfTypes.NoSuchFunctionExists{}, err)
+
+	// publish
+	_, err = cf.PublishFunction(ctx, &cloudfront.PublishFunctionInput{Name: aws.String("fn-1"), IfMatch: aws.String("old")})
+	asst.IsType(&cfTypes.PreconditionFailed{}, err)
+	published, err := cf.PublishFunction(ctx, &cloudfront.PublishFunctionInput{Name: aws.String("fn-1"), IfMatch: fn.ETag})
+	asst.NoError(err)
+	asst.Equal(emulator.FunctionStatusUnassociated, aws.ToString(published.FunctionSummary.Status))
+	asst.Equal(cfTypes.FunctionStageLive, published.FunctionSummary.FunctionMetadata.Stage)
+
+	// list
+	list, err := cf.ListFunctions(ctx, &cloudfront.ListFunctionsInput{MaxItems: aws.Int32(2)})
+	asst.NoError(err)
+	asst.Len(list.FunctionList.Items, 2)
+	asst.NotNil(list.FunctionList.NextMarker)
+	next, err := cf.ListFunctions(ctx, &cloudfront.ListFunctionsInput{Marker: list.FunctionList.NextMarker})
+	asst.NoError(err)
+	if asst.Len(next.FunctionList.Items, 1) {
+		asst.Equal("fn-2", aws.ToString(next.FunctionList.Items[0].Name))
+	}
+	live, err := cf.ListFunctions(ctx, &cloudfront.ListFunctionsInput{Stage: cfTypes.FunctionStageLive})
+	asst.NoError(err)
+	asst.Len(live.FunctionList.Items, 1)
+
+	// a key value store cannot be deleted while it is associated with a function
+	describedKVS, err := cf.DescribeKeyValueStore(ctx, &cloudfront.DescribeKeyValueStoreInput{Name: aws.String("kvs-1")})
+	asst.NoError(err)
+	_, err = cf.DeleteKeyValueStore(ctx, &cloudfront.DeleteKeyValueStoreInput{Name: aws.String("kvs-1"), IfMatch: describedKVS.ETag})
+	asst.IsType(&cfTypes.CannotDeleteEntityWhileInUse{}, err)
+
+	_, err = cf.DeleteFunction(ctx, &cloudfront.DeleteFunctionInput{Name: aws.String("fn-1"), IfMatch: fn.ETag})
+	asst.NoError(err)
+	_, err = cf.DeleteKeyValueStore(ctx, &cloudfront.DeleteKeyValueStoreInput{Name: aws.String("kvs-1"), IfMatch: describedKVS.ETag})
+	asst.NoError(err)
+}
+
+func Test_CloudFront_UpdateKeyValueStore(t *testing.T) {
+	cases := []struct {
+		name    string
+		kvsName string
+		ifMatch func(etag string) *string
+		wantErr error
+	}{
+		{
+			name:    "ok",
+			kvsName: "kvs-1",
+			ifMatch: func(etag string) *string { return aws.String(etag) },
+		},
+		{
+			name:    "error: not found",
+			kvsName: "not-found",
+			ifMatch: func(etag string) *string { return aws.String(etag) },
+			wantErr: &cfTypes.EntityNotFound{},
+		},
+		{
+			name:    "error: If-Match does not match",
+			kvsName: "kvs-1",
+			ifMatch: func(etag string) *string { return aws.String("old") },
+			wantErr: &cfTypes.PreconditionFailed{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e := emulator.New()
+			cf := e.CloudFront()
+			created, err := cf.CreateKeyValueStore(context.Background(), &cloudfront.CreateKeyValueStoreInput{Name: aws.String("kvs-1"), Comment: aws.String("old comment")})
+			asst.NoError(err)
+
+			out, err := cf.UpdateKeyValueStore(context.Background(), &cloudfront.UpdateKeyValueStoreInput{
+				Name:    aws.String(c.kvsName),
+				Comment: aws.String("new comment"),
+				IfMatch: c.ifMatch(aws.ToString(created.ETag)),
+			})
+			if c.wantErr != nil {
+				asst.IsType(c.wantErr, err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal("new comment", aws.ToString(out.KeyValueStore.Comment))
+			asst.NotEqual(aws.ToString(created.ETag), aws.ToString(out.ETag))
+
+			described, err := cf.DescribeKeyValueStore(context.Background(), &cloudfront.DescribeKeyValueStoreInput{Name: aws.String(c.kvsName)})
+			asst.NoError(err)
+			asst.Equal("new comment", aws.ToString(described.KeyValueStore.Comment))
+			asst.Equal(aws.ToString(out.ETag), aws.ToString(described.ETag))
+		})
+	}
+}
+
+func Test_KeyValueStore_UpdateKeys(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    func(o *emulator.Options)
+		input   func(arn, etag string) *kvs.UpdateKeysInput
+		wantErr error
+	}{
+		{
+			name: "ok",
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{
+					KvsARN:  aws.String(arn),
+					IfMatch: aws.String(etag),
+					Puts:    []kvsTypes.PutKeyRequestListItem{{Key: aws.String("key2"), Value: aws.String("value2")}},
+					Deletes: []kvsTypes.DeleteKeyRequestListItem{{Key: aws.String("key1")}},
+				}
+			},
+		},
+		{
+			name: "error: not found",
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{KvsARN: aws.String("not-found"), IfMatch: aws.String(etag)}
+			},
+			wantErr: &kvsTypes.ResourceNotFoundException{},
+		},
+		{
+			name: "error: IfMatch is missing",
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{KvsARN: aws.String(arn)}
+			},
+			wantErr: &kvsTypes.ValidationException{},
+		},
+		{
+			name: "error: IfMatch does not match",
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{KvsARN: aws.String(arn), IfMatch: aws.String("old")}
+			},
+			wantErr: &kvsTypes.ConflictException{},
+		},
+		{
+			name: "error: too many keys",
+			opts: func(o *emulator.Options) { o.Quotas.MaxItemsPerUpdateKeys = 1 },
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{
+					KvsARN:  aws.String(arn),
+					IfMatch: aws.String(etag),
+					Puts: []kvsTypes.PutKeyRequestListItem{
+						{Key: aws.String("key2"), Value: aws.String("value2")},
+						{Key: aws.String("key3"), Value: aws.String("value3")},
+					},
+				}
+			},
+			wantErr: &kvsTypes.ValidationException{},
+		},
+		{
+			name: "error: duplicated key",
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{
+					KvsARN:  aws.String(arn),
+					IfMatch: aws.String(etag),
+					Puts:    []kvsTypes.PutKeyRequestListItem{{Key: aws.String("key1"), Value: aws.String("value")}},
+					Deletes: []kvsTypes.DeleteKeyRequestListItem{{Key: aws.String("key1")}},
+				}
+			},
+			wantErr: &kvsTypes.ValidationException{},
+		},
+		{
+			name: "error: value is too large",
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{
+					KvsARN:  aws.String(arn),
+					IfMatch: aws.String(etag),
+					Puts:    []kvsTypes.PutKeyRequestListItem{{Key: aws.String("key2"), Value: aws.String(strings.Repeat("v", types.MaxValueSize+1))}},
+				}
+			},
+			wantErr: &kvsTypes.ValidationException{},
+		},
+		{
+			name: "error: request is too large",
+			opts: func(o *emulator.Options) { o.Quotas.MaxUpdateKeysRequestSize = 9 },
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{
+					KvsARN:  aws.String(arn),
+					IfMatch: aws.String(etag),
+					Puts:    []kvsTypes.PutKeyRequestListItem{{Key: aws.String("key2"), Value: aws.String("value2")}},
+				}
+			},
+			wantErr: &kvsTypes.ValidationException{},
+		},
+		{
+			name: "error: key value store is too large",
+			opts: func(o *emulator.Options) { o.Quotas.MaxKeyValueStoreSize = 15 },
+			input: func(arn, etag string) *kvs.UpdateKeysInput {
+				return &kvs.UpdateKeysInput{
+					KvsARN:  aws.String(arn),
+					IfMatch: aws.String(etag),
+					Puts:    []kvsTypes.PutKeyRequestListItem{{Key: aws.String("key2"), Value: aws.String("value2")}},
+				}
+			},
+			wantErr: &kvsTypes.ServiceQuotaExceededException{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			optFns := []func(*emulator.Options){}
+			if c.opts != nil {
+				optFns = append(optFns, c.opts)
+			}
+			e := emulator.New(optFns...)
+			kvsc := e.KeyValueStore()
+			arn := createKVS(tt, e, "kvs-1")
+
+			d, err := kvsc.DescribeKeyValueStore(context.Background(), &kvs.DescribeKeyValueStoreInput{KvsARN: aws.String(arn)})
+			asst.NoError(err)
+			put, err := kvsc.PutKey(context.Background(), &kvs.PutKeyInput{
+				KvsARN:  aws.String(arn),
+				Key:     aws.String("key1"),
+				Value:   aws.String("value1"),
+				IfMatch: d.ETag,
+			})
+			asst.NoError(err)
+
+			out, err := kvsc.UpdateKeys(context.Background(), c.input(arn, aws.ToString(put.ETag)))
+			if c.wantErr != nil {
+				asst.IsType(c.wantErr, err)
+				asst.Nil(out)
+
+				// a failed request must not change the key value store
+				got, err := kvsc.GetKey(context.Background(), &kvs.GetKeyInput{KvsARN: aws.String(arn), Key: aws.String("key1")})
+				asst.NoError(err)
+				asst.Equal("value1", aws.ToString(got.Value))
+				asst.Equal(int32(1), aws.ToInt32(got.ItemCount))
+				return
+			}
+
+			asst.NoError(err)
+			asst.NotEqual(aws.ToString(put.ETag), aws.ToString(out.ETag))
+			asst.Equal(int32(1), aws.ToInt32(out.ItemCount))
+			asst.Equal(int64(len("key2")+len("value2")), aws.ToInt64(out.TotalSizeInBytes))
+
+			_, err = kvsc.GetKey(context.Background(), &kvs.GetKeyInput{KvsARN: aws.String(arn), Key: aws.String("key1")})
+			asst.IsType(&kvsTypes.ResourceNotFoundException{}, err)
+		})
+	}
+}
+
+func Test_KeyValueStore_ListKeys(t *testing.T) {
+	asst := assert.New(t)
+
+	e := emulator.New()
+	kvsc := e.KeyValueStore()
+	arn := createKVS(t, e, "kvs-1")
+
+	putList := []types.Item{}
+	for i := 0; i < 120; i++ {
+		putList = append(putList, types.Item{Key: fmt.Sprintf("key%03d", i), Value: fmt.Sprintf("value%03d", i)})
+	}
+	_, err := libs.SyncItems(context.Background(), kvsc, arn, putList, nil)
+	asst.NoError(err)
+
+	list, err := libs.ListItems(context.Background(), kvsc, arn)
+	asst.NoError(err)
+	asst.Equal(putList, list.Data)
+}
+
+func Test_KeyValueStore_DeleteKey(t *testing.T) {
+	asst := assert.New(t)
+
+	e := emulator.New()
+	kvsc := e.KeyValueStore()
+	arn := createKVS(t, e, "kvs-1")
+
+	d, err := kvsc.DescribeKeyValueStore(context.Background(), &kvs.DescribeKeyValueStoreInput{KvsARN: aws.String(arn)})
+	asst.NoError(err)
+
+	_, err = kvsc.DeleteKey(context.Background(), &kvs.DeleteKeyInput{
+		KvsARN:  aws.String(arn),
+		Key:     aws.String("not-found"),
+		IfMatch: d.ETag,
+	})
+	var notFound *kvsTypes.ResourceNotFoundException
+	asst.True(errors.As(err, &notFound))
+}
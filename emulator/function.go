@@ -0,0 +1,350 @@
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+const (
+	FunctionStatusUnpublished  = "UNPUBLISHED"
+	FunctionStatusUnassociated = "UNASSOCIATED"
+)
+
+// function is a CloudFront Function. It is not run by the emulator, and it only holds the associations with key value stores.
+// Distributions are not emulated, so a published function is always UNASSOCIATED.
+type function struct {
+	name string
+	arn  string
+	eTag string
+
+	development functionConfig
+	// live is nil until the function is published.
+	live *functionConfig
+}
+
+type functionConfig struct {
+	comment      string
+	runtime      cfTypes.FunctionRuntime
+	code         []byte
+	kvsARNs      []string
+	created      time.Time
+	lastModified time.Time
+}
+
+// CreateFunction creates a function in the DEVELOPMENT stage.
+// The key value stores of the associations must exist.
+func (c *CloudFront) CreateFunction(ctx context.Context, params *cloudfront.CreateFunctionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.CreateFunctionOutput, error) {
+	if params == nil || !kvsNamePattern.MatchString(aws.ToString(params.Name)) {
+		return nil, &cfTypes.InvalidArgument{Message: aws.String("name must match the pattern ^[a-zA-Z0-9-_]{1,64}$")}
+	}
+	if params.FunctionConfig == nil {
+		return nil, &cfTypes.InvalidArgument{Message: aws.String("function config is required")}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	name := aws.ToString(params.Name)
+	if e.findFunction(name) != nil {
+		return nil, &cfTypes.FunctionAlreadyExists{Message: aws.String(fmt.Sprintf("the function '%s' already exists", name))}
+	}
+
+	kvsARNs := []string{}
+	if a := params.FunctionConfig.KeyValueStoreAssociations; a != nil {
+		for _, item := range a.Items {
+			arn := aws.ToString(item.KeyValueStoreARN)
+			if e.findByARN(arn) == nil {
+				return nil, &cfTypes.EntityNotFound{Message: aws.String(fmt.Sprintf("the key value store '%s' is not found", arn))}
+			}
+			kvsARNs = append(kvsARNs, arn)
+		}
+	}
+	if len(kvsARNs) > 1 {
+		return nil, &cfTypes.InvalidArgument{Message: aws.String("a function can be associated with one key value store at most")}
+	}
+
+	now := e.opts.Now()
+	f := &function{
+		name: name,
+		arn:  fmt.Sprintf("arn:aws:cloudfront::%s:function/%s", e.opts.AccountID, name),
+		eTag: e.newCloudFrontETag(),
+		development: functionConfig{
+			comment:      aws.ToString(params.FunctionConfig.Comment),
+			runtime:      params.FunctionConfig.Runtime,
+			code:         params.FunctionCode,
+			kvsARNs:      kvsARNs,
+			created:      now,
+			lastModified: now,
+		},
+	}
+	e.functions = append(e.functions, f)
+
+	return &cloudfront.CreateFunctionOutput{
+		ETag:            aws.String(f.eTag),
+		FunctionSummary: f.toSummary(cfTypes.FunctionStageDevelopment),
+		Location:        aws.String(fmt.Sprintf("https://cloudfront.amazonaws.com/2020-05-31/function/%s", f.arn)),
+	}, nil
+}
+
+// PublishFunction copies the DEVELOPMENT stage of the function to the LIVE stage.
+func (c *CloudFront) PublishFunction(ctx context.Context, params *cloudfront.PublishFunctionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.PublishFunctionOutput, error) {
+	if params == nil {
+		params = &cloudfront.PublishFunctionInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	f, err := e.functionByName(aws.ToString(params.Name))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFunctionIfMatch(f, params.IfMatch); err != nil {
+		return nil, err
+	}
+
+	live := f.development
+	live.kvsARNs = append([]string{}, f.development.kvsARNs...)
+	live.lastModified = e.opts.Now()
+	if f.live != nil {
+		live.created = f.live.created
+	} else {
+		live.created = live.lastModified
+	}
+	f.live = &live
+
+	return &cloudfront.PublishFunctionOutput{
+		FunctionSummary: f.toSummary(cfTypes.FunctionStageLive),
+	}, nil
+}
+
+func (c *CloudFront) DeleteFunction(ctx context.Context, params *cloudfront.DeleteFunctionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DeleteFunctionOutput, error) {
+	if params == nil {
+		params = &cloudfront.DeleteFunctionInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	f, err := e.functionByName(aws.ToString(params.Name))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFunctionIfMatch(f, params.IfMatch); err != nil {
+		return nil, err
+	}
+
+	for i := range e.functions {
+		if e.functions[i] == f {
+			e.functions = append(e.functions[:i], e.functions[i+1:]...)
+			break
+		}
+	}
+
+	return &cloudfront.DeleteFunctionOutput{}, nil
+}
+
+func (c *CloudFront) DescribeFunction(ctx context.Context, params *cloudfront.DescribeFunctionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeFunctionOutput, error) {
+	if params == nil {
+		params = &cloudfront.DescribeFunctionInput{}
+	}
+
+	stage := params.Stage
+	if stage == "" {
+		stage = cfTypes.FunctionStageDevelopment
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	f, err := e.functionByName(aws.ToString(params.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	summary := f.toSummary(stage)
+	if summary == nil {
+		return nil, &cfTypes.NoSuchFunctionExists{Message: aws.String(fmt.Sprintf("the function '%s' does not have the %s stage", f.name, stage))}
+	}
+
+	return &cloudfront.DescribeFunctionOutput{
+		ETag:            aws.String(f.eTag),
+		FunctionSummary: summary,
+	}, nil
+}
+
+// ListFunctions lists the functions, a summary for each stage.
+// The marker is the name and the stage of the first summary in the page.
+func (c *CloudFront) ListFunctions(ctx context.Context, params *cloudfront.ListFunctionsInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListFunctionsOutput, error) {
+	if params == nil {
+		params = &cloudfront.ListFunctionsInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	maxItems := int32(defaultPageSize)
+	if params.MaxItems != nil && *params.MaxItems > 0 {
+		maxItems = *params.MaxItems
+	}
+
+	matched := []*cfTypes.FunctionSummary{}
+	for _, f := range e.functions {
+		for _, stage := range []cfTypes.FunctionStage{cfTypes.FunctionStageDevelopment, cfTypes.FunctionStageLive} {
+			if params.Stage != "" && params.Stage != stage {
+				continue
+			}
+			if summary := f.toSummary(stage); summary != nil {
+				matched = append(matched, summary)
+			}
+		}
+	}
+
+	start := 0
+	if marker := aws.ToString(params.Marker); marker != "" {
+		start = -1
+		for i, summary := range matched {
+			if functionMarker(summary) == marker {
+				start = i
+				break
+			}
+		}
+		if start < 0 {
+			return nil, &cfTypes.InvalidArgument{Message: aws.String(fmt.Sprintf("invalid marker: %s", marker))}
+		}
+	}
+
+	end := start + int(maxItems)
+	var nextMarker *string
+	if end < len(matched) {
+		nextMarker = aws.String(functionMarker(matched[end]))
+	} else {
+		end = len(matched)
+	}
+
+	items := []cfTypes.FunctionSummary{}
+	for _, summary := range matched[start:end] {
+		items = append(items, *summary)
+	}
+
+	return &cloudfront.ListFunctionsOutput{
+		FunctionList: &cfTypes.FunctionList{
+			Items:      items,
+			MaxItems:   aws.Int32(maxItems),
+			NextMarker: nextMarker,
+			Quantity:   aws.Int32(int32(len(items))),
+		},
+	}, nil
+}
+
+func functionMarker(summary *cfTypes.FunctionSummary) string {
+	return fmt.Sprintf("%s:%s", aws.ToString(summary.Name), summary.FunctionMetadata.Stage)
+}
+
+// findFunction returns the function that has the name, or nil.
+// The caller must hold e.mu.
+func (e *Emulator) findFunction(name string) *function {
+	for _, f := range e.functions {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// functionByName returns the function that has the name.
+// The caller must hold e.mu.
+func (e *Emulator) functionByName(name string) (*function, error) {
+	f := e.findFunction(name)
+	if f == nil {
+		return nil, &cfTypes.NoSuchFunctionExists{Message: aws.String(fmt.Sprintf("the function '%s' does not exist", name))}
+	}
+	return f, nil
+}
+
+// associatedFunctions returns the names of the functions that have a stage associated with the key value store.
+// The caller must hold e.mu.
+func (e *Emulator) associatedFunctions(kvsARN string) []string {
+	names := []string{}
+	for _, f := range e.functions {
+		for _, config := range []*functionConfig{&f.development, f.live} {
+			if config != nil && config.isAssociated(kvsARN) {
+				names = append(names, f.name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+func (fc *functionConfig) isAssociated(kvsARN string) bool {
+	for _, arn := range fc.kvsARNs {
+		if arn == kvsARN {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFunctionIfMatch(f *function, ifMatch *string) error {
+	if ifMatch == nil || *ifMatch == "" {
+		return &cfTypes.InvalidIfMatchVersion{Message: aws.String("the If-Match version is missing or not valid")}
+	}
+	if *ifMatch != f.eTag {
+		return &cfTypes.PreconditionFailed{Message: aws.String("the precondition in If-Match evaluated to false")}
+	}
+	return nil
+}
+
+// toSummary returns the summary of the stage, or nil if the function does not have the stage.
+func (f *function) toSummary(stage cfTypes.FunctionStage) *cfTypes.FunctionSummary {
+	config := &f.development
+	status := FunctionStatusUnpublished
+	if f.live != nil {
+		status = FunctionStatusUnassociated
+	}
+
+	switch stage {
+	case cfTypes.FunctionStageDevelopment:
+	case cfTypes.FunctionStageLive:
+		if f.live == nil {
+			return nil
+		}
+		config = f.live
+	default:
+		return nil
+	}
+
+	associations := &cfTypes.KeyValueStoreAssociations{
+		Quantity: aws.Int32(int32(len(config.kvsARNs))),
+	}
+	for _, arn := range config.kvsARNs {
+		associations.Items = append(associations.Items, cfTypes.KeyValueStoreAssociation{KeyValueStoreARN: aws.String(arn)})
+	}
+
+	return &cfTypes.FunctionSummary{
+		Name:   aws.String(f.name),
+		Status: aws.String(status),
+		FunctionConfig: &cfTypes.FunctionConfig{
+			Comment:                   aws.String(config.comment),
+			Runtime:                   config.runtime,
+			KeyValueStoreAssociations: associations,
+		},
+		FunctionMetadata: &cfTypes.FunctionMetadata{
+			FunctionARN:      aws.String(f.arn),
+			Stage:            stage,
+			CreatedTime:      aws.Time(config.created),
+			LastModifiedTime: aws.Time(config.lastModified),
+		},
+	}
+}
@@ -0,0 +1,329 @@
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
+	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+)
+
+// KeyValueStore is an in-process client of the CloudFront KeyValueStore API backed by the emulator.
+// It implements libs.CloudFrontKeyValueStoreClient.
+type KeyValueStore struct {
+	e *Emulator
+}
+
+func (c *KeyValueStore) DescribeKeyValueStore(ctx context.Context, params *kvs.DescribeKeyValueStoreInput, optFns ...func(*kvs.Options)) (*kvs.DescribeKeyValueStoreOutput, error) {
+	if params == nil {
+		params = &kvs.DescribeKeyValueStoreInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.storeByARN(aws.ToString(params.KvsARN))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &kvs.DescribeKeyValueStoreOutput{
+		Created:          aws.Time(s.created),
+		ETag:             aws.String(s.kvsETag),
+		ItemCount:        aws.Int32(int32(len(s.items))),
+		KvsARN:           aws.String(s.arn),
+		TotalSizeInBytes: aws.Int64(int64(s.size)),
+		LastModified:     aws.Time(s.lastModified),
+		Status:           aws.String(s.status),
+	}
+	if s.failureReason != "" {
+		out.FailureReason = aws.String(s.failureReason)
+	}
+
+	return out, nil
+}
+
+func (c *KeyValueStore) ListKeys(ctx context.Context, params *kvs.ListKeysInput, optFns ...func(*kvs.Options)) (*kvs.ListKeysOutput, error) {
+	if params == nil {
+		params = &kvs.ListKeysInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.storeByARN(aws.ToString(params.KvsARN))
+	if err != nil {
+		return nil, err
+	}
+
+	maxResults := defaultPageSize
+	if params.MaxResults != nil && *params.MaxResults > 0 {
+		maxResults = int(*params.MaxResults)
+	}
+
+	keys := s.sortedKeys()
+
+	// NextToken is the key of the first item in the next page.
+	start := 0
+	if token := aws.ToString(params.NextToken); token != "" {
+		start = sort.SearchStrings(keys, token)
+	}
+
+	end := start + maxResults
+	var nextToken *string
+	if end < len(keys) {
+		nextToken = aws.String(keys[end])
+	} else {
+		end = len(keys)
+	}
+
+	items := []kvsTypes.ListKeysResponseListItem{}
+	for _, k := range keys[start:end] {
+		items = append(items, kvsTypes.ListKeysResponseListItem{
+			Key:   aws.String(k),
+			Value: aws.String(s.items[k]),
+		})
+	}
+
+	return &kvs.ListKeysOutput{
+		Items:     items,
+		NextToken: nextToken,
+	}, nil
+}
+
+func (c *KeyValueStore) GetKey(ctx context.Context, params *kvs.GetKeyInput, optFns ...func(*kvs.Options)) (*kvs.GetKeyOutput, error) {
+	if params == nil {
+		params = &kvs.GetKeyInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.storeByARN(aws.ToString(params.KvsARN))
+	if err != nil {
+		return nil, err
+	}
+
+	key := aws.ToString(params.Key)
+	value, ok := s.items[key]
+	if !ok {
+		return nil, &kvsTypes.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("the key '%s' is not found", key))}
+	}
+
+	return &kvs.GetKeyOutput{
+		ItemCount:        aws.Int32(int32(len(s.items))),
+		Key:              aws.String(key),
+		TotalSizeInBytes: aws.Int64(int64(s.size)),
+		Value:            aws.String(value),
+	}, nil
+}
+
+func (c *KeyValueStore) PutKey(ctx context.Context, params *kvs.PutKeyInput, optFns ...func(*kvs.Options)) (*kvs.PutKeyOutput, error) {
+	if params == nil {
+		params = &kvs.PutKeyInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.writableStore(aws.ToString(params.KvsARN), params.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	key := aws.ToString(params.Key)
+	if err := e.validateItem(key, params.Value); err != nil {
+		return nil, err
+	}
+
+	items := s.copyItems()
+	items[key] = aws.ToString(params.Value)
+	if err := e.commit(s, items); err != nil {
+		return nil, err
+	}
+
+	return &kvs.PutKeyOutput{
+		ETag:             aws.String(s.kvsETag),
+		ItemCount:        aws.Int32(int32(len(s.items))),
+		TotalSizeInBytes: aws.Int64(int64(s.size)),
+	}, nil
+}
+
+func (c *KeyValueStore) DeleteKey(ctx context.Context, params *kvs.DeleteKeyInput, optFns ...func(*kvs.Options)) (*kvs.DeleteKeyOutput, error) {
+	if params == nil {
+		params = &kvs.DeleteKeyInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.writableStore(aws.ToString(params.KvsARN), params.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	key := aws.ToString(params.Key)
+	if _, ok := s.items[key]; !ok {
+		return nil, &kvsTypes.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("the key '%s' is not found", key))}
+	}
+
+	items := s.copyItems()
+	delete(items, key)
+	if err := e.commit(s, items); err != nil {
+		return nil, err
+	}
+
+	return &kvs.DeleteKeyOutput{
+		ETag:             aws.String(s.kvsETag),
+		ItemCount:        aws.Int32(int32(len(s.items))),
+		TotalSizeInBytes: aws.Int64(int64(s.size)),
+	}, nil
+}
+
+func (c *KeyValueStore) UpdateKeys(ctx context.Context, params *kvs.UpdateKeysInput, optFns ...func(*kvs.Options)) (*kvs.UpdateKeysOutput, error) {
+	if params == nil {
+		params = &kvs.UpdateKeysInput{}
+	}
+
+	e := c.e
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s, err := e.writableStore(aws.ToString(params.KvsARN), params.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas := e.opts.Quotas
+	if n := len(params.Puts) + len(params.Deletes); n > quotas.MaxItemsPerUpdateKeys {
+		return nil, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("the number of keys in a request (%d) exceeds the limit of %d", n, quotas.MaxItemsPerUpdateKeys))}
+	}
+
+	requestSize := 0
+	touched := map[string]bool{}
+	items := s.copyItems()
+
+	for _, d := range params.Deletes {
+		key := aws.ToString(d.Key)
+		if err := e.validateItem(key, nil); err != nil {
+			return nil, err
+		}
+		if touched[key] {
+			return nil, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("the key '%s' is specified more than once", key))}
+		}
+		touched[key] = true
+		requestSize += len(key)
+		delete(items, key)
+	}
+
+	for _, p := range params.Puts {
+		key := aws.ToString(p.Key)
+		if err := e.validateItem(key, p.Value); err != nil {
+			return nil, err
+		}
+		if touched[key] {
+			return nil, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("the key '%s' is specified more than once", key))}
+		}
+		touched[key] = true
+		requestSize += itemSize(key, aws.ToString(p.Value))
+		items[key] = aws.ToString(p.Value)
+	}
+
+	if requestSize > quotas.MaxUpdateKeysRequestSize {
+		return nil, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("the size of a request (%d bytes) exceeds the limit of %d bytes", requestSize, quotas.MaxUpdateKeysRequestSize))}
+	}
+
+	if err := e.commit(s, items); err != nil {
+		return nil, err
+	}
+
+	return &kvs.UpdateKeysOutput{
+		ETag:             aws.String(s.kvsETag),
+		ItemCount:        aws.Int32(int32(len(s.items))),
+		TotalSizeInBytes: aws.Int64(int64(s.size)),
+	}, nil
+}
+
+// storeByARN returns the key value store that has the ARN.
+// The caller must hold e.mu.
+func (e *Emulator) storeByARN(arn string) (*store, error) {
+	s := e.findByARN(arn)
+	if s == nil {
+		return nil, &kvsTypes.ResourceNotFoundException{Message: aws.String(fmt.Sprintf("the key value store '%s' is not found", arn))}
+	}
+
+	e.refresh(s)
+	return s, nil
+}
+
+// writableStore returns the key value store that has the ARN,
+// after checking that it is READY and ifMatch is its current ETag.
+// The caller must hold e.mu.
+func (e *Emulator) writableStore(arn string, ifMatch *string) (*store, error) {
+	s, err := e.storeByARN(arn)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.status != StatusReady {
+		return nil, &kvsTypes.ConflictException{Message: aws.String(fmt.Sprintf("the key value store is %s", s.status))}
+	}
+	if ifMatch == nil || *ifMatch == "" {
+		return nil, &kvsTypes.ValidationException{Message: aws.String("IfMatch is required")}
+	}
+	if *ifMatch != s.kvsETag {
+		return nil, &kvsTypes.ConflictException{Message: aws.String("the precondition in IfMatch evaluated to false")}
+	}
+
+	return s, nil
+}
+
+// validateItem validates the key and the value against the quotas.
+// value is nil when the key is deleted.
+func (e *Emulator) validateItem(key string, value *string) error {
+	quotas := e.opts.Quotas
+	if key == "" || len(key) > quotas.MaxKeySize {
+		return &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("the size of key must be between 1 and %d bytes", quotas.MaxKeySize))}
+	}
+	if value == nil {
+		return nil
+	}
+	if len(*value) > quotas.MaxValueSize {
+		return &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("the size of the value of '%s' exceeds the limit of %d bytes", key, quotas.MaxValueSize))}
+	}
+	return nil
+}
+
+// commit replaces the items of the key value store, after checking the total size.
+// The caller must hold e.mu.
+func (e *Emulator) commit(s *store, items map[string]string) error {
+	size := 0
+	for k, v := range items {
+		size += itemSize(k, v)
+	}
+	if size > e.opts.Quotas.MaxKeyValueStoreSize {
+		return &kvsTypes.ServiceQuotaExceededException{Message: aws.String(fmt.Sprintf("the total size of the key value store exceeds the limit of %d bytes", e.opts.Quotas.MaxKeyValueStoreSize))}
+	}
+
+	s.items = items
+	s.size = size
+	s.kvsETag = e.newKeyValueStoreETag()
+	s.lastModified = e.opts.Now()
+
+	return nil
+}
+
+func (s *store) copyItems() map[string]string {
+	items := make(map[string]string, len(s.items))
+	for k, v := range s.items {
+		items[k] = v
+	}
+	return items
+}
@@ -0,0 +1,691 @@
+package emulator
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
+	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/aws/smithy-go"
+)
+
+const (
+	cloudFrontAPIVersion = "2020-05-31"
+	cloudFrontNamespace  = "http://cloudfront.amazonaws.com/doc/2020-05-31/"
+
+	// timestamps of the CloudFront API are ISO 8601 with milliseconds
+	cloudFrontTimeFormat = "2006-01-02T15:04:05.000Z"
+)
+
+// Handler returns an http.Handler that serves the CloudFront API (REST-XML) and
+// the CloudFront KeyValueStore API (REST-JSON) backed by the emulator.
+// Both APIs are served on the same endpoint, and requests are not authenticated.
+func (e *Emulator) Handler() http.Handler {
+	return &server{
+		cf:  e.CloudFront(),
+		kvs: e.KeyValueStore(),
+	}
+}
+
+type server struct {
+	cf  *CloudFront
+	kvs *KeyValueStore
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments, err := pathSegments(r.URL)
+	if err != nil {
+		writeCloudFrontError(w, &cfTypes.InvalidArgument{Message: aws.String(err.Error())})
+		return
+	}
+
+	switch {
+	case len(segments) >= 2 && segments[0] == cloudFrontAPIVersion && segments[1] == "key-value-store":
+		s.serveCloudFront(w, r, segments[2:])
+	case len(segments) >= 2 && segments[0] == cloudFrontAPIVersion && segments[1] == "function":
+		s.serveFunction(w, r, segments[2:])
+	case len(segments) >= 2 && segments[0] == "key-value-stores":
+		s.serveKeyValueStore(w, r, segments[1], segments[2:])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// pathSegments splits the escaped path of the URL and unescapes each segment,
+// because ARNs and keys in the path may contain escaped slashes.
+func pathSegments(u *url.URL) ([]string, error) {
+	segments := []string{}
+	for _, seg := range strings.Split(strings.Trim(u.EscapedPath(), "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(seg)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, unescaped)
+	}
+	return segments, nil
+}
+
+// CloudFront API
+
+type xmlKeyValueStore struct {
+	XMLName          xml.Name `xml:"KeyValueStore"`
+	Xmlns            string   `xml:"xmlns,attr,omitempty"`
+	Name             string   `xml:"Name"`
+	Id               string   `xml:"Id"`
+	Comment          string   `xml:"Comment"`
+	ARN              string   `xml:"ARN"`
+	Status           string   `xml:"Status,omitempty"`
+	LastModifiedTime string   `xml:"LastModifiedTime"`
+}
+
+type xmlKeyValueStoreList struct {
+	XMLName    xml.Name           `xml:"KeyValueStoreList"`
+	Xmlns      string             `xml:"xmlns,attr"`
+	NextMarker string             `xml:"NextMarker,omitempty"`
+	MaxItems   int32              `xml:"MaxItems"`
+	Quantity   int32              `xml:"Quantity"`
+	Items      []xmlKeyValueStore `xml:"Items>KeyValueStore"`
+}
+
+type xmlCreateKeyValueStoreRequest struct {
+	Name         string `xml:"Name"`
+	Comment      string `xml:"Comment"`
+	ImportSource *struct {
+		SourceType string `xml:"SourceType"`
+		SourceARN  string `xml:"SourceARN"`
+	} `xml:"ImportSource"`
+}
+
+type xmlUpdateKeyValueStoreRequest struct {
+	Comment string `xml:"Comment"`
+}
+
+type xmlErrorResponse struct {
+	XMLName   xml.Name `xml:"ErrorResponse"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Type      string   `xml:"Error>Type"`
+	Code      string   `xml:"Error>Code"`
+	Message   string   `xml:"Error>Message"`
+	RequestId string   `xml:"RequestId"`
+}
+
+func toXMLKeyValueStore(k *cfTypes.KeyValueStore) xmlKeyValueStore {
+	return xmlKeyValueStore{
+		Name:             aws.ToString(k.Name),
+		Id:               aws.ToString(k.Id),
+		Comment:          aws.ToString(k.Comment),
+		ARN:              aws.ToString(k.ARN),
+		Status:           aws.ToString(k.Status),
+		LastModifiedTime: aws.ToTime(k.LastModifiedTime).UTC().Format(cloudFrontTimeFormat),
+	}
+}
+
+func (s *server) serveCloudFront(w http.ResponseWriter, r *http.Request, segments []string) {
+	ctx := r.Context()
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		in := &cloudfront.ListKeyValueStoresInput{}
+		q := r.URL.Query()
+		if v := q.Get("Marker"); v != "" {
+			in.Marker = aws.String(v)
+		}
+		if v := q.Get("MaxItems"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				writeCloudFrontError(w, &cfTypes.InvalidArgument{Message: aws.String("MaxItems must be an integer")})
+				return
+			}
+			in.MaxItems = aws.Int32(int32(n))
+		}
+		if v := q.Get("Status"); v != "" {
+			in.Status = aws.String(v)
+		}
+
+		out, err := s.cf.ListKeyValueStores(ctx, in)
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		list := xmlKeyValueStoreList{
+			Xmlns:      cloudFrontNamespace,
+			NextMarker: aws.ToString(out.KeyValueStoreList.NextMarker),
+			MaxItems:   aws.ToInt32(out.KeyValueStoreList.MaxItems),
+			Quantity:   aws.ToInt32(out.KeyValueStoreList.Quantity),
+		}
+		for _, k := range out.KeyValueStoreList.Items {
+			list.Items = append(list.Items, toXMLKeyValueStore(&k))
+		}
+		writeXML(w, http.StatusOK, list)
+
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		req := xmlCreateKeyValueStoreRequest{}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeCloudFrontError(w, &cfTypes.InvalidArgument{Message: aws.String(fmt.Sprintf("failed to decode request body: %v", err))})
+			return
+		}
+
+		in := &cloudfront.CreateKeyValueStoreInput{
+			Name:    aws.String(req.Name),
+			Comment: aws.String(req.Comment),
+		}
+		if req.ImportSource != nil {
+			in.ImportSource = &cfTypes.ImportSource{
+				SourceARN:  aws.String(req.ImportSource.SourceARN),
+				SourceType: cfTypes.ImportSourceType(req.ImportSource.SourceType),
+			}
+		}
+
+		out, err := s.cf.CreateKeyValueStore(ctx, in)
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		w.Header().Set("Location", aws.ToString(out.Location))
+		body := toXMLKeyValueStore(out.KeyValueStore)
+		body.Xmlns = cloudFrontNamespace
+		writeXML(w, http.StatusCreated, body)
+
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		out, err := s.cf.DescribeKeyValueStore(ctx, &cloudfront.DescribeKeyValueStoreInput{
+			Name: aws.String(segments[0]),
+		})
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		body := toXMLKeyValueStore(out.KeyValueStore)
+		body.Xmlns = cloudFrontNamespace
+		writeXML(w, http.StatusOK, body)
+
+	case len(segments) == 1 && r.Method == http.MethodPut:
+		req := xmlUpdateKeyValueStoreRequest{}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeCloudFrontError(w, &cfTypes.InvalidArgument{Message: aws.String(fmt.Sprintf("failed to decode request body: %v", err))})
+			return
+		}
+
+		out, err := s.cf.UpdateKeyValueStore(ctx, &cloudfront.UpdateKeyValueStoreInput{
+			Name:    aws.String(segments[0]),
+			Comment: aws.String(req.Comment),
+			IfMatch: headerValue(r, "If-Match"),
+		})
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		body := toXMLKeyValueStore(out.KeyValueStore)
+		body.Xmlns = cloudFrontNamespace
+		writeXML(w, http.StatusOK, body)
+
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		_, err := s.cf.DeleteKeyValueStore(ctx, &cloudfront.DeleteKeyValueStoreInput{
+			Name:    aws.String(segments[0]),
+			IfMatch: headerValue(r, "If-Match"),
+		})
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeCloudFrontError(w, &cfTypes.UnsupportedOperation{Message: aws.String(fmt.Sprintf("%s %s is not supported by the emulator", r.Method, r.URL.Path))})
+	}
+}
+
+type xmlFunctionSummary struct {
+	XMLName        xml.Name `xml:"FunctionSummary"`
+	Xmlns          string   `xml:"xmlns,attr,omitempty"`
+	Name           string   `xml:"Name"`
+	Status         string   `xml:"Status"`
+	FunctionConfig struct {
+		Comment                   string                       `xml:"Comment"`
+		Runtime                   string                       `xml:"Runtime"`
+		KeyValueStoreAssociations xmlKeyValueStoreAssociations `xml:"KeyValueStoreAssociations"`
+	} `xml:"FunctionConfig"`
+	FunctionMetadata struct {
+		FunctionARN      string `xml:"FunctionARN"`
+		Stage            string `xml:"Stage"`
+		CreatedTime      string `xml:"CreatedTime"`
+		LastModifiedTime string `xml:"LastModifiedTime"`
+	} `xml:"FunctionMetadata"`
+}
+
+type xmlKeyValueStoreAssociations struct {
+	Quantity int32                         `xml:"Quantity"`
+	Items    []xmlKeyValueStoreAssociation `xml:"Items>KeyValueStoreAssociation"`
+}
+
+type xmlKeyValueStoreAssociation struct {
+	KeyValueStoreARN string `xml:"KeyValueStoreARN"`
+}
+
+type xmlFunctionList struct {
+	XMLName    xml.Name             `xml:"FunctionList"`
+	Xmlns      string               `xml:"xmlns,attr"`
+	NextMarker string               `xml:"NextMarker,omitempty"`
+	MaxItems   int32                `xml:"MaxItems"`
+	Quantity   int32                `xml:"Quantity"`
+	Items      []xmlFunctionSummary `xml:"Items>FunctionSummary"`
+}
+
+type xmlCreateFunctionRequest struct {
+	Name           string `xml:"Name"`
+	FunctionCode   []byte `xml:"FunctionCode"`
+	FunctionConfig struct {
+		Comment                   string                        `xml:"Comment"`
+		Runtime                   string                        `xml:"Runtime"`
+		KeyValueStoreAssociations *xmlKeyValueStoreAssociations `xml:"KeyValueStoreAssociations"`
+	} `xml:"FunctionConfig"`
+}
+
+func toXMLFunctionSummary(f *cfTypes.FunctionSummary) xmlFunctionSummary {
+	x := xmlFunctionSummary{
+		Name:   aws.ToString(f.Name),
+		Status: aws.ToString(f.Status),
+	}
+	if c := f.FunctionConfig; c != nil {
+		x.FunctionConfig.Comment = aws.ToString(c.Comment)
+		x.FunctionConfig.Runtime = string(c.Runtime)
+		if c.KeyValueStoreAssociations != nil {
+			x.FunctionConfig.KeyValueStoreAssociations.Quantity = aws.ToInt32(c.KeyValueStoreAssociations.Quantity)
+			for _, a := range c.KeyValueStoreAssociations.Items {
+				x.FunctionConfig.KeyValueStoreAssociations.Items = append(x.FunctionConfig.KeyValueStoreAssociations.Items, xmlKeyValueStoreAssociation{KeyValueStoreARN: aws.ToString(a.KeyValueStoreARN)})
+			}
+		}
+	}
+	if m := f.FunctionMetadata; m != nil {
+		x.FunctionMetadata.FunctionARN = aws.ToString(m.FunctionARN)
+		x.FunctionMetadata.Stage = string(m.Stage)
+		x.FunctionMetadata.CreatedTime = aws.ToTime(m.CreatedTime).UTC().Format(cloudFrontTimeFormat)
+		x.FunctionMetadata.LastModifiedTime = aws.ToTime(m.LastModifiedTime).UTC().Format(cloudFrontTimeFormat)
+	}
+	return x
+}
+
+func (s *server) serveFunction(w http.ResponseWriter, r *http.Request, segments []string) {
+	ctx := r.Context()
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		in := &cloudfront.ListFunctionsInput{}
+		q := r.URL.Query()
+		if v := q.Get("Marker"); v != "" {
+			in.Marker = aws.String(v)
+		}
+		if v := q.Get("MaxItems"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				writeCloudFrontError(w, &cfTypes.InvalidArgument{Message: aws.String("MaxItems must be an integer")})
+				return
+			}
+			in.MaxItems = aws.Int32(int32(n))
+		}
+		if v := q.Get("Stage"); v != "" {
+			in.Stage = cfTypes.FunctionStage(v)
+		}
+
+		out, err := s.cf.ListFunctions(ctx, in)
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		list := xmlFunctionList{
+			Xmlns:      cloudFrontNamespace,
+			NextMarker: aws.ToString(out.FunctionList.NextMarker),
+			MaxItems:   aws.ToInt32(out.FunctionList.MaxItems),
+			Quantity:   aws.ToInt32(out.FunctionList.Quantity),
+		}
+		for _, f := range out.FunctionList.Items {
+			list.Items = append(list.Items, toXMLFunctionSummary(&f))
+		}
+		writeXML(w, http.StatusOK, list)
+
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		req := xmlCreateFunctionRequest{}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeCloudFrontError(w, &cfTypes.InvalidArgument{Message: aws.String(fmt.Sprintf("failed to decode request body: %v", err))})
+			return
+		}
+
+		in := &cloudfront.CreateFunctionInput{
+			Name:         aws.String(req.Name),
+			FunctionCode: req.FunctionCode,
+			FunctionConfig: &cfTypes.FunctionConfig{
+				Comment: aws.String(req.FunctionConfig.Comment),
+				Runtime: cfTypes.FunctionRuntime(req.FunctionConfig.Runtime),
+			},
+		}
+		if a := req.FunctionConfig.KeyValueStoreAssociations; a != nil {
+			associations := &cfTypes.KeyValueStoreAssociations{Quantity: aws.Int32(int32(len(a.Items)))}
+			for _, item := range a.Items {
+				associations.Items = append(associations.Items, cfTypes.KeyValueStoreAssociation{KeyValueStoreARN: aws.String(item.KeyValueStoreARN)})
+			}
+			in.FunctionConfig.KeyValueStoreAssociations = associations
+		}
+
+		out, err := s.cf.CreateFunction(ctx, in)
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		w.Header().Set("Location", aws.ToString(out.Location))
+		body := toXMLFunctionSummary(out.FunctionSummary)
+		body.Xmlns = cloudFrontNamespace
+		writeXML(w, http.StatusCreated, body)
+
+	case len(segments) == 2 && segments[1] == "describe" && r.Method == http.MethodGet:
+		out, err := s.cf.DescribeFunction(ctx, &cloudfront.DescribeFunctionInput{
+			Name:  aws.String(segments[0]),
+			Stage: cfTypes.FunctionStage(r.URL.Query().Get("Stage")),
+		})
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		body := toXMLFunctionSummary(out.FunctionSummary)
+		body.Xmlns = cloudFrontNamespace
+		writeXML(w, http.StatusOK, body)
+
+	case len(segments) == 2 && segments[1] == "publish" && r.Method == http.MethodPost:
+		out, err := s.cf.PublishFunction(ctx, &cloudfront.PublishFunctionInput{
+			Name:    aws.String(segments[0]),
+			IfMatch: headerValue(r, "If-Match"),
+		})
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		body := toXMLFunctionSummary(out.FunctionSummary)
+		body.Xmlns = cloudFrontNamespace
+		writeXML(w, http.StatusOK, body)
+
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		_, err := s.cf.DeleteFunction(ctx, &cloudfront.DeleteFunctionInput{
+			Name:    aws.String(segments[0]),
+			IfMatch: headerValue(r, "If-Match"),
+		})
+		if err != nil {
+			writeCloudFrontError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeCloudFrontError(w, &cfTypes.UnsupportedOperation{Message: aws.String(fmt.Sprintf("%s %s is not supported by the emulator", r.Method, r.URL.Path))})
+	}
+}
+
+func writeXML(w http.ResponseWriter, status int, v any) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(b)
+}
+
+func writeCloudFrontError(w http.ResponseWriter, err error) {
+	code, message := "InternalError", err.Error()
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code, message = apiErr.ErrorCode(), apiErr.ErrorMessage()
+	}
+
+	status := http.StatusInternalServerError
+	switch code {
+	case "InvalidArgument", "InvalidIfMatchVersion", "EntityLimitExceeded", "EntitySizeLimitExceeded", "UnsupportedOperation":
+		status = http.StatusBadRequest
+	case "EntityNotFound", "NoSuchFunctionExists":
+		status = http.StatusNotFound
+	case "EntityAlreadyExists", "FunctionAlreadyExists", "CannotDeleteEntityWhileInUse":
+		status = http.StatusConflict
+	case "PreconditionFailed":
+		status = http.StatusPreconditionFailed
+	}
+
+	writeXML(w, status, xmlErrorResponse{
+		Xmlns:   cloudFrontNamespace,
+		Type:    "Sender",
+		Code:    code,
+		Message: message,
+	})
+}
+
+// CloudFront KeyValueStore API
+
+type jsonItem struct {
+	Key   *string `json:"Key,omitempty"`
+	Value *string `json:"Value,omitempty"`
+}
+
+func (s *server) serveKeyValueStore(w http.ResponseWriter, r *http.Request, kvsARN string, segments []string) {
+	ctx := r.Context()
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		out, err := s.kvs.DescribeKeyValueStore(ctx, &kvs.DescribeKeyValueStoreInput{
+			KvsARN: aws.String(kvsARN),
+		})
+		if err != nil {
+			writeKeyValueStoreError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		writeJSON(w, http.StatusOK, map[string]any{
+			"KvsARN":           out.KvsARN,
+			"ItemCount":        out.ItemCount,
+			"TotalSizeInBytes": out.TotalSizeInBytes,
+			"Created":          epochSeconds(out.Created),
+			"LastModified":     epochSeconds(out.LastModified),
+			"Status":           out.Status,
+			"FailureReason":    out.FailureReason,
+		})
+
+	case len(segments) == 1 && segments[0] == "keys" && r.Method == http.MethodGet:
+		in := &kvs.ListKeysInput{KvsARN: aws.String(kvsARN)}
+		q := r.URL.Query()
+		if v := q.Get("NextToken"); v != "" {
+			in.NextToken = aws.String(v)
+		}
+		if v := q.Get("MaxResults"); v != "" {
+			n, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				writeKeyValueStoreError(w, &kvsTypes.ValidationException{Message: aws.String("MaxResults must be an integer")})
+				return
+			}
+			in.MaxResults = aws.Int32(int32(n))
+		}
+
+		out, err := s.kvs.ListKeys(ctx, in)
+		if err != nil {
+			writeKeyValueStoreError(w, err)
+			return
+		}
+
+		items := []jsonItem{}
+		for _, item := range out.Items {
+			items = append(items, jsonItem{Key: item.Key, Value: item.Value})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"Items":     items,
+			"NextToken": out.NextToken,
+		})
+
+	case len(segments) == 1 && segments[0] == "keys" && r.Method == http.MethodPost:
+		req := struct {
+			Puts    []jsonItem `json:"Puts"`
+			Deletes []jsonItem `json:"Deletes"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeKeyValueStoreError(w, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("failed to decode request body: %v", err))})
+			return
+		}
+
+		in := &kvs.UpdateKeysInput{
+			KvsARN:  aws.String(kvsARN),
+			IfMatch: headerValue(r, "If-Match"),
+		}
+		for _, p := range req.Puts {
+			in.Puts = append(in.Puts, kvsTypes.PutKeyRequestListItem{Key: p.Key, Value: p.Value})
+		}
+		for _, d := range req.Deletes {
+			in.Deletes = append(in.Deletes, kvsTypes.DeleteKeyRequestListItem{Key: d.Key})
+		}
+
+		out, err := s.kvs.UpdateKeys(ctx, in)
+		if err != nil {
+			writeKeyValueStoreError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ItemCount":        out.ItemCount,
+			"TotalSizeInBytes": out.TotalSizeInBytes,
+		})
+
+	case len(segments) == 2 && segments[0] == "keys" && r.Method == http.MethodGet:
+		out, err := s.kvs.GetKey(ctx, &kvs.GetKeyInput{
+			KvsARN: aws.String(kvsARN),
+			Key:    aws.String(segments[1]),
+		})
+		if err != nil {
+			writeKeyValueStoreError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"Key":              out.Key,
+			"Value":            out.Value,
+			"ItemCount":        out.ItemCount,
+			"TotalSizeInBytes": out.TotalSizeInBytes,
+		})
+
+	case len(segments) == 2 && segments[0] == "keys" && r.Method == http.MethodPut:
+		req := jsonItem{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeKeyValueStoreError(w, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("failed to decode request body: %v", err))})
+			return
+		}
+
+		out, err := s.kvs.PutKey(ctx, &kvs.PutKeyInput{
+			KvsARN:  aws.String(kvsARN),
+			Key:     aws.String(segments[1]),
+			Value:   req.Value,
+			IfMatch: headerValue(r, "If-Match"),
+		})
+		if err != nil {
+			writeKeyValueStoreError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ItemCount":        out.ItemCount,
+			"TotalSizeInBytes": out.TotalSizeInBytes,
+		})
+
+	case len(segments) == 2 && segments[0] == "keys" && r.Method == http.MethodDelete:
+		out, err := s.kvs.DeleteKey(ctx, &kvs.DeleteKeyInput{
+			KvsARN:  aws.String(kvsARN),
+			Key:     aws.String(segments[1]),
+			IfMatch: headerValue(r, "If-Match"),
+		})
+		if err != nil {
+			writeKeyValueStoreError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", aws.ToString(out.ETag))
+		writeJSON(w, http.StatusOK, map[string]any{
+			"ItemCount":        out.ItemCount,
+			"TotalSizeInBytes": out.TotalSizeInBytes,
+		})
+
+	default:
+		writeKeyValueStoreError(w, &kvsTypes.ValidationException{Message: aws.String(fmt.Sprintf("%s %s is not supported by the emulator", r.Method, r.URL.Path))})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func writeKeyValueStoreError(w http.ResponseWriter, err error) {
+	code, message := "InternalServerException", err.Error()
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		code, message = apiErr.ErrorCode(), apiErr.ErrorMessage()
+	}
+
+	status := http.StatusInternalServerError
+	switch code {
+	case "ValidationException":
+		status = http.StatusBadRequest
+	case "ServiceQuotaExceededException":
+		status = http.StatusPaymentRequired
+	case "AccessDeniedException":
+		status = http.StatusForbidden
+	case "ResourceNotFoundException":
+		status = http.StatusNotFound
+	case "ConflictException":
+		status = http.StatusConflict
+	}
+
+	w.Header().Set("X-Amzn-ErrorType", code)
+	writeJSON(w, status, map[string]string{"Message": message})
+}
+
+func headerValue(r *http.Request, name string) *string {
+	if v := r.Header.Get(name); v != "" {
+		return aws.String(v)
+	}
+	return nil
+}
+
+func epochSeconds(t *time.Time) float64 {
+	return float64(aws.ToTime(t).UnixMilli()) / 1000
+}
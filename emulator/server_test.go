@@ -0,0 +1,145 @@
+package emulator_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/michimani/cfkvs/emulator"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// setEmulatorEnv points the AWS SDK at the emulator with dummy credentials.
+func setEmulatorEnv(t *testing.T, endpoint string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ENDPOINT_URL", endpoint)
+	t.Setenv("AWS_ACCESS_KEY_ID", "dummy")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "dummy")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+}
+
+func Test_Handler(t *testing.T) {
+	asst := assert.New(t)
+
+	srv := httptest.NewServer(emulator.New().Handler())
+	defer srv.Close()
+	setEmulatorEnv(t, srv.URL)
+
+	ctx := context.Background()
+	cfg, err := libs.LoadAWSConfig(ctx, libs.AWSConfigOptions{})
+	if !asst.NoError(err) {
+		return
+	}
+	cfc := libs.NewCloudFrontClient(cfg)
+	kvsc := libs.NewCloudFrontKeyValueStoreClient(cfg)
+
+	// create
+	created, err := libs.CreateKeyValueStore(ctx, cfc, "kvs-1", "comment", nil)
+	if !asst.NoError(err) {
+		return
+	}
+	asst.Equal("kvs-1", *created.KeyValueStore.Name)
+
+	_, err = libs.CreateKeyValueStore(ctx, cfc, "kvs-1", "comment", nil)
+	var exists *cfTypes.EntityAlreadyExists
+	asst.True(errors.As(err, &exists))
+
+	arn, err := libs.GetKeyValueStoreArn(ctx, cfc, "kvs-1")
+	asst.NoError(err)
+	asst.Equal(*created.KeyValueStore.ARN, arn)
+
+	// sync
+	data, err := libs.GetKeyValueStoreDataFromFile("../testdata/valid-a-lot.json", types.DataFormatAuto)
+	asst.NoError(err)
+	putList := data.ToItemList().Data
+	_, err = libs.SyncItems(ctx, kvsc, arn, putList, nil)
+	asst.NoError(err)
+
+	_, err = libs.PutItem(ctx, kvsc, arn, "key-put", "value-put")
+	asst.NoError(err)
+	_, err = libs.DeleteItem(ctx, kvsc, arn, "key-put")
+	asst.NoError(err)
+
+	// list
+	list, err := libs.ListItems(ctx, kvsc, arn)
+	asst.NoError(err)
+	asst.ElementsMatch(putList, list.Data)
+
+	got, err := libs.GetItem(ctx, kvsc, arn, putList[0].Key)
+	asst.NoError(err)
+	asst.Equal(putList[0].Value, *got.Value)
+
+	_, err = libs.GetItem(ctx, kvsc, arn, "key-put")
+	var notFound *kvsTypes.ResourceNotFoundException
+	asst.True(errors.As(err, &notFound))
+
+	full, err := libs.DescribeKeyValueStore(ctx, cfc, kvsc, "kvs-1")
+	asst.NoError(err)
+	asst.Equal(arn, full.ARN)
+	asst.Equal("comment", full.Comment)
+	asst.Equal(emulator.StatusReady, full.Status)
+	asst.Equal(int32(len(putList)), full.ItemCount)
+	asst.NotEmpty(full.ETag)
+
+	// update
+	updated, err := libs.UpdateKeyValueStore(ctx, cfc, "kvs-1", "new comment")
+	asst.NoError(err)
+	asst.Equal("new comment", *updated.KeyValueStore.Comment)
+
+	// functions
+	fn, err := cfc.CreateFunction(ctx, &cloudfront.CreateFunctionInput{
+		Name:         aws.String("fn-1"),
+		FunctionCode: []byte("function handler(event) { return event.request; }"),
+		FunctionConfig: &cfTypes.FunctionConfig{
+			Comment: aws.String(""),
+			Runtime: cfTypes.FunctionRuntimeCloudfrontJs20,
+			KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{
+				Quantity: aws.Int32(1),
+				Items:    []cfTypes.KeyValueStoreAssociation{{KeyValueStoreARN: aws.String(arn)}},
+			},
+		},
+	})
+	if !asst.NoError(err) {
+		return
+	}
+	_, err = cfc.PublishFunction(ctx, &cloudfront.PublishFunctionInput{Name: aws.String("fn-1"), IfMatch: fn.ETag})
+	asst.NoError(err)
+
+	associated, err := libs.ListFunctionAssociations(ctx, cfc, arn)
+	asst.NoError(err)
+	if asst.Len(associated, 2) {
+		asst.Equal(cfTypes.FunctionStageDevelopment, associated[0].FunctionMetadata.Stage)
+		asst.Equal(cfTypes.FunctionStageLive, associated[1].FunctionMetadata.Stage)
+		asst.Equal(emulator.FunctionStatusUnassociated, *associated[1].Status)
+	}
+
+	described, err := cfc.DescribeFunction(ctx, &cloudfront.DescribeFunctionInput{Name: aws.String("fn-1"), Stage: cfTypes.FunctionStageLive})
+	asst.NoError(err)
+	asst.Equal(arn, *described.FunctionSummary.FunctionConfig.KeyValueStoreAssociations.Items[0].KeyValueStoreARN)
+
+	var inUse *libs.KeyValueStoreInUseError
+	asst.True(errors.As(libs.DeleteKeyValueStore(ctx, cfc, "kvs-1"), &inUse))
+
+	_, err = cfc.DeleteFunction(ctx, &cloudfront.DeleteFunctionInput{Name: aws.String("fn-1"), IfMatch: fn.ETag})
+	asst.NoError(err)
+
+	// delete
+	asst.NoError(libs.DeleteKeyValueStore(ctx, cfc, "kvs-1"))
+
+	_, err = libs.GetKeyValueStoreArn(ctx, cfc, "kvs-1")
+	asst.Error(err)
+}
@@ -0,0 +1,245 @@
+// Package function runs CloudFront Functions of the cloudfront-js-2.0 runtime locally, with an embedded JavaScript engine.
+//
+// The cloudfront module is provided by the runtime, so that a function can read items with cf.kvs().
+// The items are given to Run as an ItemList, read from a key value store or a local file.
+// Other modules of CloudFront Functions, like crypto, and the helper methods for origins are not supported.
+package function
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/michimani/cfkvs/types"
+)
+
+// DefaultTimeout is the maximum time to run a function.
+// It is far longer than the quota of CloudFront Functions, because it only stops infinite loops.
+const DefaultTimeout = 5 * time.Second
+
+const (
+	ValueFormatString = "string"
+	ValueFormatJSON   = "json"
+	ValueFormatBytes  = "bytes"
+)
+
+// cloudfrontModule is the global name that import declarations of the cloudfront module are bound to.
+const cloudfrontModule = "__cloudfront"
+
+// importPattern matches the default import declarations, e.g. import cf from 'cloudfront';
+var importPattern = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+([A-Za-z_$][\w$]*)[ \t]+from[ \t]+['"]([^'"]+)['"][ \t]*;?`)
+
+type Options struct {
+	// Timeout is the maximum time to run the function. DefaultTimeout is used if it is zero.
+	Timeout time.Duration
+	// LogTarget is written with the messages of console.log. They are discarded if it is nil.
+	LogTarget io.Writer
+}
+
+// Run runs the handler of the function code with the event, and returns the JSON form of the request or response that it returns.
+// The items are read by the function with cf.kvs().get() and cf.kvs().exists(). A nil ItemList is an empty key value store.
+func Run(code string, event []byte, items *types.ItemList, optFns ...func(*Options)) (any, error) {
+	opts := Options{Timeout: DefaultTimeout}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	src, err := resolveImports(code)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(event) {
+		return nil, errors.New("event must be a JSON object")
+	}
+
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.UncapFieldNameMapper())
+
+	timer := time.AfterFunc(opts.Timeout, func() {
+		vm.Interrupt(fmt.Sprintf("the function did not finish in %s", opts.Timeout))
+	})
+	defer timer.Stop()
+
+	if err := setGlobals(vm, items, opts.LogTarget); err != nil {
+		return nil, err
+	}
+
+	if _, err := vm.RunScript("function.js", src); err != nil {
+		return nil, fmt.Errorf("failed to load the function: %w", err)
+	}
+
+	handler, ok := goja.AssertFunction(vm.Get("handler"))
+	if !ok {
+		return nil, errors.New("the function must define a handler function")
+	}
+
+	eventValue, err := parseJSON(vm, string(event))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the event: %w", err)
+	}
+	if _, ok := eventValue.(*goja.Object); !ok {
+		return nil, errors.New("event must be a JSON object")
+	}
+
+	// Promise jobs, including async handlers, are run before the outermost call returns.
+	result, err := handler(goja.Undefined(), eventValue)
+	if err != nil {
+		return nil, fmt.Errorf("the handler threw an error: %w", err)
+	}
+
+	if p, ok := result.Export().(*goja.Promise); ok {
+		switch p.State() {
+		case goja.PromiseStateFulfilled:
+			result = p.Result()
+		case goja.PromiseStateRejected:
+			return nil, fmt.Errorf("the handler threw an error: %s", p.Result())
+		default:
+			return nil, errors.New("the handler did not resolve its promise")
+		}
+	}
+
+	if _, ok := result.(*goja.Object); !ok {
+		return nil, errors.New("the handler must return a request or a response object")
+	}
+
+	return exportJSON(vm, result)
+}
+
+// resolveImports replaces the import declarations, because the engine does not support ES modules.
+func resolveImports(code string) (string, error) {
+	var unsupported error
+	src := importPattern.ReplaceAllStringFunc(code, func(decl string) string {
+		m := importPattern.FindStringSubmatch(decl)
+		if m[2] != "cloudfront" {
+			unsupported = fmt.Errorf("module %q is not supported", m[2])
+			return decl
+		}
+		// the declaration is replaced in the same line, so that line numbers in errors do not change
+		return fmt.Sprintf("const %s = %s;", m[1], cloudfrontModule)
+	})
+	if unsupported != nil {
+		return "", unsupported
+	}
+
+	return src, nil
+}
+
+func setGlobals(vm *goja.Runtime, items *types.ItemList, logTarget io.Writer) error {
+	console := vm.NewObject()
+	if err := console.Set("log", func(call goja.FunctionCall) goja.Value {
+		if logTarget == nil {
+			return goja.Undefined()
+		}
+		args := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			args[i] = arg.String()
+		}
+		_, _ = fmt.Fprintln(logTarget, strings.Join(args, " "))
+		return goja.Undefined()
+	}); err != nil {
+		return err
+	}
+	if err := vm.Set("console", console); err != nil {
+		return err
+	}
+
+	cf := vm.NewObject()
+	if err := cf.Set("kvs", func(call goja.FunctionCall) goja.Value {
+		// A function is associated with one key value store at most, so the ID is not used to select it.
+		return newKVSHandle(vm, items)
+	}); err != nil {
+		return err
+	}
+
+	return vm.Set(cloudfrontModule, cf)
+}
+
+// newKVSHandle returns the object of cf.kvs(), whose methods return promises as the ones of CloudFront Functions.
+func newKVSHandle(vm *goja.Runtime, items *types.ItemList) *goja.Object {
+	handle := vm.NewObject()
+
+	_ = handle.Set("get", func(key string, options *goja.Object) *goja.Promise {
+		p, resolve, reject := vm.NewPromise()
+
+		item, ok := items.Get(key)
+		if !ok {
+			_ = reject(vm.NewGoError(fmt.Errorf("key not found: %s", key)))
+			return p
+		}
+
+		format := ValueFormatString
+		if options != nil {
+			if f := options.Get("format"); f != nil && !goja.IsUndefined(f) {
+				format = f.String()
+			}
+		}
+
+		v, err := formatValue(vm, item.Value, format)
+		if err != nil {
+			_ = reject(vm.NewGoError(err))
+			return p
+		}
+
+		_ = resolve(v)
+		return p
+	})
+
+	_ = handle.Set("exists", func(key string) *goja.Promise {
+		p, resolve, _ := vm.NewPromise()
+		_, ok := items.Get(key)
+		_ = resolve(ok)
+		return p
+	})
+
+	return handle
+}
+
+func formatValue(vm *goja.Runtime, value, format string) (goja.Value, error) {
+	switch format {
+	case ValueFormatString:
+		return vm.ToValue(value), nil
+
+	case ValueFormatJSON:
+		v, err := parseJSON(vm, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the value as JSON: %w", err)
+		}
+		return v, nil
+
+	case ValueFormatBytes:
+		return vm.New(vm.Get("Uint8Array"), vm.ToValue(vm.NewArrayBuffer([]byte(value))))
+
+	default:
+		return nil, fmt.Errorf("invalid format %q. It must be one of: %s, %s, %s", format, ValueFormatString, ValueFormatJSON, ValueFormatBytes)
+	}
+}
+
+func parseJSON(vm *goja.Runtime, s string) (goja.Value, error) {
+	parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+	return parse(goja.Undefined(), vm.ToValue(s))
+}
+
+// exportJSON returns the value as the types that encoding/json decodes JSON into, through JSON.stringify.
+func exportJSON(vm *goja.Runtime, v goja.Value) (any, error) {
+	stringify, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("stringify"))
+	s, err := stringify(goja.Undefined(), v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the result to JSON: %w", err)
+	}
+
+	var result any
+	if err := json.Unmarshal([]byte(s.String()), &result); err != nil {
+		return nil, fmt.Errorf("failed to convert the result to JSON: %w", err)
+	}
+
+	return result, nil
+}
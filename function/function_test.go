@@ -0,0 +1,201 @@
+package function_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/michimani/cfkvs/function"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const viewerRequestEvent = `{"version":"1.0","context":{"eventType":"viewer-request"},"request":{"method":"GET","uri":"/old","headers":{}}}`
+
+func Test_Run(t *testing.T) {
+	items := types.NewItemList([]types.Item{
+		{Key: "/old", Value: `{"location":"/new"}`},
+		{Key: "greeting", Value: "hello"},
+	})
+
+	cases := []struct {
+		name      string
+		code      string
+		event     string
+		items     *types.ItemList
+		expect    any
+		expectLog string
+		wantError bool
+	}{
+		{
+			name: "ok: sync handler returns the request",
+			code: `function handler(event) {
+  event.request.headers['x-test'] = { value: 'true' };
+  return event.request;
+}`,
+			event: viewerRequestEvent,
+			expect: map[string]any{
+				"method":  "GET",
+				"uri":     "/old",
+				"headers": map[string]any{"x-test": map[string]any{"value": "true"}},
+			},
+		},
+		{
+			name: "ok: async handler returns a response with a value in json format",
+			code: `import cf from 'cloudfront';
+const kvsHandle = cf.kvs('kvs-id');
+async function handler(event) {
+  const redirect = await kvsHandle.get(event.request.uri, { format: 'json' });
+  return { statusCode: 302, headers: { location: { value: redirect.location } } };
+}`,
+			event: viewerRequestEvent,
+			items: items,
+			expect: map[string]any{
+				"statusCode": float64(302),
+				"headers":    map[string]any{"location": map[string]any{"value": "/new"}},
+			},
+		},
+		{
+			name: "ok: exists and get in string and bytes format",
+			code: `import cf from "cloudfront"
+async function handler(event) {
+  const kvs = cf.kvs();
+  const value = await kvs.get('greeting');
+  const bytes = await kvs.get('greeting', { format: 'bytes' });
+  return { statusCode: 200, body: { data: value + ' ' + bytes.length + ' ' + (await kvs.exists('greeting')) + ' ' + (await kvs.exists('none')) } };
+}`,
+			event: viewerRequestEvent,
+			items: items,
+			expect: map[string]any{
+				"statusCode": float64(200),
+				"body":       map[string]any{"data": "hello 5 true false"},
+			},
+		},
+		{
+			name: "ok: get of a key that does not exist throws",
+			code: `import cf from 'cloudfront';
+async function handler(event) {
+  try {
+    await cf.kvs().get('none');
+  } catch (e) {
+    return { statusCode: 404, statusDescription: e.message };
+  }
+}`,
+			event: viewerRequestEvent,
+			items: items,
+			expect: map[string]any{
+				"statusCode":        float64(404),
+				"statusDescription": "key not found: none",
+			},
+		},
+		{
+			name: "ok: nil items are an empty key value store",
+			code: `import cf from 'cloudfront';
+async function handler(event) {
+  return { statusCode: (await cf.kvs().exists('greeting')) ? 200 : 404 };
+}`,
+			event:  viewerRequestEvent,
+			items:  nil,
+			expect: map[string]any{"statusCode": float64(404)},
+		},
+		{
+			name: "ok: console.log",
+			code: `function handler(event) {
+  console.log('uri:', event.request.uri);
+  return event.request;
+}`,
+			event: `{"request":{"uri":"/"}}`,
+			expect: map[string]any{
+				"uri": "/",
+			},
+			expectLog: "uri: /\n",
+		},
+		{
+			name:      "error: module not supported",
+			code:      "import crypto from 'crypto';\nfunction handler(event) { return event.request; }",
+			event:     viewerRequestEvent,
+			wantError: true,
+		},
+		{
+			name:      "error: syntax error",
+			code:      "function handler(event) {",
+			event:     viewerRequestEvent,
+			wantError: true,
+		},
+		{
+			name:      "error: no handler",
+			code:      "function main(event) { return event.request; }",
+			event:     viewerRequestEvent,
+			wantError: true,
+		},
+		{
+			name:      "error: invalid event",
+			code:      "function handler(event) { return event.request; }",
+			event:     `{"request":`,
+			wantError: true,
+		},
+		{
+			name:      "error: event is not an object",
+			code:      "function handler(event) { return event; }",
+			event:     `"request"`,
+			wantError: true,
+		},
+		{
+			name:      "error: handler throws",
+			code:      "function handler(event) { throw new Error('boom'); }",
+			event:     viewerRequestEvent,
+			wantError: true,
+		},
+		{
+			name:      "error: async handler rejects",
+			code:      "import cf from 'cloudfront';\nasync function handler(event) { return await cf.kvs().get('none'); }",
+			event:     viewerRequestEvent,
+			items:     items,
+			wantError: true,
+		},
+		{
+			name:      "error: invalid format",
+			code:      "import cf from 'cloudfront';\nasync function handler(event) { await cf.kvs().get('greeting', { format: 'xml' }); return event.request; }",
+			event:     viewerRequestEvent,
+			items:     items,
+			wantError: true,
+		},
+		{
+			name:      "error: handler returns no object",
+			code:      "function handler(event) {}",
+			event:     viewerRequestEvent,
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			log := &bytes.Buffer{}
+			result, err := function.Run(c.code, []byte(c.event), c.items, func(o *function.Options) {
+				o.LogTarget = log
+			})
+			if c.wantError {
+				asst.Error(err)
+				asst.Nil(result)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, result)
+			asst.Equal(c.expectLog, log.String())
+		})
+	}
+}
+
+func Test_Run_timeout(t *testing.T) {
+	asst := assert.New(t)
+
+	result, err := function.Run("function handler(event) { while (true) {} }", []byte(viewerRequestEvent), nil, func(o *function.Options) {
+		o.Timeout = 10 * time.Millisecond
+	})
+	asst.Error(err)
+	asst.Contains(err.Error(), "did not finish")
+	asst.Nil(result)
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+)
+
+// AWSConfigFlags are the AWS flags of one side of a command that works with two accounts or regions.
+// Flags that are not specified fall back to the global flags.
+type AWSConfigFlags struct {
+	Profile     string `name:"profile" help:"AWS profile name in the shared config files, instead of --profile."`
+	Region      string `name:"region" help:"AWS region, instead of --region."`
+	EndpointURL string `name:"endpoint-url" help:"Endpoint URL of AWS APIs, instead of --endpoint-url."`
+	RoleARN     string `name:"role-arn" help:"ARN of the IAM role to assume, instead of --role-arn."`
+	ExternalID  string `name:"external-id" help:"External ID to assume the role, instead of --external-id."`
+}
+
+func (f *AWSConfigFlags) isSet() bool {
+	return *f != AWSConfigFlags{}
+}
+
+// options returns the options to load the AWS config, merged with the global flags.
+func (f *AWSConfigFlags) options(globals *Globals) libs.AWSConfigOptions {
+	opts := globals.awsConfigOptions()
+	if f.Profile != "" {
+		opts.Profile = f.Profile
+	}
+	if f.Region != "" {
+		opts.Region = f.Region
+	}
+	if f.EndpointURL != "" {
+		opts.EndpointURL = f.EndpointURL
+	}
+	if f.RoleARN != "" {
+		// the external ID of the global role is not for this role
+		opts.RoleARN = f.RoleARN
+		opts.ExternalID = f.ExternalID
+	} else if f.ExternalID != "" {
+		opts.ExternalID = f.ExternalID
+	}
+
+	return opts
+}
+
+// withClients returns a copy of globals whose clients are for this side.
+// The clients of globals are used as they are if no flag is specified.
+func (f *AWSConfigFlags) withClients(ctx context.Context, globals *Globals) (*Globals, error) {
+	side := *globals
+	if !f.isSet() {
+		return &side, nil
+	}
+
+	cfg, err := libs.LoadAWSConfig(ctx, f.options(globals))
+	if err != nil {
+		return nil, err
+	}
+	side.S3Client = libs.NewS3Client(cfg)
+	side.CloudFrontClient = libs.NewCloudFrontClient(cfg)
+	side.CloudFrontKeyValueStoreClient = libs.NewCloudFrontKeyValueStoreClient(cfg)
+
+	return &side, nil
+}
+
+type CopySubCmd struct {
+	From   string `name:"from" help:"Name, ID or ARN of the source key value store." required:""`
+	To     string `name:"to" help:"Name, ID or ARN of the destination key value store." required:""`
+	Delete bool   `name:"delete" help:"Delete items in the destination that are not in the source. With a filter, only the items whose keys match it are deleted."`
+	Yes    bool   `name:"yes" short:"y" help:"Execute copy. If not specified, only show the items to be copied."`
+
+	Source      AWSConfigFlags `embed:"" prefix:"from-"`
+	Destination AWSConfigFlags `embed:"" prefix:"to-"`
+
+	KeyFilterFlags `embed:""`
+}
+
+func (c *CopySubCmd) Run(globals *Globals) error {
+	if c.From == "" {
+		return errors.New("from is required")
+	}
+	if c.To == "" {
+		return errors.New("to is required")
+	}
+	filter, err := c.keyFilter()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	src, err := c.Source.withClients(ctx, globals)
+	if err != nil {
+		return err
+	}
+	dst, err := c.Destination.withClients(ctx, globals)
+	if err != nil {
+		return err
+	}
+
+	srcARN, err := getKVSArn(ctx, src.CloudFrontClient, c.From)
+	if err != nil {
+		return err
+	}
+	dstARN, err := getKVSArn(ctx, dst.CloudFrontClient, c.To)
+	if err != nil {
+		return err
+	}
+	if srcARN == dstARN && c.Source.options(globals) == c.Destination.options(globals) {
+		return errors.New("from and to are the same key value store")
+	}
+
+	after, err := libs.ListItems(ctx, src.CloudFrontKeyValueStoreClient, srcARN)
+	if err != nil {
+		return err
+	}
+	after = after.Filter(filter)
+
+	diff, eTag, err := syncDiff(ctx, dst, dstARN, after, c.Delete, filter)
+	if err != nil {
+		return err
+	}
+
+	// show diff
+	if err := renderDiff(globals, diff); err != nil {
+		return err
+	}
+
+	if !c.Yes {
+		return nil
+	}
+
+	return applyDiffWithConflictRetry(ctx, dst, dstARN, diff, eTag, "copy", func() (*types.ItemListDiff, string, error) {
+		return syncDiff(ctx, dst, dstARN, after, c.Delete, filter)
+	})
+}
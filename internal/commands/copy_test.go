@@ -0,0 +1,170 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/michimani/cfkvs/emulator"
+	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CopySubCmd_Run(t *testing.T) {
+	srcItems := []types.Item{
+		{Key: "flags/a", Value: "on"},
+		{Key: "flags/b", Value: "off"},
+		{Key: "redirects/a", Value: "/new"},
+	}
+	dstItems := []types.Item{
+		{Key: "flags/a", Value: "off"},
+		{Key: "flags/c", Value: "on"},
+		{Key: "redirects/b", Value: "/old"},
+	}
+
+	cases := []struct {
+		name        string
+		cmd         *commands.CopySubCmd
+		wantError   bool
+		expectItems []types.Item
+	}{
+		{
+			name:        "ok: dry run",
+			cmd:         &commands.CopySubCmd{From: "src", To: "dst", Delete: true},
+			expectItems: dstItems,
+		},
+		{
+			name: "ok: copy",
+			cmd:  &commands.CopySubCmd{From: "src", To: "dst", Yes: true},
+			expectItems: []types.Item{
+				{Key: "flags/a", Value: "on"},
+				{Key: "flags/b", Value: "off"},
+				{Key: "flags/c", Value: "on"},
+				{Key: "redirects/a", Value: "/new"},
+				{Key: "redirects/b", Value: "/old"},
+			},
+		},
+		{
+			name:        "ok: mirror",
+			cmd:         &commands.CopySubCmd{From: "src", To: "dst", Delete: true, Yes: true},
+			expectItems: srcItems,
+		},
+		{
+			name: "ok: mirror with prefix",
+			cmd: &commands.CopySubCmd{
+				From: "src", To: "dst", Delete: true, Yes: true,
+				KeyFilterFlags: commands.KeyFilterFlags{Prefix: "flags/"},
+			},
+			expectItems: []types.Item{
+				{Key: "flags/a", Value: "on"},
+				{Key: "flags/b", Value: "off"},
+				{Key: "redirects/b", Value: "/old"},
+			},
+		},
+		{
+			name:        "error: same key value store",
+			cmd:         &commands.CopySubCmd{From: "dst", To: "dst", Yes: true},
+			wantError:   true,
+			expectItems: dstItems,
+		},
+		{
+			name:        "error: source not found",
+			cmd:         &commands.CopySubCmd{From: "not-found", To: "dst", Yes: true},
+			wantError:   true,
+			expectItems: dstItems,
+		},
+		{
+			name:        "error: invalid filter",
+			cmd:         &commands.CopySubCmd{From: "src", To: "dst", Yes: true, KeyFilterFlags: commands.KeyFilterFlags{Regex: "("}},
+			wantError:   true,
+			expectItems: dstItems,
+		},
+		{
+			name: "error: external id without role arn",
+			cmd: &commands.CopySubCmd{
+				From: "src", To: "dst", Yes: true,
+				Source: commands.AWSConfigFlags{ExternalID: "external-id"},
+			},
+			wantError:   true,
+			expectItems: dstItems,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e := emulator.New()
+			createTestKVS(tt, e, "src", "", srcItems)
+			dstARN := createTestKVS(tt, e, "dst", "", dstItems)
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  out,
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+			} else {
+				asst.NoError(err)
+				asst.NotEmpty(out.String())
+			}
+
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), dstARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectItems, items.Data)
+		})
+	}
+}
+
+func Test_CopySubCmd_Run_acrossAccounts(t *testing.T) {
+	asst := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ENDPOINT_URL", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "dummy")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "dummy")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	// the same name in another account
+	ctx := context.Background()
+	staging := emulator.New(func(o *emulator.Options) { o.AccountID = "111111111111" })
+	production := emulator.New(func(o *emulator.Options) { o.AccountID = "222222222222" })
+	srv := httptest.NewServer(staging.Handler())
+	defer srv.Close()
+
+	createTestKVS(t, staging, "kvs-name", "", []types.Item{{Key: "key1", Value: "staging"}})
+	prodARN := createTestKVS(t, production, "kvs-name", "", nil)
+
+	globals := &commands.Globals{
+		Output:                        output.OutputTypeTable,
+		CloudFrontClient:              production.CloudFront(),
+		CloudFrontKeyValueStoreClient: production.KeyValueStore(),
+		OutputTarget:                  &bytes.Buffer{},
+	}
+	cmd := &commands.CopySubCmd{
+		From:   "kvs-name",
+		To:     "kvs-name",
+		Yes:    true,
+		Source: commands.AWSConfigFlags{EndpointURL: srv.URL},
+	}
+	asst.NoError(cmd.Run(globals))
+
+	items, err := libs.ListItems(ctx, production.KeyValueStore(), prodARN)
+	asst.NoError(err)
+	asst.Equal([]types.Item{{Key: "key1", Value: "staging"}}, items.Data)
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/michimani/cfkvs/emulator"
+)
+
+type EmulatorCmd struct {
+	Addr      string `name:"addr" help:"Address to listen on." default:"127.0.0.1:4599"`
+	AccountID string `name:"account-id" help:"AWS account ID used in ARNs of key value stores." default:"123456789012"`
+}
+
+func (c *EmulatorCmd) Run(globals *Globals) error {
+	l, err := net.Listen("tcp", c.Addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	e := emulator.New(func(o *emulator.Options) {
+		if c.AccountID != "" {
+			o.AccountID = c.AccountID
+		}
+	})
+
+	globals.logf("emulator is listening on http://%s\n", l.Addr())
+	return http.Serve(l, e.Handler())
+}
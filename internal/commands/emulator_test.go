@@ -0,0 +1,91 @@
+package commands_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmulatorCmd_Run(t *testing.T) {
+	cases := []struct {
+		name      string
+		cmd       *commands.EmulatorCmd
+		wantError bool
+	}{
+		{
+			name:      "error: invalid address",
+			cmd:       &commands.EmulatorCmd{Addr: "invalid address"},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.cmd.Run(&commands.Globals{})
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
+
+func Test_EmulatorCmd_Run_serve(t *testing.T) {
+	asst := assert.New(t)
+
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "dummy")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "dummy")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	// the address to listen on is logged, after the listener is ready
+	pr, pw := io.Pipe()
+	cmd := &commands.EmulatorCmd{Addr: "127.0.0.1:0", AccountID: "111111111111"}
+	go func() {
+		// Run returns only on an error, that fails the read of the address
+		_ = pw.CloseWithError(cmd.Run(&commands.Globals{LogTarget: pw}))
+	}()
+
+	line, err := bufio.NewReader(pr).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := strings.TrimSpace(strings.TrimPrefix(line, "emulator is listening on "))
+
+	ctx := context.Background()
+	cfg, err := libs.LoadAWSConfig(ctx, libs.AWSConfigOptions{EndpointURL: endpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfc := libs.NewCloudFrontClient(cfg)
+
+	created, err := libs.CreateKeyValueStore(ctx, cfc, "kvs-name", "", nil)
+	if asst.NoError(err) {
+		asst.Contains(aws.ToString(created.KeyValueStore.ARN), ":111111111111:")
+	}
+
+	out, err := libs.ListKeyValueStore(ctx, cfc)
+	asst.NoError(err)
+	kvsList := types.KVSList{}
+	asst.NoError(kvsList.Parse(out))
+	if asst.Len(kvsList, 1) {
+		asst.Equal("kvs-name", kvsList[0].Name)
+	}
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/michimani/cfkvs/libs"
+)
+
+// Exit codes other than 1, that is used for any other error.
+const (
+	// ExitCodeChangesPending is used by --detailed-exitcode when the dry run has changes to apply.
+	ExitCodeChangesPending = 2
+
+	// ExitCodePreconditionFailed is used when the condition of a conditional write is not satisfied.
+	ExitCodePreconditionFailed = 3
+)
+
+// ExitError is an error that makes cfkvs exit with the code.
+// If Err is nil, cfkvs exits with the code without any error message.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// withExitCode wraps the error with the exit code for it, if it has a specific one.
+func withExitCode(err error) error {
+	var precondition *libs.PreconditionFailedError
+	if errors.As(err, &precondition) {
+		return &ExitError{Code: ExitCodePreconditionFailed, Err: err}
+	}
+
+	return err
+}
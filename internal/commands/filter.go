@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"github.com/michimani/cfkvs/types"
+)
+
+// KeyFilterFlags are the flags to scope a command to the keys that match all of the specified filters.
+type KeyFilterFlags struct {
+	Prefix string `name:"prefix" help:"Only the keys that start with this prefix."`
+	Glob   string `name:"glob" help:"Only the keys that match this glob pattern. * does not match /."`
+	Regex  string `name:"regex" help:"Only the keys that match this regular expression."`
+}
+
+func (f *KeyFilterFlags) keyFilter() (*types.KeyFilter, error) {
+	return types.NewKeyFilter(f.Prefix, f.Glob, f.Regex)
+}
+
+// filterSyncSource drops the items in the source that do not match the filter,
+// so that sync never touches keys out of the scope.
+func filterSyncSource(globals *Globals, after *types.ItemList, filter *types.KeyFilter) *types.ItemList {
+	if filter == nil {
+		return after
+	}
+
+	filtered := after.Filter(filter)
+	if ignored := len(after.Data) - len(filtered.Data); ignored > 0 {
+		globals.logf("%d items in the source are ignored because their keys do not match the filter\n", ignored)
+	}
+
+	return filtered
+}
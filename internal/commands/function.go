@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/michimani/cfkvs/function"
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+)
+
+type FunctionCmd struct {
+	Test FunctionTestSubCmd `cmd:"" help:"Run a CloudFront Function locally with an event, and show the request or response that it returns."`
+}
+
+type FunctionTestSubCmd struct {
+	Code  string `name:"code" help:"Path to the code of the function, that runs on the cloudfront-js-2.0 runtime." required:""`
+	Event string `name:"event" help:"Path to the event object of the function in JSON." required:""`
+
+	KVSName string `name:"kvs-name" help:"Name, ID or ARN of the key value store that the function reads with cf.kvs()."`
+	Data    string `name:"data" help:"Path to the file of items that the function reads with cf.kvs(), instead of a key value store."`
+	Format  string `name:"format" help:"Format of the file specified with --data. One of: auto, json, csv, tsv, yaml, ndjson, dotenv. auto detects it from the extension." enum:"auto,json,csv,tsv,yaml,ndjson,dotenv" default:"auto"`
+
+	Timeout time.Duration `name:"timeout" help:"Maximum time to run the function." default:"5s"`
+}
+
+func (c *FunctionTestSubCmd) Run(globals *Globals) error {
+	if c.Code == "" {
+		return errors.New("code is required")
+	}
+	if c.Event == "" {
+		return errors.New("event is required")
+	}
+	if c.KVSName != "" && c.Data != "" {
+		return errors.New("kvs-name and data cannot be specified at the same time")
+	}
+
+	code, err := os.ReadFile(c.Code)
+	if err != nil {
+		return err
+	}
+
+	event, err := os.ReadFile(c.Event)
+	if err != nil {
+		return err
+	}
+
+	items, err := c.items(globals)
+	if err != nil {
+		return err
+	}
+
+	result, err := function.Run(string(code), event, items, func(o *function.Options) {
+		o.Timeout = c.Timeout
+		o.LogTarget = globals.LogTarget
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := globals.render(&output.QueryResult{Value: result}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// items returns the items that the function reads, from the key value store or the data file.
+// The function runs with no item if neither is specified.
+func (c *FunctionTestSubCmd) items(globals *Globals) (*types.ItemList, error) {
+	if c.Data != "" {
+		format, err := types.ParseDataFormat(c.Format)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := libs.GetKeyValueStoreDataFromFile(c.Data, format)
+		if err != nil {
+			return nil, err
+		}
+
+		return data.ToItemList(), nil
+	}
+
+	if c.KVSName == "" {
+		return types.NewItemList(nil), nil
+	}
+
+	// the clients are created only here, so that the function runs offline with --data
+	ctx := context.TODO()
+	if err := globals.LoadClients(ctx); err != nil {
+		return nil, err
+	}
+
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.KVSName)
+	if err != nil {
+		return nil, err
+	}
+
+	return libs.ListItems(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN)
+}
@@ -0,0 +1,199 @@
+package commands_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FunctionTestSubCmd_Run(t *testing.T) {
+	redirect := map[string]any{
+		"statusCode":        float64(302),
+		"statusDescription": "Found",
+		"headers":           map[string]any{"location": map[string]any{"value": "/new"}},
+	}
+
+	cases := []struct {
+		name      string
+		cmd       *commands.FunctionTestSubCmd
+		seed      []types.Item
+		expect    any
+		expectLog string
+		wantError bool
+	}{
+		{
+			name: "ok: kvs-name",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:    "../../testdata/function.js",
+				Event:   "../../testdata/function-event.json",
+				KVSName: "kvs-name",
+			},
+			seed:   []types.Item{{Key: "/old", Value: `{"statusCode":302,"location":"/new"}`}},
+			expect: redirect,
+		},
+		{
+			name: "ok: data",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:   "../../testdata/function.js",
+				Event:  "../../testdata/function-event.json",
+				Data:   "../../testdata/function-data.json",
+				Format: "auto",
+			},
+			expect: redirect,
+		},
+		{
+			name: "ok: no items",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:  "../../testdata/function.js",
+				Event: "../../testdata/function-event.json",
+			},
+			expect: map[string]any{
+				"method":      "GET",
+				"uri":         "/old",
+				"querystring": map[string]any{},
+				"headers":     map[string]any{"host": map[string]any{"value": "example.com"}},
+				"cookies":     map[string]any{},
+			},
+			expectLog: "no redirect for /old\n",
+		},
+		{
+			name: "error: kvs-name and data",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:    "../../testdata/function.js",
+				Event:   "../../testdata/function-event.json",
+				KVSName: "kvs-name",
+				Data:    "../../testdata/function-data.json",
+			},
+			wantError: true,
+		},
+		{
+			name: "error: code not found",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:  "../../testdata/not-found.js",
+				Event: "../../testdata/function-event.json",
+			},
+			wantError: true,
+		},
+		{
+			name: "error: event not found",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:  "../../testdata/function.js",
+				Event: "../../testdata/not-found.json",
+			},
+			wantError: true,
+		},
+		{
+			name: "error: key value store not found",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:    "../../testdata/function.js",
+				Event:   "../../testdata/function-event.json",
+				KVSName: "not-found",
+			},
+			wantError: true,
+		},
+		{
+			name: "error: invalid data",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:   "../../testdata/function.js",
+				Event:  "../../testdata/function-event.json",
+				Data:   "../../testdata/invalid-1.json",
+				Format: "auto",
+			},
+			wantError: true,
+		},
+		{
+			name: "error: event is not valid",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:  "../../testdata/function.js",
+				Event: "../../testdata/valid.csv",
+			},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, _ := newTestKVS(tt, c.seed)
+
+			out := &bytes.Buffer{}
+			log := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        output.OutputTypeJson,
+				OutputTarget:                  out,
+				LogTarget:                     log,
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				asst.Empty(out.String())
+				return
+			}
+
+			asst.NoError(err)
+			var result any
+			if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+				tt.Fatal(err)
+			}
+			asst.Equal(c.expect, result)
+			asst.Equal(c.expectLog, log.String())
+		})
+	}
+}
+
+func Test_FunctionTestSubCmd_Run_offline(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_PROFILE", "not-found")
+
+	cases := []struct {
+		name      string
+		cmd       *commands.FunctionTestSubCmd
+		wantError bool
+	}{
+		{
+			name: "ok: data without an AWS config",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:   "../../testdata/function.js",
+				Event:  "../../testdata/function-event.json",
+				Data:   "../../testdata/function-data.json",
+				Format: "auto",
+			},
+		},
+		{
+			name: "error: kvs-name needs an AWS config",
+			cmd: &commands.FunctionTestSubCmd{
+				Code:    "../../testdata/function.js",
+				Event:   "../../testdata/function-event.json",
+				KVSName: "kvs-name",
+			},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			globals := &commands.Globals{Output: output.OutputTypeTable, OutputTarget: &bytes.Buffer{}}
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"text/template"
 
 	"github.com/alecthomas/kong"
+	"github.com/jmespath/go-jmespath"
 	"github.com/michimani/cfkvs/internal/output"
 	"github.com/michimani/cfkvs/libs"
 )
@@ -19,13 +24,151 @@ func (v VersionFlag) BeforeApply(app *kong.Kong, vars kong.Vars) error {
 	return nil
 }
 
+// QueryFlag is a JMESPath expression, that is validated on parse before any request.
+type QueryFlag string
+
+func (q QueryFlag) Validate() error {
+	if q == "" {
+		return nil
+	}
+
+	if _, err := jmespath.Compile(string(q)); err != nil {
+		return fmt.Errorf("invalid JMESPath expression: %w", err)
+	}
+
+	return nil
+}
+
 type Globals struct {
 	Debug   bool              `short:"D" name:"debug" help:"Enable debug mode."`
-	Output  output.OutputType `name:"output" help:"Output format. One of: json, table." default:"table"`
+	Output  output.OutputType `name:"output" help:"Output format. One of: table, json, yaml, csv, tsv, markdown, template." enum:"table,json,yaml,csv,tsv,markdown,template" default:"table"`
+	Query   QueryFlag         `name:"query" help:"JMESPath expression to apply to the JSON form of the output, before rendering it in the output format."`
 	Version VersionFlag       `name:"version" help:"Print version information and quit"`
 
+	Template     string `name:"template" help:"Go template for the template output."`
+	TemplateFile string `name:"template-file" help:"Path to the file of the Go template for the template output."`
+
+	Profile     string `name:"profile" help:"AWS profile name in the shared config files."`
+	Region      string `name:"region" help:"AWS region."`
+	EndpointURL string `name:"endpoint-url" help:"Override the endpoint URL of AWS APIs, e.g. for the local emulator."`
+	RoleARN     string `name:"role-arn" help:"ARN of the IAM role to assume."`
+	ExternalID  string `name:"external-id" help:"External ID to assume the role specified with --role-arn."`
+
+	Retries int `name:"retries" help:"Number of retries when a write conflicts with another write to the key value store." default:"3"`
+
 	S3Client                      libs.S3Client                      `kong:"-"`
 	CloudFrontClient              libs.CloudFrontClient              `kong:"-"`
 	CloudFrontKeyValueStoreClient libs.CloudFrontKeyValueStoreClient `kong:"-"`
+	InputSource                   io.Reader                          `kong:"-"`
 	OutputTarget                  io.Writer                          `kong:"-"`
+	LogTarget                     io.Writer                          `kong:"-"`
+}
+
+// Validate checks the flags of the template output on parse, before any request.
+func (g *Globals) Validate() error {
+	if g.Template != "" && g.TemplateFile != "" {
+		return errors.New("template and template-file cannot be specified at the same time")
+	}
+
+	hasTemplate := g.Template != "" || g.TemplateFile != ""
+	if g.Output != output.OutputTypeTemplate {
+		if hasTemplate {
+			return errors.New("template and template-file are only for --output=template")
+		}
+		return nil
+	}
+	if !hasTemplate {
+		return errors.New("template or template-file is required for --output=template")
+	}
+
+	_, err := g.template()
+	return err
+}
+
+// template parses the template of the template output.
+func (g *Globals) template() (*template.Template, error) {
+	text := g.Template
+	if g.TemplateFile != "" {
+		b, err := os.ReadFile(g.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the template file: %w", err)
+		}
+		text = string(b)
+	}
+
+	tmpl, err := output.NewTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// logf writes a progress message to LogTarget.
+// The message is discarded if LogTarget is not set.
+func (g *Globals) logf(format string, a ...any) {
+	if g.LogTarget == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(g.LogTarget, format, a...)
+}
+
+// render renders the data in the output format, after applying the query if specified.
+func (g *Globals) render(data any) error {
+	if g.Query != "" {
+		result, err := output.Query(data, string(g.Query))
+		if err != nil {
+			return err
+		}
+		data = result
+	}
+
+	if g.Output == output.OutputTypeTemplate {
+		tmpl, err := g.template()
+		if err != nil {
+			return err
+		}
+		return output.RenderAsTemplate(data, tmpl, g.OutputTarget)
+	}
+
+	return output.Render(data, g.Output, g.OutputTarget)
+}
+
+// awsConfigOptions returns the options to load the AWS config from the global flags.
+func (g *Globals) awsConfigOptions() libs.AWSConfigOptions {
+	return libs.AWSConfigOptions{
+		Profile:     g.Profile,
+		Region:      g.Region,
+		EndpointURL: g.EndpointURL,
+		RoleARN:     g.RoleARN,
+		ExternalID:  g.ExternalID,
+	}
+}
+
+// LoadClients creates the clients of AWS APIs from the global flags, if they have not been set.
+// Commands that call AWS APIs only with some flags call it by themselves, so that they run offline without an AWS config.
+func (g *Globals) LoadClients(ctx context.Context) error {
+	if g.CloudFrontClient != nil && g.CloudFrontKeyValueStoreClient != nil {
+		return nil
+	}
+
+	cfg, err := libs.LoadAWSConfig(ctx, g.awsConfigOptions())
+	if err != nil {
+		return err
+	}
+
+	g.S3Client = libs.NewS3Client(cfg)
+	g.CloudFrontClient = libs.NewCloudFrontClient(cfg)
+	g.CloudFrontKeyValueStoreClient = libs.NewCloudFrontKeyValueStoreClient(cfg)
+
+	return nil
+}
+
+// writeItemOptions sets the retry options of item writes from the global flags.
+func (g *Globals) writeItemOptions(o *libs.WriteItemOptions) {
+	o.Retries = g.Retries
+	o.OnRetry = func(retry int, err error) {
+		g.logf("conflicted with another write, retrying (%d/%d): %v\n", retry, g.Retries, err)
+	}
 }
@@ -0,0 +1,152 @@
+package commands_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michimani/cfkvs/emulator"
+	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Globals_query(t *testing.T) {
+	e := emulator.New()
+	kvsARN := createTestKVS(t, e, "kvs-b", "", []types.Item{{Key: "key-1", Value: "old"}})
+	createTestKVS(t, e, "kvs-a", "", nil)
+
+	cases := []struct {
+		name       string
+		cmd        interface{ Run(*commands.Globals) error }
+		query      commands.QueryFlag
+		outputType output.OutputType
+		template   string
+		expect     string
+	}{
+		{
+			name:       "kvs list",
+			cmd:        &commands.ListKVSSubCmd{},
+			query:      "[?name=='kvs-b'].arn | [0]",
+			outputType: output.OutputTypeTsv,
+			expect:     kvsARN + "\n",
+		},
+		{
+			name:       "item list",
+			cmd:        &commands.ListItemsSubCmd{KVSName: "kvs-b"},
+			query:      "Data[].key",
+			outputType: output.OutputTypeJson,
+			expect:     "[\n    \"key-1\"\n]\n",
+		},
+		{
+			name:       "template",
+			cmd:        &commands.ListItemsSubCmd{KVSName: "kvs-b"},
+			outputType: output.OutputTypeTemplate,
+			template:   `{{range .Data}}{{.Key}}={{.Value}}{{"\n"}}{{end}}`,
+			expect:     "key-1=old\n",
+		},
+		{
+			name:       "template with query",
+			cmd:        &commands.ListKVSSubCmd{},
+			query:      "sort([].name)",
+			outputType: output.OutputTypeTemplate,
+			template:   `{{range .}}{{.}} {{end}}`,
+			expect:     "kvs-a kvs-b ",
+		},
+		{
+			name:       "sync diff is queried as a diff document",
+			cmd:        &commands.SyncSubCmd{Name: "kvs-b", File: "../../testdata/valid.json"},
+			query:      "summary.{added: added, updated: updated}",
+			outputType: output.OutputTypeTable,
+			expect: `+-------+---------+
+| ADDED | UPDATED |
++-------+---------+
+| 2     | 1       |
++-------+---------+
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Query:                         c.query,
+				Output:                        c.outputType,
+				Template:                      c.template,
+				OutputTarget:                  out,
+			}
+
+			asst.NoError(c.cmd.Run(globals))
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
+
+func Test_Globals_Validate(t *testing.T) {
+	templateFile := filepath.Join(t.TempDir(), "items.tmpl")
+	if err := os.WriteFile(templateFile, []byte(`{{range .Data}}{{.Key}}{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		globals *commands.Globals
+		wantErr bool
+	}{
+		{name: "table", globals: &commands.Globals{Output: output.OutputTypeTable}},
+		{name: "template", globals: &commands.Globals{Output: output.OutputTypeTemplate, Template: "{{.}}"}},
+		{name: "template file", globals: &commands.Globals{Output: output.OutputTypeTemplate, TemplateFile: templateFile}},
+		{name: "error: no template", globals: &commands.Globals{Output: output.OutputTypeTemplate}, wantErr: true},
+		{name: "error: template and template file", globals: &commands.Globals{Output: output.OutputTypeTemplate, Template: "{{.}}", TemplateFile: templateFile}, wantErr: true},
+		{name: "error: template without template output", globals: &commands.Globals{Output: output.OutputTypeJson, Template: "{{.}}"}, wantErr: true},
+		{name: "error: invalid template", globals: &commands.Globals{Output: output.OutputTypeTemplate, Template: "{{."}, wantErr: true},
+		{name: "error: template file not found", globals: &commands.Globals{Output: output.OutputTypeTemplate, TemplateFile: "not-found.tmpl"}, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.globals.Validate()
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
+
+func Test_QueryFlag_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		query   commands.QueryFlag
+		wantErr bool
+	}{
+		{name: "empty", query: ""},
+		{name: "ok", query: "[?status=='READY'].arn"},
+		{name: "error: invalid", query: "[?status==", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.query.Validate()
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/michimani/cfkvs/emulator"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+)
+
+// newTestKVS creates a key value store named kvs-name with the items in a new emulator.
+// It returns the emulator and the ARN of the key value store.
+func newTestKVS(t *testing.T, items []types.Item) (*emulator.Emulator, string) {
+	t.Helper()
+
+	e := emulator.New()
+	return e, createTestKVS(t, e, "kvs-name", "", items)
+}
+
+// createTestKVS creates a key value store with the comment and the items in the emulator, and returns its ARN.
+func createTestKVS(t *testing.T, e *emulator.Emulator, name, comment string, items []types.Item) string {
+	t.Helper()
+
+	ctx := context.Background()
+	created, err := libs.CreateKeyValueStore(ctx, e.CloudFront(), name, comment, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvsARN := aws.ToString(created.KeyValueStore.ARN)
+	if len(items) > 0 {
+		if _, err := libs.SyncItems(ctx, e.KeyValueStore(), kvsARN, items, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return kvsARN
+}
@@ -3,8 +3,9 @@ package commands
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 
-	"github.com/michimani/cfkvs/internal/output"
 	"github.com/michimani/cfkvs/libs"
 	"github.com/michimani/cfkvs/types"
 )
@@ -12,28 +13,50 @@ import (
 type ItemCmd struct {
 	List   ListItemsSubCmd `cmd:"" help:"List items in the key value store."`
 	Get    GetSubCmd       `cmd:"" help:"Get an item in the key value store."`
-	Put    PutSubCmd       `cmd:"" help:"Put an item in the key value store."`
-	Delete DeleteSubCmd    `cmd:"" help:"Delete an item in the key value store."`
+	Put    PutSubCmd       `cmd:"" help:"Put an item, or items in a file, in the key value store."`
+	Delete DeleteSubCmd    `cmd:"" help:"Delete an item, or items of keys, in the key value store."`
 }
 
 type ListItemsSubCmd struct {
-	KVSName string `name:"kvs-name" help:"Name of the key value store." required:""`
+	KVSName string `name:"kvs-name" help:"Name, ID or ARN of the key value store." required:""`
+
+	KeyFilterFlags `embed:""`
 }
 
 type GetSubCmd struct {
-	KVSName string `name:"kvs-name" help:"Name of the key value store." required:""`
+	KVSName string `name:"kvs-name" help:"Name, ID or ARN of the key value store." required:""`
 	Key     string `name:"key" help:"Key of the item to get." required:""`
 }
 
 type PutSubCmd struct {
-	KVSName string `name:"kvs-name" help:"Name of the key value store." required:""`
-	Key     string `name:"key" help:"Key of the item to put." required:""`
-	Value   string `name:"value" help:"Value of the item to put." required:""`
+	KVSName string `name:"kvs-name" help:"Name, ID or ARN of the key value store." required:""`
+	Key     string `name:"key" help:"Key of the item to put."`
+	Value   string `name:"value" help:"Value of the item to put."`
+
+	ValueFile  string `name:"value-file" help:"Path to the file to read the value of the item from. Use - to read from stdin."`
+	MinifyJSON bool   `name:"minify-json" help:"Minify the value as JSON before putting it."`
+
+	IfNotExists bool   `name:"if-not-exists" help:"Put only if the key does not exist."`
+	IfValue     string `name:"if-value" help:"Put only if the current value of the key is this value."`
+	IfETag      string `name:"if-etag" help:"Put only if the current ETag of the key value store is this ETag."`
+
+	FromFile string `name:"from-file" help:"Path to the file of items to put. Keys that are not in the file are not changed."`
+	Format   string `name:"format" help:"Format of the file specified with --from-file. One of: auto, json, csv, tsv, yaml, ndjson, dotenv. auto detects it from the extension." enum:"auto,json,csv,tsv,yaml,ndjson,dotenv" default:"auto"`
+	Yes      bool   `name:"yes" short:"y" help:"Execute the put of the items in --from-file. If not specified, only show the items to be put."`
 }
 
 type DeleteSubCmd struct {
-	KVSName string `name:"kvs-name" help:"Name of the key value store." required:""`
-	Key     string `name:"key" help:"Key of the item to delete." required:""`
+	KVSName string `name:"kvs-name" help:"Name, ID or ARN of the key value store." required:""`
+	Key     string `name:"key" help:"Key of the item to delete."`
+
+	IfValue string `name:"if-value" help:"Delete only if the current value of the key is this value."`
+	IfETag  string `name:"if-etag" help:"Delete only if the current ETag of the key value store is this ETag."`
+
+	Keys     []string `name:"keys" help:"Comma separated keys of the items to delete."`
+	KeysFile string   `name:"keys-file" help:"Path to the file of keys of the items to delete, one key per line."`
+	Yes      bool     `name:"yes" short:"y" help:"Execute the delete of the items in --keys, --keys-file or the filter. If not specified, only show the items to be deleted."`
+
+	KeyFilterFlags `embed:""`
 }
 
 func getKVSArn(ctx context.Context, cfc libs.CloudFrontClient, kvsName string) (string, error) {
@@ -45,10 +68,23 @@ func getKVSArn(ctx context.Context, cfc libs.CloudFrontClient, kvsName string) (
 	return kvsARN, nil
 }
 
+func getKVSName(ctx context.Context, cfc libs.CloudFrontClient, kvsName string) (string, error) {
+	name, err := libs.GetKeyValueStoreName(ctx, cfc, kvsName)
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
 func (c *ListItemsSubCmd) Run(globals *Globals) error {
 	if c.KVSName == "" {
 		return errors.New("kvs-name is required")
 	}
+	filter, err := c.keyFilter()
+	if err != nil {
+		return err
+	}
 
 	ctx := context.TODO()
 	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.KVSName)
@@ -61,7 +97,7 @@ func (c *ListItemsSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	if err := output.Render(itemList, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(itemList.Filter(filter)); err != nil {
 		return err
 	}
 
@@ -92,7 +128,7 @@ func (c *GetSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	if err := output.Render(&item, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(&item); err != nil {
 		return err
 	}
 
@@ -103,11 +139,25 @@ func (c *PutSubCmd) Run(globals *Globals) error {
 	if c.KVSName == "" {
 		return errors.New("kvs-name is required")
 	}
+	if c.FromFile != "" {
+		return c.runBulk(globals)
+	}
 	if c.Key == "" {
 		return errors.New("key is required")
 	}
-	if c.Value == "" {
-		return errors.New("value is required")
+	if c.Value == "" && c.ValueFile == "" {
+		return errors.New("value or value-file is required")
+	}
+	if c.Value != "" && c.ValueFile != "" {
+		return errors.New("value and value-file cannot be specified at the same time")
+	}
+	if c.IfNotExists && c.IfValue != "" {
+		return errors.New("if-not-exists and if-value cannot be specified at the same time")
+	}
+
+	value, err := c.value(globals)
+	if err != nil {
+		return err
 	}
 
 	ctx := context.TODO()
@@ -116,9 +166,13 @@ func (c *PutSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	out, err := libs.PutItem(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN, c.Key, c.Value)
+	out, err := libs.PutItem(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN, c.Key, value, globals.writeItemOptions, func(o *libs.WriteItemOptions) {
+		o.IfNotExists = c.IfNotExists
+		o.IfValue = optionalString(c.IfValue)
+		o.IfETag = optionalString(c.IfETag)
+	})
 	if err != nil {
-		return err
+		return withExitCode(err)
 	}
 
 	kvsSimple := types.KVSSimple{}
@@ -126,17 +180,114 @@ func (c *PutSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	if err := output.Render(&kvsSimple, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(&kvsSimple); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// runBulk puts the items in the file by UpdateKeys requests, without changing the other keys.
+// Like sync, it only shows the diff unless --yes is specified.
+func (c *PutSubCmd) runBulk(globals *Globals) error {
+	if c.Key != "" || c.Value != "" || c.ValueFile != "" || c.MinifyJSON {
+		return errors.New("key, value, value-file and minify-json cannot be specified with from-file")
+	}
+	if c.IfNotExists || c.IfValue != "" || c.IfETag != "" {
+		return errors.New("if-not-exists, if-value and if-etag cannot be specified with from-file")
+	}
+	format, err := types.ParseDataFormat(c.Format)
+	if err != nil {
+		return err
+	}
+
+	data, err := libs.GetKeyValueStoreDataFromFile(c.FromFile, format)
+	if err != nil {
+		return err
+	}
+	after := data.ToItemList()
+
+	ctx := context.TODO()
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.KVSName)
+	if err != nil {
+		return err
+	}
+
+	diff, eTag, err := syncDiff(ctx, globals, kvsARN, after, false, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := renderDiff(globals, diff); err != nil {
+		return err
+	}
+
+	if !c.Yes {
+		return nil
+	}
+
+	return applyDiffWithConflictRetry(ctx, globals, kvsARN, diff, eTag, "item put", func() (*types.ItemListDiff, string, error) {
+		return syncDiff(ctx, globals, kvsARN, after, false, nil)
+	})
+}
+
+// value returns the value to put, read from the value-file if specified.
+// The value is checked against the quotas before any request to the key value store.
+func (c *PutSubCmd) value(globals *Globals) (string, error) {
+	value := c.Value
+	if c.ValueFile != "" {
+		b, err := readValueFile(c.ValueFile, globals.InputSource)
+		if err != nil {
+			return "", err
+		}
+		value = string(b)
+	}
+
+	if c.MinifyJSON {
+		minified, err := types.MinifyJSON(value)
+		if err != nil {
+			return "", err
+		}
+		value = minified
+	}
+
+	if err := types.ValidateValue(value); err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+// readValueFile reads the whole content of the file, or of stdin if the path is "-".
+// The content is used as it is, including a trailing newline.
+func readValueFile(path string, stdin io.Reader) ([]byte, error) {
+	if path != "-" {
+		return libs.GetValueFromFile(path)
+	}
+
+	if stdin == nil {
+		return nil, errors.New("stdin is not available")
+	}
+
+	b, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the value from stdin: %w", err)
+	}
+
+	return b, nil
+}
+
 func (c *DeleteSubCmd) Run(globals *Globals) error {
 	if c.KVSName == "" {
 		return errors.New("kvs-name is required")
 	}
+	filter, err := c.keyFilter()
+	if err != nil {
+		return err
+	}
+	if len(c.Keys) > 0 || c.KeysFile != "" || filter != nil {
+		return c.runBulk(globals, filter)
+	}
 	if c.Key == "" {
 		return errors.New("key is required")
 	}
@@ -147,9 +298,12 @@ func (c *DeleteSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	out, err := libs.DeleteItem(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN, c.Key)
+	out, err := libs.DeleteItem(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN, c.Key, globals.writeItemOptions, func(o *libs.WriteItemOptions) {
+		o.IfValue = optionalString(c.IfValue)
+		o.IfETag = optionalString(c.IfETag)
+	})
 	if err != nil {
-		return err
+		return withExitCode(err)
 	}
 
 	kvsSimple := types.KVSSimple{}
@@ -157,9 +311,77 @@ func (c *DeleteSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	if err := output.Render(&kvsSimple, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(&kvsSimple); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// runBulk deletes the items of the keys by UpdateKeys requests.
+// If the filter is specified, only the keys that match it are deleted, or all of them if no key is specified.
+// Keys that do not exist are ignored. Like sync, it only shows the diff unless --yes is specified.
+func (c *DeleteSubCmd) runBulk(globals *Globals, filter *types.KeyFilter) error {
+	if c.Key != "" {
+		return errors.New("key cannot be specified with keys, keys-file or a filter")
+	}
+	if c.IfValue != "" || c.IfETag != "" {
+		return errors.New("if-value and if-etag cannot be specified with keys, keys-file or a filter")
+	}
+
+	selectAll := len(c.Keys) == 0 && c.KeysFile == ""
+	keys := c.Keys
+	if c.KeysFile != "" {
+		fromFile, err := libs.GetKeysFromFile(c.KeysFile)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, fromFile...)
+	}
+	for _, key := range keys {
+		if key == "" {
+			return errors.New("keys cannot contain an empty key")
+		}
+	}
+
+	ctx := context.TODO()
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.KVSName)
+	if err != nil {
+		return err
+	}
+
+	deleteDiff := func() (*types.ItemListDiff, string, error) {
+		before, eTag, err := listItemsWithETag(ctx, globals, kvsARN)
+		if err != nil {
+			return nil, "", err
+		}
+		before = before.Filter(filter)
+		if selectAll {
+			return before.Diff(types.NewItemList(nil), true), eTag, nil
+		}
+		return before.DeleteDiff(keys), eTag, nil
+	}
+
+	diff, eTag, err := deleteDiff()
+	if err != nil {
+		return err
+	}
+
+	if err := renderDiff(globals, diff); err != nil {
+		return err
+	}
+
+	if !c.Yes {
+		return nil
+	}
+
+	return applyDiffWithConflictRetry(ctx, globals, kvsARN, diff, eTag, "item delete", deleteDiff)
+}
+
+// optionalString returns nil for an empty string, that means the flag is not specified.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
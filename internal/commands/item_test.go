@@ -2,8 +2,12 @@ package commands_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -14,7 +18,9 @@ import (
 	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
 	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -83,6 +89,7 @@ func Test_ListItemsSubCmd_Run(t *testing.T) {
 			cfcMock := c.cfcMock(ctrl)
 			kvscMock := c.kvscMock(ctrl)
 			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
 				CloudFrontClient:              cfcMock,
 				CloudFrontKeyValueStoreClient: kvscMock,
 				OutputTarget:                  &bytes.Buffer{},
@@ -99,6 +106,74 @@ func Test_ListItemsSubCmd_Run(t *testing.T) {
 	}
 }
 
+func Test_ListItemsSubCmd_Run_filter(t *testing.T) {
+	cases := []struct {
+		name       string
+		filter     commands.KeyFilterFlags
+		wantError  bool
+		expectKeys []string
+	}{
+		{
+			name:       "ok: no filter",
+			expectKeys: []string{"flags/a", "flags/team/b", "redirects/a"},
+		},
+		{
+			name:       "ok: prefix",
+			filter:     commands.KeyFilterFlags{Prefix: "flags/"},
+			expectKeys: []string{"flags/a", "flags/team/b"},
+		},
+		{
+			name:       "ok: glob",
+			filter:     commands.KeyFilterFlags{Glob: "flags/*"},
+			expectKeys: []string{"flags/a"},
+		},
+		{
+			name:       "ok: regex",
+			filter:     commands.KeyFilterFlags{Regex: "/a$"},
+			expectKeys: []string{"flags/a", "redirects/a"},
+		},
+		{
+			name:      "error: invalid glob",
+			filter:    commands.KeyFilterFlags{Glob: "["},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, _ := newTestKVS(tt, []types.Item{{Key: "flags/a", Value: "v"}, {Key: "flags/team/b", Value: "v"}, {Key: "redirects/a", Value: "v"}})
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        output.OutputTypeJson,
+				OutputTarget:                  out,
+			}
+
+			cmd := &commands.ListItemsSubCmd{KVSName: "kvs-name", KeyFilterFlags: c.filter}
+			err := cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			list := types.ItemList{}
+			if err := json.Unmarshal(out.Bytes(), &list); err != nil {
+				tt.Fatal(err)
+			}
+			keys := []string{}
+			for _, item := range list.Data {
+				keys = append(keys, item.Key)
+			}
+			asst.ElementsMatch(c.expectKeys, keys)
+		})
+	}
+}
+
 func Test_GetSubCmd_Run(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -178,6 +253,7 @@ func Test_GetSubCmd_Run(t *testing.T) {
 			cfcMock := c.cfcMock(ctrl)
 			kvscMock := c.kvscMock(ctrl)
 			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
 				CloudFrontClient:              cfcMock,
 				CloudFrontKeyValueStoreClient: kvscMock,
 			}
@@ -223,6 +299,19 @@ func Test_PutSubCmd_Run(t *testing.T) {
 				return m
 			},
 		},
+		{
+			name: "error: if-not-exists and if-value",
+			cmd: &commands.PutSubCmd{
+				KVSName:     "kvs-name",
+				Key:         "key",
+				Value:       "value",
+				IfNotExists: true,
+				IfValue:     "old",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
 		{
 			name: "error: kvsName is empty",
 			cmd: &commands.PutSubCmd{
@@ -256,6 +345,29 @@ func Test_PutSubCmd_Run(t *testing.T) {
 			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
 			wantError: true,
 		},
+		{
+			name: "error: value and value-file",
+			cmd: &commands.PutSubCmd{
+				KVSName:   "kvs-name",
+				Key:       "key",
+				Value:     "value",
+				ValueFile: "value.json",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: value is too large",
+			cmd: &commands.PutSubCmd{
+				KVSName: "kvs-name",
+				Key:     "key",
+				Value:   strings.Repeat("v", types.MaxValueSize+1),
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
 		{
 			name: "error: getKVSArn returns error",
 			cmd: &commands.PutSubCmd{
@@ -312,6 +424,7 @@ func Test_PutSubCmd_Run(t *testing.T) {
 			cfcMock := c.cfcMock(ctrl)
 			kvscMock := c.kvscMock(ctrl)
 			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
 				CloudFrontClient:              cfcMock,
 				CloudFrontKeyValueStoreClient: kvscMock,
 				OutputTarget:                  &bytes.Buffer{},
@@ -328,6 +441,487 @@ func Test_PutSubCmd_Run(t *testing.T) {
 	}
 }
 
+func Test_PutSubCmd_Run_condition(t *testing.T) {
+	cases := []struct {
+		name         string
+		cmd          *commands.PutSubCmd
+		ifETag       func(eTag string) string
+		race         bool
+		wantExitCode int
+		expectValue  string
+	}{
+		{
+			name:        "ok: if not exists",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "new", Value: "on", IfNotExists: true},
+			expectValue: "on",
+		},
+		{
+			name:         "precondition failed: if not exists",
+			cmd:          &commands.PutSubCmd{KVSName: "kvs-name", Key: "flag", Value: "on", IfNotExists: true},
+			wantExitCode: commands.ExitCodePreconditionFailed,
+			expectValue:  "off",
+		},
+		{
+			name:        "ok: if value",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "flag", Value: "on", IfValue: "off"},
+			expectValue: "on",
+		},
+		{
+			name:         "precondition failed: if value",
+			cmd:          &commands.PutSubCmd{KVSName: "kvs-name", Key: "flag", Value: "on", IfValue: "on"},
+			wantExitCode: commands.ExitCodePreconditionFailed,
+			expectValue:  "off",
+		},
+		{
+			name:         "precondition failed: another operator flips the flag after the check",
+			cmd:          &commands.PutSubCmd{KVSName: "kvs-name", Key: "flag", Value: "on", IfValue: "off"},
+			race:         true,
+			wantExitCode: commands.ExitCodePreconditionFailed,
+			expectValue:  "raced",
+		},
+		{
+			name:        "ok: if etag",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "flag", Value: "on"},
+			ifETag:      func(eTag string) string { return eTag },
+			expectValue: "on",
+		},
+		{
+			name:         "precondition failed: if etag",
+			cmd:          &commands.PutSubCmd{KVSName: "kvs-name", Key: "flag", Value: "on"},
+			ifETag:       func(eTag string) string { return "stale" },
+			wantExitCode: commands.ExitCodePreconditionFailed,
+			expectValue:  "off",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, []types.Item{{Key: "flag", Value: "off"}})
+
+			if c.ifETag != nil {
+				eTag, err := libs.GetKeyValueStoreETag(ctx, e.KeyValueStore(), kvsARN)
+				if err != nil {
+					tt.Fatal(err)
+				}
+				c.cmd.IfETag = c.ifETag(eTag)
+			}
+
+			kvsc := &racingKeyValueStore{KeyValueStore: e.KeyValueStore()}
+			if c.race {
+				kvsc.racePut = func() {
+					if _, err := libs.PutItem(ctx, e.KeyValueStore(), kvsARN, "flag", "raced"); err != nil {
+						tt.Fatal(err)
+					}
+				}
+			}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: kvsc,
+				Retries:                       3,
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantExitCode != 0 {
+				var exitErr *commands.ExitError
+				if asst.True(errors.As(err, &exitErr), err) {
+					asst.Equal(c.wantExitCode, exitErr.Code)
+				}
+			} else {
+				asst.NoError(err)
+			}
+
+			got, err := libs.GetItem(ctx, e.KeyValueStore(), kvsARN, c.cmd.Key)
+			asst.NoError(err)
+			asst.Equal(c.expectValue, aws.ToString(got.Value))
+		})
+	}
+}
+
+func Test_PutSubCmd_Run_valueFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	cert := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	certFile := writeFile("cert.pem", cert)
+	jsonFile := writeFile("value.json", "{\n  \"a\": 1,\n  \"b\": \"x y\"\n}\n")
+	largeFile := writeFile("large.txt", strings.Repeat("v", types.MaxValueSize+1))
+	largeJSONFile := writeFile("large.json", "{\"a\": \""+strings.Repeat(" ", types.MaxValueSize)+"\"}")
+	emptyFile := writeFile("empty.txt", "")
+
+	cases := []struct {
+		name        string
+		cmd         *commands.PutSubCmd
+		stdin       io.Reader
+		wantErr     bool
+		expectValue string
+	}{
+		{
+			name:        "ok: file",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "cert", ValueFile: certFile},
+			expectValue: cert,
+		},
+		{
+			name:        "ok: stdin",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "html", ValueFile: "-"},
+			stdin:       strings.NewReader("<p>\n  hello\n</p>"),
+			expectValue: "<p>\n  hello\n</p>",
+		},
+		{
+			name:        "ok: minify json",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "json", ValueFile: jsonFile, MinifyJSON: true},
+			expectValue: `{"a":1,"b":"x y"}`,
+		},
+		{
+			name:        "ok: minify inline json",
+			cmd:         &commands.PutSubCmd{KVSName: "kvs-name", Key: "json", Value: `{ "a": 1 }`, MinifyJSON: true},
+			expectValue: `{"a":1}`,
+		},
+		{
+			name:    "error: minify invalid json",
+			cmd:     &commands.PutSubCmd{KVSName: "kvs-name", Key: "cert", ValueFile: certFile, MinifyJSON: true},
+			wantErr: true,
+		},
+		{
+			name:    "error: file is too large",
+			cmd:     &commands.PutSubCmd{KVSName: "kvs-name", Key: "large", ValueFile: largeFile},
+			wantErr: true,
+		},
+		{
+			name:    "error: minified json is still too large",
+			cmd:     &commands.PutSubCmd{KVSName: "kvs-name", Key: "large", ValueFile: largeJSONFile, MinifyJSON: true},
+			wantErr: true,
+		},
+		{
+			name:    "error: file is empty",
+			cmd:     &commands.PutSubCmd{KVSName: "kvs-name", Key: "empty", ValueFile: emptyFile},
+			wantErr: true,
+		},
+		{
+			name:    "error: file not found",
+			cmd:     &commands.PutSubCmd{KVSName: "kvs-name", Key: "none", ValueFile: filepath.Join(dir, "none.txt")},
+			wantErr: true,
+		},
+		{
+			name:    "error: stdin is not available",
+			cmd:     &commands.PutSubCmd{KVSName: "kvs-name", Key: "html", ValueFile: "-"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, nil)
+
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				InputSource:                   c.stdin,
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantErr {
+				asst.Error(err)
+
+				list, err := libs.ListItems(ctx, e.KeyValueStore(), kvsARN)
+				asst.NoError(err)
+				asst.Empty(list.Data)
+				return
+			}
+
+			asst.NoError(err)
+			got, err := libs.GetItem(ctx, e.KeyValueStore(), kvsARN, c.cmd.Key)
+			asst.NoError(err)
+			asst.Equal(c.expectValue, aws.ToString(got.Value))
+		})
+	}
+}
+
+func Test_PutSubCmd_Run_fromFile(t *testing.T) {
+	cases := []struct {
+		name          string
+		cmd           *commands.PutSubCmd
+		wantError     bool
+		expectMessage string
+		expectItems   []types.Item
+	}{
+		{
+			name: "ok: only shows the diff",
+			cmd:  &commands.PutSubCmd{KVSName: "kvs-name", FromFile: "../../testdata/valid.json"},
+			expectItems: []types.Item{
+				{Key: "key-1", Value: "old"},
+				{Key: "other", Value: "keep"},
+			},
+		},
+		{
+			name: "ok: puts the items without changing the other keys",
+			cmd:  &commands.PutSubCmd{KVSName: "kvs-name", FromFile: "../../testdata/valid.json", Yes: true},
+			expectItems: []types.Item{
+				{Key: "key-1", Value: "v 1"},
+				{Key: "key-2", Value: "value-2"},
+				{Key: "key-4", Value: "v 4"},
+				{Key: "other", Value: "keep"},
+			},
+		},
+		{
+			name: "ok: csv",
+			cmd:  &commands.PutSubCmd{KVSName: "kvs-name", FromFile: "../../testdata/valid.csv", Format: "csv", Yes: true},
+			expectItems: []types.Item{
+				{Key: "key-1", Value: "v 1"},
+				{Key: "key-2", Value: "value-2"},
+				{Key: "key-4", Value: "v 4"},
+				{Key: "other", Value: "keep"},
+			},
+		},
+		{
+			name:          "error: from-file and key",
+			cmd:           &commands.PutSubCmd{KVSName: "kvs-name", FromFile: "../../testdata/valid.json", Key: "key", Value: "value"},
+			wantError:     true,
+			expectMessage: "cannot be specified with from-file",
+		},
+		{
+			name:          "error: from-file and condition",
+			cmd:           &commands.PutSubCmd{KVSName: "kvs-name", FromFile: "../../testdata/valid.json", IfNotExists: true},
+			wantError:     true,
+			expectMessage: "cannot be specified with from-file",
+		},
+		{
+			name:          "error: invalid file",
+			cmd:           &commands.PutSubCmd{KVSName: "kvs-name", FromFile: "../../testdata/invalid-1.json", Yes: true},
+			wantError:     true,
+			expectMessage: "invalid",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, []types.Item{{Key: "key-1", Value: "old"}, {Key: "other", Value: "keep"}})
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  out,
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.ErrorContains(err, c.expectMessage)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Contains(out.String(), "key-2")
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), kvsARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectItems, items.Data)
+		})
+	}
+}
+
+func Test_DeleteSubCmd_Run_keys(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(keysFile, []byte("key-2\n\n  key-3  \nnot-found\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		cmd           *commands.DeleteSubCmd
+		raceKey       string
+		wantError     bool
+		expectMessage string
+		expectKeys    []string
+	}{
+		{
+			name:       "ok: only shows the diff",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1", "key-2"}},
+			expectKeys: []string{"key-1", "key-2", "key-3", "key-4"},
+		},
+		{
+			name:       "ok: keys",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1", "key-2", "key-1", "not-found"}, Yes: true},
+			expectKeys: []string{"key-3", "key-4"},
+		},
+		{
+			name:       "ok: keys-file",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", KeysFile: keysFile, Yes: true},
+			expectKeys: []string{"key-1", "key-4"},
+		},
+		{
+			name:       "ok: keys and keys-file",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1"}, KeysFile: keysFile, Yes: true},
+			expectKeys: []string{"key-4"},
+		},
+		{
+			name:       "ok: retried after a write to another key",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1"}, Yes: true},
+			raceKey:    "other",
+			expectKeys: []string{"key-2", "key-3", "key-4", "other"},
+		},
+		{
+			name:       "ok: filter",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", KeyFilterFlags: commands.KeyFilterFlags{Regex: "key-[12]"}, Yes: true},
+			expectKeys: []string{"key-3", "key-4"},
+		},
+		{
+			name:       "ok: keys and filter",
+			cmd:        &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1", "key-3"}, KeyFilterFlags: commands.KeyFilterFlags{Glob: "*-[12]"}, Yes: true},
+			expectKeys: []string{"key-2", "key-3", "key-4"},
+		},
+		{
+			name:          "error: invalid filter",
+			cmd:           &commands.DeleteSubCmd{KVSName: "kvs-name", KeyFilterFlags: commands.KeyFilterFlags{Regex: "key-("}, Yes: true},
+			wantError:     true,
+			expectMessage: "invalid regex",
+		},
+		{
+			name:          "error: the diff has been changed",
+			cmd:           &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1"}, Yes: true},
+			raceKey:       "key-1",
+			wantError:     true,
+			expectMessage: "run item delete again to review the new diff",
+		},
+		{
+			name:          "error: keys and key",
+			cmd:           &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1"}, Key: "key-2"},
+			wantError:     true,
+			expectMessage: "key cannot be specified with keys",
+		},
+		{
+			name:          "error: keys and condition",
+			cmd:           &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1"}, IfValue: "v"},
+			wantError:     true,
+			expectMessage: "cannot be specified with keys",
+		},
+		{
+			name:          "error: empty key",
+			cmd:           &commands.DeleteSubCmd{KVSName: "kvs-name", Keys: []string{"key-1", ""}},
+			wantError:     true,
+			expectMessage: "empty key",
+		},
+		{
+			name:          "error: keys-file not found",
+			cmd:           &commands.DeleteSubCmd{KVSName: "kvs-name", KeysFile: "not-found.txt"},
+			wantError:     true,
+			expectMessage: "file not found",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, []types.Item{{Key: "key-1", Value: "v"}, {Key: "key-2", Value: "v"}, {Key: "key-3", Value: "v"}, {Key: "key-4", Value: "v"}})
+
+			kvsc := &racingKeyValueStore{KeyValueStore: e.KeyValueStore()}
+			if c.raceKey != "" {
+				kvsc.race = func() {
+					if _, err := libs.PutItem(ctx, e.KeyValueStore(), kvsARN, c.raceKey, "raced"); err != nil {
+						tt.Fatal(err)
+					}
+				}
+			}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: kvsc,
+				Retries:                       3,
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.ErrorContains(err, c.expectMessage)
+				return
+			}
+
+			asst.NoError(err)
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), kvsARN)
+			asst.NoError(err)
+			keys := []string{}
+			for _, item := range items.Data {
+				keys = append(keys, item.Key)
+			}
+			asst.ElementsMatch(c.expectKeys, keys)
+		})
+	}
+}
+
+func Test_DeleteSubCmd_Run_condition(t *testing.T) {
+	cases := []struct {
+		name         string
+		cmd          *commands.DeleteSubCmd
+		wantExitCode int
+		expectExists bool
+	}{
+		{
+			name: "ok: if value",
+			cmd:  &commands.DeleteSubCmd{KVSName: "kvs-name", Key: "flag", IfValue: "off"},
+		},
+		{
+			name:         "precondition failed: if value",
+			cmd:          &commands.DeleteSubCmd{KVSName: "kvs-name", Key: "flag", IfValue: "on"},
+			wantExitCode: commands.ExitCodePreconditionFailed,
+			expectExists: true,
+		},
+		{
+			name:         "precondition failed: if etag",
+			cmd:          &commands.DeleteSubCmd{KVSName: "kvs-name", Key: "flag", IfETag: "stale"},
+			wantExitCode: commands.ExitCodePreconditionFailed,
+			expectExists: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, []types.Item{{Key: "flag", Value: "off"}})
+
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantExitCode != 0 {
+				var exitErr *commands.ExitError
+				if asst.True(errors.As(err, &exitErr), err) {
+					asst.Equal(c.wantExitCode, exitErr.Code)
+				}
+			} else {
+				asst.NoError(err)
+			}
+
+			_, err = libs.GetItem(ctx, e.KeyValueStore(), kvsARN, "flag")
+			asst.Equal(c.expectExists, err == nil)
+		})
+	}
+}
+
 func Test_DeleteSubCmd_Run(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -430,6 +1024,7 @@ func Test_DeleteSubCmd_Run(t *testing.T) {
 			cfcMock := c.cfcMock(ctrl)
 			kvscMock := c.kvscMock(ctrl)
 			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
 				CloudFrontClient:              cfcMock,
 				CloudFrontKeyValueStoreClient: kvscMock,
 				OutputTarget:                  &bytes.Buffer{},
@@ -3,18 +3,32 @@ package commands
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	"github.com/michimani/cfkvs/internal/output"
 	"github.com/michimani/cfkvs/libs"
 	"github.com/michimani/cfkvs/types"
 )
 
 type KVSCmd struct {
-	List   ListKVSSubCmd   `cmd:"" help:"List key value stores in your account."`
-	Create CreateSubCmd    `cmd:"" help:"Create a key value store."`
-	Delete DeleteKVSSubCmd `cmd:"" help:"Delete a key value store."`
-	Info   InfoSubCmd      `cmd:"" help:"Show information of the key value store."`
-	Sync   SyncSubCmd      `cmd:"" help:"Sync items in the key value store with S3 object or specified JSON file."`
+	List         ListKVSSubCmd      `cmd:"" help:"List key value stores in your account."`
+	Create       CreateSubCmd       `cmd:"" help:"Create a key value store."`
+	Delete       DeleteKVSSubCmd    `cmd:"" help:"Delete a key value store. Fails while functions are associated with it."`
+	Info         InfoSubCmd         `cmd:"" help:"Show information of the key value store."`
+	Associations AssociationsSubCmd `cmd:"" help:"List the CloudFront Functions associated with the key value store."`
+	Update       UpdateSubCmd       `cmd:"" help:"Update the comment of the key value store."`
+	Sync         SyncSubCmd         `cmd:"" help:"Sync items in the key value store with S3 object or specified JSON file."`
+	Export       ExportSubCmd       `cmd:"" help:"Export items in the key value store to S3 object or JSON file."`
+	Copy         CopySubCmd         `cmd:"" help:"Copy items in the key value store to another one, across regions and accounts."`
+	Plan         PlanSubCmd         `cmd:"" help:"Save the diff of sync to a plan file, to apply it later with apply command."`
+	Apply        ApplySubCmd        `cmd:"" help:"Apply the plan file, if the key value store has not been changed since the plan was created."`
+	Snapshot     SnapshotSubCmd     `cmd:"" help:"Save a snapshot of the items in the key value store to a local directory or S3."`
+	Snapshots    SnapshotsCmd       `cmd:"" help:"Manage the snapshots of the key value store."`
+	Restore      RestoreSubCmd      `cmd:"" help:"Restore the items in the key value store to a snapshot."`
+	Wait         WaitSubCmd         `cmd:"" help:"Wait until the key value store has the status."`
 }
 
 type ListKVSSubCmd struct{}
@@ -24,23 +38,72 @@ type CreateSubCmd struct {
 	Comment   string `name:"comment" help:"Comment of the key value store."`
 	Bucket    string `name:"bucket" help:"S3 bucket name to import key value store, if you want."`
 	ObjectKey string `name:"object-key" help:"S3 object key to import key value store, if you want."`
+
+	Wait        bool          `name:"wait" help:"Wait until the key value store is READY. Fails if the import from S3 fails."`
+	WaitTimeout time.Duration `name:"wait-timeout" help:"Maximum time to wait with --wait." default:"10m"`
 }
 
 type DeleteKVSSubCmd struct {
-	Name string `name:"name" help:"Name of the key value store." required:""`
+	Name string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
 }
 
 type InfoSubCmd struct {
-	Name string `name:"name" help:"Name of the key value store." required:""`
+	Name string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+}
+
+type AssociationsSubCmd struct {
+	Name string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+}
+
+type UpdateSubCmd struct {
+	Name    string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+	Comment string `name:"comment" help:"New comment of the key value store. An empty string clears the comment." required:""`
 }
 
 type SyncSubCmd struct {
-	Name      string `name:"name" help:"Name of the key value store." required:""`
+	Name      string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
 	Bucket    string `name:"bucket" help:"S3 bucket name to sync key value store. If you want to sync with S3 object, this is required."`
 	ObjectKey string `name:"object-key" help:"S3 object key to sync key value store. If you want to sync with S3 object, this is required."`
 	File      string `name:"file" help:"Path to the file to sync key value store. If this is specified, sync with this file instead of S3 object."`
-	Delete    bool   `name:"delete" help:"Delete items that are not in the S3 object."`
+	Format    string `name:"format" help:"Format of the file or the S3 object. One of: auto, json, csv, tsv, yaml, ndjson, dotenv. auto detects it from the extension." enum:"auto,json,csv,tsv,yaml,ndjson,dotenv" default:"auto"`
+	Delete    bool   `name:"delete" help:"Delete items that are not in the S3 object. With a filter, only the items whose keys match it are deleted."`
 	Yes       bool   `name:"yes" short:"y" help:"Execute sync. If not specified, only show the items to be synced."`
+
+	DetailedExitCode bool `name:"detailed-exitcode" help:"Exit with 0 if there is no change, 2 if there are changes to sync, or 1 on error. Only for a dry run without --yes."`
+
+	Snapshot           bool `name:"snapshot" help:"Save a snapshot of the key value store before applying the changes, to restore it with restore command. Only with --yes."`
+	SnapshotStoreFlags `embed:"" prefix:"snapshot-"`
+
+	KeyFilterFlags `embed:""`
+}
+
+type PlanSubCmd struct {
+	Name      string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+	Bucket    string `name:"bucket" help:"S3 bucket name to sync key value store. If you want to sync with S3 object, this is required."`
+	ObjectKey string `name:"object-key" help:"S3 object key to sync key value store. If you want to sync with S3 object, this is required."`
+	File      string `name:"file" help:"Path to the file to sync key value store. If this is specified, sync with this file instead of S3 object."`
+	Format    string `name:"format" help:"Format of the file or the S3 object. One of: auto, json, csv, tsv, yaml, ndjson, dotenv. auto detects it from the extension." enum:"auto,json,csv,tsv,yaml,ndjson,dotenv" default:"auto"`
+	Delete    bool   `name:"delete" help:"Delete items that are not in the S3 object. With a filter, only the items whose keys match it are deleted."`
+	Out       string `name:"out" help:"Path to the plan file to write." required:""`
+
+	KeyFilterFlags `embed:""`
+}
+
+type ApplySubCmd struct {
+	Plan string `arg:"" name:"plan" help:"Path to the plan file created by plan command."`
+}
+
+type WaitSubCmd struct {
+	Name    string        `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+	Status  string        `name:"status" help:"Status to wait for. One of: READY, PROVISIONING, FAILED." enum:"READY,PROVISIONING,FAILED" default:"READY"`
+	Timeout time.Duration `name:"timeout" help:"Maximum time to wait." default:"10m"`
+}
+
+type ExportSubCmd struct {
+	Name      string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+	Bucket    string `name:"bucket" help:"S3 bucket name to export key value store. If you want to export to S3 object, this is required."`
+	ObjectKey string `name:"object-key" help:"S3 object key to export key value store. If you want to export to S3 object, this is required."`
+	File      string `name:"file" help:"Path to the file to export key value store. If this is specified, export to this file instead of S3 object."`
 }
 
 func (c *ListKVSSubCmd) Run(globals *Globals) error {
@@ -54,7 +117,7 @@ func (c *ListKVSSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	if err := output.Render(&kvsList, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(&kvsList); err != nil {
 		return err
 	}
 
@@ -62,29 +125,39 @@ func (c *ListKVSSubCmd) Run(globals *Globals) error {
 }
 
 func (c *CreateSubCmd) Run(globals *Globals) error {
-	srcS3 := new(libs.KVSImportSourceS3)
+	var src libs.KVSImportSource
 	if c.Bucket != "" {
 		if c.ObjectKey == "" {
 			return errors.New("object-key is required when bucket is specified")
 		}
 
-		srcS3 = &libs.KVSImportSourceS3{
+		src = &libs.KVSImportSourceS3{
 			Bucket: c.Bucket,
 			Key:    c.ObjectKey,
 		}
 	}
 
-	out, err := libs.CreateKeyValueStore(context.TODO(), globals.CloudFrontClient, c.Name, c.Comment, srcS3)
+	ctx := context.TODO()
+	out, err := libs.CreateKeyValueStore(ctx, globals.CloudFrontClient, c.Name, c.Comment, src)
 	if err != nil {
 		return err
 	}
 
+	var parsed any = out
+	if c.Wait {
+		waited, err := waitKeyValueStore(ctx, globals, c.Name, "READY", c.WaitTimeout)
+		if err != nil {
+			return err
+		}
+		parsed = waited
+	}
+
 	kvs := types.KVS{}
-	if err := kvs.Parse(out); err != nil {
+	if err := kvs.Parse(parsed); err != nil {
 		return err
 	}
 
-	if err := output.Render(&kvs, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(&kvs); err != nil {
 		return err
 	}
 
@@ -92,16 +165,74 @@ func (c *CreateSubCmd) Run(globals *Globals) error {
 }
 
 func (c *InfoSubCmd) Run(globals *Globals) error {
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
 	info, err := libs.DescribeKeyValueStore(
-		context.TODO(),
+		ctx,
 		globals.CloudFrontClient,
 		globals.CloudFrontKeyValueStoreClient,
-		c.Name)
+		name)
 	if err != nil {
 		return err
 	}
 
-	if err := output.Render(info, globals.Output, globals.OutputTarget); err != nil {
+	if err := globals.render(info); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *AssociationsSubCmd) Run(globals *Globals) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	ctx := context.TODO()
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	functions, err := libs.ListFunctionAssociations(ctx, globals.CloudFrontClient, kvsARN)
+	if err != nil {
+		return err
+	}
+
+	list := types.FunctionAssociationList{}
+	if err := list.Parse(functions); err != nil {
+		return err
+	}
+
+	if err := globals.render(&list); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *UpdateSubCmd) Run(globals *Globals) error {
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	out, err := libs.UpdateKeyValueStore(ctx, globals.CloudFrontClient, name, c.Comment)
+	if err != nil {
+		return err
+	}
+
+	kvs := types.KVS{}
+	if err := kvs.Parse(out); err != nil {
+		return err
+	}
+
+	if err := globals.render(&kvs); err != nil {
 		return err
 	}
 
@@ -113,7 +244,13 @@ func (c *DeleteKVSSubCmd) Run(globals *Globals) error {
 		return errors.New("name is required")
 	}
 
-	if err := libs.DeleteKeyValueStore(context.TODO(), globals.CloudFrontClient, c.Name); err != nil {
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := libs.DeleteKeyValueStore(ctx, globals.CloudFrontClient, name); err != nil {
 		return err
 	}
 
@@ -126,58 +263,245 @@ func (c *SyncSubCmd) Run(globals *Globals) error {
 	if c.Name == "" {
 		return errors.New("kvs-name is required")
 	}
-
-	fromFile := false
-	if c.File != "" {
-		fromFile = true
-	} else {
-		if c.Bucket == "" {
-			return errors.New("bucket is required")
-		}
-		if c.ObjectKey == "" {
-			return errors.New("object-key is required")
-		}
+	if c.DetailedExitCode && c.Yes {
+		return errors.New("detailed-exitcode cannot be specified with yes")
+	}
+	if c.Snapshot && !c.Yes {
+		return errors.New("snapshot can only be specified with yes")
+	}
+	if err := validateSyncSource(c.Bucket, c.ObjectKey, c.File); err != nil {
+		return err
+	}
+	format, err := types.ParseDataFormat(c.Format)
+	if err != nil {
+		return err
+	}
+	filter, err := c.keyFilter()
+	if err != nil {
+		return err
 	}
 
 	ctx := context.TODO()
+	after, err := loadSyncSource(ctx, globals, c.Bucket, c.ObjectKey, c.File, format)
+	if err != nil {
+		return err
+	}
+	after = filterSyncSource(globals, after, filter)
+
 	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.Name)
 	if err != nil {
 		return err
 	}
 
-	// get before items
-	before, err := libs.ListItems(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN)
+	diff, eTag, err := syncDiff(ctx, globals, kvsARN, after, c.Delete, filter)
 	if err != nil {
 		return err
 	}
 
-	// get after items
-	afterItems := &types.KeyValueStoreData{}
-	if fromFile {
-		// from the file
-		if afterItems, err = libs.GetKeyValueStoreDataFromFile(c.File); err != nil {
+	// show diff
+	if err := renderDiff(globals, diff); err != nil {
+		return err
+	}
+
+	if !c.Yes {
+		if c.DetailedExitCode && !diff.IsEmpty() {
+			return &ExitError{Code: ExitCodeChangesPending}
+		}
+		return nil
+	}
+
+	if c.Snapshot && !diff.IsEmpty() {
+		name, err := getKVSName(ctx, globals.CloudFrontClient, kvsARN)
+		if err != nil {
 			return err
 		}
-	} else {
-		// from S3
-		if afterItems, err = libs.GetKeyValueStoreData(ctx, globals.S3Client, c.Bucket, c.ObjectKey); err != nil {
+		snapshot, location, err := saveSnapshot(ctx, globals, c.snapshotStore(globals), name)
+		if err != nil {
 			return err
 		}
+		globals.logf("Saved the snapshot %s to %s\n", snapshot.ID, location)
 	}
 
-	// show diff
-	diff := before.Diff(afterItems.ToItemList(), c.Delete)
-	if err := output.Render(diff, output.OutputTypeTable, globals.OutputTarget); err != nil {
+	return applyDiffWithConflictRetry(ctx, globals, kvsARN, diff, eTag, "sync", func() (*types.ItemListDiff, string, error) {
+		return syncDiff(ctx, globals, kvsARN, after, c.Delete, filter)
+	})
+}
+
+func (c *PlanSubCmd) Run(globals *Globals) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := validateSyncSource(c.Bucket, c.ObjectKey, c.File); err != nil {
+		return err
+	}
+	format, err := types.ParseDataFormat(c.Format)
+	if err != nil {
+		return err
+	}
+	filter, err := c.keyFilter()
+	if err != nil {
 		return err
 	}
 
-	if !c.Yes {
+	ctx := context.TODO()
+	after, err := loadSyncSource(ctx, globals, c.Bucket, c.ObjectKey, c.File, format)
+	if err != nil {
+		return err
+	}
+	after = filterSyncSource(globals, after, filter)
+
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	// The ETag is got before listing items, so that any change after it makes the plan stale.
+	diff, eTag, err := syncDiff(ctx, globals, kvsARN, after, c.Delete, filter)
+	if err != nil {
+		return err
+	}
+
+	plan := &types.Plan{
+		Version:   types.PlanVersion,
+		KVSARN:    kvsARN,
+		ETag:      eTag,
+		CreatedAt: time.Now().UTC(),
+		Diff:      diff,
+	}
+	if err := libs.PutPlanToFile(c.Out, plan); err != nil {
+		return err
+	}
+
+	if err := renderDiff(globals, diff); err != nil {
+		return err
+	}
+
+	if globals.Output.IsStructured() {
+		// keep the output a diff that tools can parse
+		globals.logf("Saved the plan to %s\n", c.Out)
+	} else {
+		_, _ = fmt.Fprintf(globals.OutputTarget, "Saved the plan to %s\n", c.Out)
+	}
+
+	return nil
+}
+
+func (c *ApplySubCmd) Run(globals *Globals) error {
+	plan, err := libs.GetPlanFromFile(c.Plan)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	eTag, err := libs.GetKeyValueStoreETag(ctx, globals.CloudFrontKeyValueStoreClient, plan.KVSARN)
+	if err != nil {
+		return err
+	}
+	if eTag != plan.ETag {
+		return fmt.Errorf("the key value store has been changed since the plan was created (ETag in the plan: %s, current ETag: %s)\nrun plan again", plan.ETag, eTag)
+	}
+
+	if err := renderDiff(globals, plan.Diff); err != nil {
+		return err
+	}
+
+	return applySyncDiff(ctx, globals, plan.KVSARN, plan.Diff, aws.String(plan.ETag), "run plan and apply again to apply the remaining changes")
+}
+
+// validateSyncSource validates the flags of the source of items to sync.
+// The file takes precedence over the S3 object.
+func validateSyncSource(bucket, objectKey, file string) error {
+	if file != "" {
 		return nil
 	}
+	if bucket == "" {
+		return errors.New("bucket is required")
+	}
+	if objectKey == "" {
+		return errors.New("object-key is required")
+	}
+	return nil
+}
 
-	// sync
-	out, err := libs.SyncItems(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN, diff.PutList(), diff.DeleteList())
+// loadSyncSource reads and validates items in the file or the S3 object to sync.
+func loadSyncSource(ctx context.Context, globals *Globals, bucket, objectKey, file string, format types.DataFormat) (*types.ItemList, error) {
+	if file != "" {
+		data, err := libs.GetKeyValueStoreDataFromFile(file, format)
+		if err != nil {
+			return nil, err
+		}
+		return data.ToItemList(), nil
+	}
+
+	data, err := libs.GetKeyValueStoreData(ctx, globals.S3Client, bucket, objectKey, format)
 	if err != nil {
+		return nil, err
+	}
+	return data.ToItemList(), nil
+}
+
+// syncDiff returns the diff between items in the key value store and the items to sync,
+// and the ETag of the key value store that the diff is computed against.
+// If filter is not nil, only the items in the key value store whose keys match it are compared,
+// so that items out of the filter are never deleted.
+func syncDiff(ctx context.Context, globals *Globals, kvsARN string, after *types.ItemList, delete bool, filter *types.KeyFilter) (*types.ItemListDiff, string, error) {
+	before, eTag, err := listItemsWithETag(ctx, globals, kvsARN)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return before.Filter(filter).Diff(after, delete), eTag, nil
+}
+
+// listItemsWithETag lists items in the key value store, with the ETag got before listing them.
+// Any write after the ETag is got makes a write with it as IfMatch fail as a conflict,
+// so that a diff from the items is never applied over changes that it does not include.
+func listItemsWithETag(ctx context.Context, globals *Globals, kvsARN string) (*types.ItemList, string, error) {
+	eTag, err := libs.GetKeyValueStoreETag(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items, err := libs.ListItems(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return items, eTag, nil
+}
+
+// renderDiff renders the diff in the output format.
+// JSON and YAML render it as a DiffDocument, that has a stable schema for tools,
+// and the query is applied to the DiffDocument too.
+func renderDiff(globals *Globals, diff *types.ItemListDiff) error {
+	if globals.Query != "" {
+		return globals.render(diff.Document())
+	}
+
+	switch globals.Output {
+	case output.OutputTypeJson, output.OutputTypeYaml:
+		return globals.render(diff.Document())
+	default:
+		return globals.render(diff)
+	}
+}
+
+// applySyncDiff applies the diff to the key value store and renders the result.
+// For structured output or a query, the result is logged instead, so that the output is only the diff that tools can parse.
+// ifMatch pins the ETag that the diff is applied to, if it is not nil.
+// hint is shown when some batches have been applied before the failure.
+func applySyncDiff(ctx context.Context, globals *Globals, kvsARN string, diff *types.ItemListDiff, ifMatch *string, hint string) error {
+	out, err := libs.SyncItems(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN, diff.PutList(), diff.DeleteList(), func(o *libs.SyncItemsOptions) {
+		o.IfMatch = ifMatch
+		o.OnBatch = func(p libs.SyncProgress) {
+			globals.logf("[%d/%d] applied %d puts and %d deletes\n", p.Batch, p.Total, p.Puts, p.Deletes)
+		}
+	})
+	if err != nil {
+		var batchErr *libs.SyncBatchError
+		if errors.As(err, &batchErr) && batchErr.Batch > 1 {
+			return fmt.Errorf("%w\n%s", err, hint)
+		}
 		return err
 	}
 
@@ -186,5 +510,118 @@ func (c *SyncSubCmd) Run(globals *Globals) error {
 		return err
 	}
 
-	return output.Render(&kvsSimple, globals.Output, globals.OutputTarget)
+	if globals.Output.IsStructured() || globals.Query != "" {
+		globals.logf("Applied: %d items, %d bytes in total\n", kvsSimple.ItemCount, kvsSimple.TotalSize)
+		return nil
+	}
+
+	return globals.render(&kvsSimple)
+}
+
+// applyDiffWithConflictRetry applies the diff that has been shown to the user, to the ETag that it was computed against.
+// On a conflict with another write, the diff is recomputed against the fresh items by recompute,
+// and applied only if it has no change other than the ones shown.
+// command is the name of the command to run again, used in error messages.
+func applyDiffWithConflictRetry(ctx context.Context, globals *Globals, kvsARN string, diff *types.ItemListDiff, eTag string, command string, recompute func() (*types.ItemListDiff, string, error)) error {
+	approved := diff
+	for retry := 1; ; retry++ {
+		err := applySyncDiff(ctx, globals, kvsARN, diff, aws.String(eTag), fmt.Sprintf("run %s again to apply the remaining changes", command))
+		if err == nil || !libs.IsConflictError(err) || retry > globals.Retries {
+			return err
+		}
+		globals.logf("conflicted with another write, recomputing the diff (%d/%d): %v\n", retry, globals.Retries, err)
+
+		if diff, eTag, err = recompute(); err != nil {
+			return err
+		}
+		if !diff.IsSubsetOf(approved) {
+			return fmt.Errorf("the key value store has been changed by another write, and the diff is different from the one shown\nrun %s again to review the new diff", command)
+		}
+	}
+}
+
+func (c *WaitSubCmd) Run(globals *Globals) error {
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	out, err := waitKeyValueStore(ctx, globals, name, c.Status, c.Timeout)
+	if err != nil {
+		return err
+	}
+
+	kvs := types.KVS{}
+	if err := kvs.Parse(out); err != nil {
+		return err
+	}
+
+	return globals.render(&kvs)
+}
+
+// waitKeyValueStore waits until the key value store has the status, and logs each status change.
+func waitKeyValueStore(ctx context.Context, globals *Globals, name, status string, timeout time.Duration) (*cloudfront.DescribeKeyValueStoreOutput, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	last := ""
+	return libs.WaitKeyValueStore(ctx, globals.CloudFrontClient, globals.CloudFrontKeyValueStoreClient, name, status, func(o *libs.WaitKeyValueStoreOptions) {
+		o.OnPoll = func(current string) {
+			if current != last {
+				globals.logf("%s: %s\n", name, current)
+				last = current
+			}
+		}
+	})
+}
+
+func (c *ExportSubCmd) Run(globals *Globals) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	toFile := false
+	if c.File != "" {
+		toFile = true
+	} else {
+		if c.Bucket == "" {
+			return errors.New("bucket is required")
+		}
+		if c.ObjectKey == "" {
+			return errors.New("object-key is required")
+		}
+	}
+
+	ctx := context.TODO()
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	itemList, err := libs.ListItems(ctx, globals.CloudFrontKeyValueStoreClient, kvsARN)
+	if err != nil {
+		return err
+	}
+	data := itemList.ToKeyValueStoreData()
+
+	dest := ""
+	if toFile {
+		if err := libs.PutKeyValueStoreDataToFile(c.File, data); err != nil {
+			return err
+		}
+		dest = c.File
+	} else {
+		if err := libs.PutKeyValueStoreData(ctx, globals.S3Client, c.Bucket, c.ObjectKey, data); err != nil {
+			return err
+		}
+		dest = fmt.Sprintf("s3://%s/%s", c.Bucket, c.ObjectKey)
+	}
+
+	_, _ = fmt.Fprintf(globals.OutputTarget, "Exported %d items to %s\n", len(*data.Data), dest)
+
+	return nil
 }
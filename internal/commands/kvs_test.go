@@ -2,18 +2,30 @@ package commands_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	cf "github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
 	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
 	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/michimani/cfkvs/emulator"
 	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
 	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
 )
 
 func Test_ListKVSSubCmd_Run(t *testing.T) {
@@ -66,6 +78,7 @@ func Test_ListKVSSubCmd_Run(t *testing.T) {
 			ctrl := gomock.NewController(tt)
 			cfcMock := c.cfcMock(ctrl)
 			globals := &commands.Globals{
+				Output:           output.OutputTypeTable,
 				CloudFrontClient: cfcMock,
 				OutputTarget:     &bytes.Buffer{},
 			}
@@ -169,6 +182,7 @@ func Test_CreateSubCmd_Ruh(t *testing.T) {
 			ctrl := gomock.NewController(tt)
 			cfcMock := c.cfcMock(ctrl)
 			globals := &commands.Globals{
+				Output:           output.OutputTypeTable,
 				CloudFrontClient: cfcMock,
 				OutputTarget:     &bytes.Buffer{},
 			}
@@ -184,6 +198,98 @@ func Test_CreateSubCmd_Ruh(t *testing.T) {
 	}
 }
 
+func Test_CreateSubCmd_Run_wait(t *testing.T) {
+	cases := []struct {
+		name          string
+		cmd           *commands.CreateSubCmd
+		expectStatus  string
+		wantError     bool
+		expectMessage string
+	}{
+		{
+			name:         "ok",
+			cmd:          &commands.CreateSubCmd{Name: "kvs-name", Wait: true},
+			expectStatus: "READY",
+		},
+		{
+			name:          "error: import failed",
+			cmd:           &commands.CreateSubCmd{Name: "kvs-name", Bucket: "bucket", ObjectKey: "object-key", Wait: true},
+			wantError:     true,
+			expectMessage: "importing from arn:aws:s3:::bucket/object-key is not supported by the emulator",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e := emulator.New()
+			buf := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        "json",
+				OutputTarget:                  buf,
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.ErrorContains(err, c.expectMessage)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Contains(buf.String(), fmt.Sprintf(`"status": "%s"`, c.expectStatus))
+		})
+	}
+}
+
+func Test_WaitSubCmd_Run(t *testing.T) {
+	cases := []struct {
+		name      string
+		cmd       *commands.WaitSubCmd
+		wantError bool
+	}{
+		{
+			name: "ok",
+			cmd:  &commands.WaitSubCmd{Name: "kvs-name", Status: "READY"},
+		},
+		{
+			name:      "error: not found",
+			cmd:       &commands.WaitSubCmd{Name: "not-found", Status: "READY"},
+			wantError: true,
+		},
+		{
+			name:      "error: timeout",
+			cmd:       &commands.WaitSubCmd{Name: "kvs-name", Status: "PROVISIONING", Timeout: 10 * time.Millisecond},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, _ := newTestKVS(tt, nil)
+
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
+
 func Test_InfoSubCmd_Run(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -219,6 +325,55 @@ func Test_InfoSubCmd_Run(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "ok: ID",
+			cmd:  &commands.InfoSubCmd{Name: "a1b2c3d4-5678-90ab-cdef-000000000001"},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				m := libs.NewMockCloudFrontClient(ctrl)
+				m.EXPECT().ListKeyValueStores(gomock.Any(), gomock.Any()).Return(
+					&cf.ListKeyValueStoresOutput{
+						KeyValueStoreList: &cfTypes.KeyValueStoreList{
+							Items: []cfTypes.KeyValueStore{
+								{
+									Id:   aws.String("a1b2c3d4-5678-90ab-cdef-000000000001"),
+									Name: aws.String("name"),
+								},
+							},
+						},
+					}, nil)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), &cf.DescribeKeyValueStoreInput{Name: aws.String("name")}).Return(
+					&cf.DescribeKeyValueStoreOutput{
+						KeyValueStore: &cfTypes.KeyValueStore{
+							Id:      aws.String("a1b2c3d4-5678-90ab-cdef-000000000001"),
+							Name:    aws.String("name"),
+							Comment: aws.String("comment"),
+							Status:  aws.String("status"),
+							ARN:     aws.String("arn"),
+						},
+					}, nil)
+				return m
+			},
+			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(
+					&kvs.DescribeKeyValueStoreOutput{
+						KvsARN: aws.String("kvs-arn"),
+					}, nil)
+				return m
+			},
+			wantError: false,
+		},
+		{
+			name: "error: failed to resolve ID",
+			cmd:  &commands.InfoSubCmd{Name: "a1b2c3d4-5678-90ab-cdef-000000000001"},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				m := libs.NewMockCloudFrontClient(ctrl)
+				m.EXPECT().ListKeyValueStores(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+				return m
+			},
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
 		{
 			name: "error: cloudfront.DescribeKeyValueStore returns error",
 			cmd:  &commands.InfoSubCmd{Name: "name"},
@@ -302,6 +457,7 @@ func Test_InfoSubCmd_Run(t *testing.T) {
 			cfcMock := c.cfcMock(ctrl)
 			kvscMock := c.kvscMock(ctrl)
 			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
 				CloudFrontClient:              cfcMock,
 				CloudFrontKeyValueStoreClient: kvscMock,
 				OutputTarget:                  &bytes.Buffer{},
@@ -318,6 +474,173 @@ func Test_InfoSubCmd_Run(t *testing.T) {
 	}
 }
 
+func Test_AssociationsSubCmd_Run(t *testing.T) {
+	cases := []struct {
+		name      string
+		kvsName   string
+		expect    types.FunctionAssociationList
+		wantError bool
+	}{
+		{
+			name:    "ok",
+			kvsName: "kvs-1",
+			expect: types.FunctionAssociationList{
+				{Name: "fn-1", Stage: "DEVELOPMENT", Status: emulator.FunctionStatusUnassociated, ARN: "arn:aws:cloudfront::123456789012:function/fn-1"},
+				{Name: "fn-1", Stage: "LIVE", Status: emulator.FunctionStatusUnassociated, ARN: "arn:aws:cloudfront::123456789012:function/fn-1"},
+			},
+		},
+		{
+			name:    "ok: no association",
+			kvsName: "kvs-2",
+			expect:  types.FunctionAssociationList{},
+		},
+		{
+			name:      "error: not found",
+			kvsName:   "not-found",
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e := emulator.New()
+			kvsARN := createTestKVS(tt, e, "kvs-1", "", nil)
+			createTestKVS(tt, e, "kvs-2", "", nil)
+			fn, err := e.CloudFront().CreateFunction(ctx, &cf.CreateFunctionInput{
+				Name: aws.String("fn-1"),
+				FunctionConfig: &cfTypes.FunctionConfig{
+					Runtime: cfTypes.FunctionRuntimeCloudfrontJs20,
+					KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{
+						Quantity: aws.Int32(1),
+						Items:    []cfTypes.KeyValueStoreAssociation{{KeyValueStoreARN: aws.String(kvsARN)}},
+					},
+				},
+			})
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if _, err := e.CloudFront().PublishFunction(ctx, &cf.PublishFunctionInput{Name: aws.String("fn-1"), IfMatch: fn.ETag}); err != nil {
+				tt.Fatal(err)
+			}
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient: e.CloudFront(),
+				Output:           output.OutputTypeJson,
+				OutputTarget:     out,
+			}
+
+			cmd := &commands.AssociationsSubCmd{Name: c.kvsName}
+			err = cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			got := types.FunctionAssociationList{}
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				tt.Fatal(err)
+			}
+			asst.Equal(c.expect, got)
+		})
+	}
+}
+
+func Test_DeleteKVSSubCmd_Run_associated(t *testing.T) {
+	asst := assert.New(t)
+
+	ctx := context.Background()
+	e := emulator.New()
+	kvsARN := createTestKVS(t, e, "kvs-1", "", nil)
+	if _, err := e.CloudFront().CreateFunction(ctx, &cf.CreateFunctionInput{
+		Name: aws.String("fn-1"),
+		FunctionConfig: &cfTypes.FunctionConfig{
+			Runtime: cfTypes.FunctionRuntimeCloudfrontJs20,
+			KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{
+				Quantity: aws.Int32(1),
+				Items:    []cfTypes.KeyValueStoreAssociation{{KeyValueStoreARN: aws.String(kvsARN)}},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	globals := &commands.Globals{
+		Output:           output.OutputTypeTable,
+		CloudFrontClient: e.CloudFront(),
+		OutputTarget:     out,
+	}
+
+	err := (&commands.DeleteKVSSubCmd{Name: "kvs-1"}).Run(globals)
+	var inUse *libs.KeyValueStoreInUseError
+	if asst.ErrorAs(err, &inUse) {
+		asst.Contains(err.Error(), "fn-1 (DEVELOPMENT)")
+	}
+	asst.Empty(out.String())
+
+	_, err = libs.GetKeyValueStoreArn(ctx, e.CloudFront(), "kvs-1")
+	asst.NoError(err)
+}
+
+func Test_UpdateSubCmd_Run(t *testing.T) {
+	cases := []struct {
+		name          string
+		cmd           *commands.UpdateSubCmd
+		wantError     bool
+		expectComment string
+	}{
+		{
+			name:          "ok",
+			cmd:           &commands.UpdateSubCmd{Name: "kvs-name", Comment: "owner: team-a"},
+			expectComment: "owner: team-a",
+		},
+		{
+			name:          "ok: clear the comment",
+			cmd:           &commands.UpdateSubCmd{Name: "kvs-name", Comment: ""},
+			expectComment: "",
+		},
+		{
+			name:      "error: not found",
+			cmd:       &commands.UpdateSubCmd{Name: "not-found", Comment: "comment"},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e := emulator.New()
+			createTestKVS(tt, e, "kvs-name", "comment", nil)
+
+			buf := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient: e.CloudFront(),
+				Output:           "json",
+				OutputTarget:     buf,
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Contains(buf.String(), fmt.Sprintf(`"comment": "%s"`, c.expectComment))
+
+			out, err := e.CloudFront().DescribeKeyValueStore(context.Background(), &cf.DescribeKeyValueStoreInput{Name: aws.String("kvs-name")})
+			asst.NoError(err)
+			asst.Equal(c.expectComment, aws.ToString(out.KeyValueStore.Comment))
+		})
+	}
+}
+
 func Test_DeleteKVSSubCmd_Run(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -332,14 +655,86 @@ func Test_DeleteKVSSubCmd_Run(t *testing.T) {
 				m := libs.NewMockCloudFrontClient(ctrl)
 				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(
 					&cf.DescribeKeyValueStoreOutput{
-						ETag: aws.String("etag"),
+						ETag:          aws.String("etag"),
+						KeyValueStore: &cfTypes.KeyValueStore{ARN: aws.String("arn")},
 					}, nil)
+				m.EXPECT().ListFunctions(gomock.Any(), gomock.Any()).Return(
+					&cf.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{}}, nil)
 				m.EXPECT().DeleteKeyValueStore(gomock.Any(), gomock.Any()).Return(
 					&cf.DeleteKeyValueStoreOutput{}, nil)
 				return m
 			},
 			wantError: false,
 		},
+		{
+			name: "ok: ARN",
+			cmd:  &commands.DeleteKVSSubCmd{Name: "arn:aws:cloudfront::123456789012:key-value-store/id"},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				m := libs.NewMockCloudFrontClient(ctrl)
+				m.EXPECT().ListKeyValueStores(gomock.Any(), gomock.Any()).Return(
+					&cf.ListKeyValueStoresOutput{
+						KeyValueStoreList: &cfTypes.KeyValueStoreList{
+							Items: []cfTypes.KeyValueStore{
+								{
+									Name: aws.String("name"),
+									ARN:  aws.String("arn:aws:cloudfront::123456789012:key-value-store/id"),
+								},
+							},
+						},
+					}, nil)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), &cf.DescribeKeyValueStoreInput{Name: aws.String("name")}).Return(
+					&cf.DescribeKeyValueStoreOutput{
+						ETag:          aws.String("etag"),
+						KeyValueStore: &cfTypes.KeyValueStore{ARN: aws.String("arn")},
+					}, nil)
+				m.EXPECT().ListFunctions(gomock.Any(), gomock.Any()).Return(
+					&cf.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{}}, nil)
+				m.EXPECT().DeleteKeyValueStore(gomock.Any(), &cf.DeleteKeyValueStoreInput{Name: aws.String("name"), IfMatch: aws.String("etag")}).Return(
+					&cf.DeleteKeyValueStoreOutput{}, nil)
+				return m
+			},
+			wantError: false,
+		},
+		{
+			name: "error: ARN not found",
+			cmd:  &commands.DeleteKVSSubCmd{Name: "arn:aws:cloudfront::123456789012:key-value-store/id"},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				m := libs.NewMockCloudFrontClient(ctrl)
+				m.EXPECT().ListKeyValueStores(gomock.Any(), gomock.Any()).Return(
+					&cf.ListKeyValueStoresOutput{
+						KeyValueStoreList: &cfTypes.KeyValueStoreList{},
+					}, nil)
+				return m
+			},
+			wantError: true,
+		},
+		{
+			name: "error: associated with functions",
+			cmd:  &commands.DeleteKVSSubCmd{Name: "name"},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				m := libs.NewMockCloudFrontClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(
+					&cf.DescribeKeyValueStoreOutput{
+						ETag:          aws.String("etag"),
+						KeyValueStore: &cfTypes.KeyValueStore{ARN: aws.String("arn")},
+					}, nil)
+				m.EXPECT().ListFunctions(gomock.Any(), gomock.Any()).Return(
+					&cf.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{
+						Items: []cfTypes.FunctionSummary{{
+							Name: aws.String("fn"),
+							FunctionConfig: &cfTypes.FunctionConfig{
+								KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{
+									Quantity: aws.Int32(1),
+									Items:    []cfTypes.KeyValueStoreAssociation{{KeyValueStoreARN: aws.String("arn")}},
+								},
+							},
+							FunctionMetadata: &cfTypes.FunctionMetadata{Stage: cfTypes.FunctionStageLive},
+						}},
+					}}, nil)
+				return m
+			},
+			wantError: true,
+		},
 		{
 			name: "error: cloudfront.DeleteKeyValueStore returns error",
 			cmd:  &commands.DeleteKVSSubCmd{Name: "name"},
@@ -347,8 +742,11 @@ func Test_DeleteKVSSubCmd_Run(t *testing.T) {
 				m := libs.NewMockCloudFrontClient(ctrl)
 				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(
 					&cf.DescribeKeyValueStoreOutput{
-						ETag: aws.String("etag"),
+						ETag:          aws.String("etag"),
+						KeyValueStore: &cfTypes.KeyValueStore{ARN: aws.String("arn")},
 					}, nil)
+				m.EXPECT().ListFunctions(gomock.Any(), gomock.Any()).Return(
+					&cf.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{}}, nil)
 				m.EXPECT().DeleteKeyValueStore(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 				return m
 			},
@@ -361,8 +759,11 @@ func Test_DeleteKVSSubCmd_Run(t *testing.T) {
 				m := libs.NewMockCloudFrontClient(ctrl)
 				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(
 					&cf.DescribeKeyValueStoreOutput{
-						ETag: aws.String("etag"),
+						ETag:          aws.String("etag"),
+						KeyValueStore: &cfTypes.KeyValueStore{ARN: aws.String("arn")},
 					}, nil)
+				m.EXPECT().ListFunctions(gomock.Any(), gomock.Any()).Return(
+					&cf.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{}}, nil)
 				m.EXPECT().DeleteKeyValueStore(gomock.Any(), gomock.Any()).Return(
 					nil, nil)
 				return m
@@ -378,6 +779,7 @@ func Test_DeleteKVSSubCmd_Run(t *testing.T) {
 			ctrl := gomock.NewController(tt)
 			cfcMock := c.cfcMock(ctrl)
 			globals := &commands.Globals{
+				Output:           output.OutputTypeTable,
 				CloudFrontClient: cfcMock,
 				OutputTarget:     &bytes.Buffer{},
 			}
@@ -411,6 +813,8 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 			cfcMock: noErrorMockCloudFrontClient,
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
 				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
 					Return(&kvs.ListKeysOutput{
 						Items: []kvsTypes.ListKeysResponseListItem{},
@@ -429,6 +833,8 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 			cfcMock: noErrorMockCloudFrontClient,
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
 				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
 					Return(&kvs.ListKeysOutput{
 						Items: []kvsTypes.ListKeysResponseListItem{},
@@ -447,12 +853,12 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 			cfcMock: noErrorMockCloudFrontClient,
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
 				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
 					Return(&kvs.ListKeysOutput{
 						Items: []kvsTypes.ListKeysResponseListItem{},
 					}, nil)
-				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
-					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().UpdateKeys(gomock.Any(), gomock.Any()).Return(&kvs.UpdateKeysOutput{
 					ItemCount:        aws.Int32(1),
 					TotalSizeInBytes: aws.Int64(1024),
@@ -514,6 +920,8 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 			cfcMock: noErrorMockCloudFrontClient,
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
 				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 				return m
 			},
@@ -526,14 +934,26 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 				Name: "kvs-name",
 				File: "../../testdata/notfound.json",
 			},
-			cfcMock: noErrorMockCloudFrontClient,
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				return libs.NewMockCloudFrontClient(ctrl)
+			},
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
-				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
-				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
-					Return(&kvs.ListKeysOutput{
-						Items: []kvsTypes.ListKeysResponseListItem{},
-					}, nil)
-				return m
+				return libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			},
+			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: invalid items in the file",
+			cmd: &commands.SyncSubCmd{
+				Name: "kvs-name",
+				File: "../../testdata/invalid-2.json",
+			},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				return libs.NewMockCloudFrontClient(ctrl)
+			},
+			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+				return libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
 			},
 			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
 			wantError: true,
@@ -545,14 +965,11 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 				Bucket:    "bucket",
 				ObjectKey: "object-key",
 			},
-			cfcMock: noErrorMockCloudFrontClient,
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				return libs.NewMockCloudFrontClient(ctrl)
+			},
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
-				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
-				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
-					Return(&kvs.ListKeysOutput{
-						Items: []kvsTypes.ListKeysResponseListItem{},
-					}, nil)
-				return m
+				return libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
 			},
 			s3cMock:   errorMockS3Client,
 			wantError: true,
@@ -567,12 +984,12 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 			cfcMock: noErrorMockCloudFrontClient,
 			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
 				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
 					Return(&kvs.ListKeysOutput{
 						Items: []kvsTypes.ListKeysResponseListItem{},
 					}, nil)
-				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
-					Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil)
 				m.EXPECT().UpdateKeys(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
 				return m
 			},
@@ -590,6 +1007,7 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 			kvscMock := c.kvscMock(ctrl)
 			s3cMock := c.s3cMock(ctrl)
 			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
 				CloudFrontClient:              cfcMock,
 				CloudFrontKeyValueStoreClient: kvscMock,
 				S3Client:                      s3cMock,
@@ -606,3 +1024,848 @@ func Test_SyncSubCmd_Run(t *testing.T) {
 		})
 	}
 }
+
+// racingKeyValueStore writes to the key value store right before the first UpdateKeys or PutKey request,
+// or right after the first ListKeys request, as another pipeline that writes to the same key value store concurrently.
+type racingKeyValueStore struct {
+	*emulator.KeyValueStore
+	race     func()
+	racePut  func()
+	raceList func()
+}
+
+func (r *racingKeyValueStore) ListKeys(ctx context.Context, params *kvs.ListKeysInput, optFns ...func(*kvs.Options)) (*kvs.ListKeysOutput, error) {
+	out, err := r.KeyValueStore.ListKeys(ctx, params, optFns...)
+	if r.raceList != nil {
+		race := r.raceList
+		r.raceList = nil
+		race()
+	}
+	return out, err
+}
+
+func (r *racingKeyValueStore) UpdateKeys(ctx context.Context, params *kvs.UpdateKeysInput, optFns ...func(*kvs.Options)) (*kvs.UpdateKeysOutput, error) {
+	if r.race != nil {
+		race := r.race
+		r.race = nil
+		race()
+	}
+	return r.KeyValueStore.UpdateKeys(ctx, params, optFns...)
+}
+
+func (r *racingKeyValueStore) PutKey(ctx context.Context, params *kvs.PutKeyInput, optFns ...func(*kvs.Options)) (*kvs.PutKeyOutput, error) {
+	if r.racePut != nil {
+		race := r.racePut
+		r.racePut = nil
+		race()
+	}
+	return r.KeyValueStore.PutKey(ctx, params, optFns...)
+}
+
+func Test_SyncSubCmd_Run_conflict(t *testing.T) {
+	cases := []struct {
+		name          string
+		retries       int
+		raceKey       string
+		afterList     bool
+		wantError     bool
+		expectMessage string
+		expectItems   []types.Item
+	}{
+		{
+			name:    "ok: retried after a write to another key",
+			retries: 3,
+			raceKey: "other",
+			expectItems: []types.Item{
+				{Key: "key-1", Value: "v 1"},
+				{Key: "key-2", Value: "value-2"},
+				{Key: "key-4", Value: "v 4"},
+				{Key: "other", Value: "raced"},
+			},
+		},
+		{
+			name:          "error: the diff has been changed",
+			retries:       3,
+			raceKey:       "key-1",
+			wantError:     true,
+			expectMessage: "the diff is different from the one shown",
+		},
+		{
+			name:      "ok: retried after a write to another key between list and apply",
+			retries:   3,
+			raceKey:   "other",
+			afterList: true,
+			expectItems: []types.Item{
+				{Key: "key-1", Value: "v 1"},
+				{Key: "key-2", Value: "value-2"},
+				{Key: "key-4", Value: "v 4"},
+				{Key: "other", Value: "raced"},
+			},
+		},
+		{
+			name:          "error: the diff has been changed between list and apply",
+			retries:       3,
+			raceKey:       "key-1",
+			afterList:     true,
+			wantError:     true,
+			expectMessage: "the diff is different from the one shown",
+			expectItems:   []types.Item{{Key: "key-1", Value: "raced"}},
+		},
+		{
+			name:          "error: no retries",
+			retries:       0,
+			raceKey:       "other",
+			wantError:     true,
+			expectMessage: "precondition",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, nil)
+
+			race := func() {
+				if _, err := libs.PutItem(ctx, e.KeyValueStore(), kvsARN, c.raceKey, "raced"); err != nil {
+					tt.Fatal(err)
+				}
+			}
+			kvsc := &racingKeyValueStore{KeyValueStore: e.KeyValueStore()}
+			if c.afterList {
+				kvsc.raceList = race
+			} else {
+				kvsc.race = race
+			}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: kvsc,
+				Retries:                       c.retries,
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			cmd := &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Yes: true}
+			err := cmd.Run(globals)
+			if c.wantError {
+				asst.ErrorContains(err, c.expectMessage)
+			} else {
+				asst.NoError(err)
+			}
+			if c.expectItems == nil {
+				return
+			}
+
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), kvsARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectItems, items.Data)
+		})
+	}
+}
+
+func Test_SyncSubCmd_Run_structuredOutput(t *testing.T) {
+	for _, o := range []output.OutputType{output.OutputTypeJson, output.OutputTypeTable} {
+		t.Run(string(o), func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, _ := newTestKVS(tt, nil)
+
+			out := &bytes.Buffer{}
+			log := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        o,
+				OutputTarget:                  out,
+				LogTarget:                     log,
+			}
+
+			cmd := &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Yes: true}
+			asst.NoError(cmd.Run(globals))
+
+			if o == output.OutputTypeTable {
+				asst.NotContains(log.String(), "Applied:")
+				return
+			}
+
+			// the output is only the diff, and the result is logged
+			doc := &types.DiffDocument{}
+			dec := json.NewDecoder(out)
+			asst.NoError(dec.Decode(doc))
+			asst.Equal(3, doc.Summary.Total)
+			asst.False(dec.More())
+			asst.Contains(log.String(), "Applied: 3 items")
+		})
+	}
+}
+
+func Test_SyncSubCmd_Run_filter(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "flags.json")
+	if err := os.WriteFile(source, []byte(`{"data":[{"key":"flags/a","value":"on"},{"key":"flags/c","value":"on"},{"key":"redirects/a","value":"/new"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name        string
+		filter      commands.KeyFilterFlags
+		delete      bool
+		wantError   bool
+		expectItems []types.Item
+	}{
+		{
+			name:   "ok: delete only the keys under the prefix",
+			filter: commands.KeyFilterFlags{Prefix: "flags/"},
+			delete: true,
+			expectItems: []types.Item{
+				{Key: "flags/a", Value: "on"},
+				{Key: "flags/c", Value: "on"},
+				{Key: "redirects/a", Value: "/old"},
+				{Key: "redirects/b", Value: "/old"},
+			},
+		},
+		{
+			name:   "ok: glob",
+			filter: commands.KeyFilterFlags{Glob: "redirects/*"},
+			delete: true,
+			expectItems: []types.Item{
+				{Key: "flags/a", Value: "off"},
+				{Key: "flags/b", Value: "off"},
+				{Key: "redirects/a", Value: "/new"},
+			},
+		},
+		{
+			name:   "ok: regex without delete",
+			filter: commands.KeyFilterFlags{Regex: "/a$"},
+			expectItems: []types.Item{
+				{Key: "flags/a", Value: "on"},
+				{Key: "flags/b", Value: "off"},
+				{Key: "redirects/a", Value: "/new"},
+				{Key: "redirects/b", Value: "/old"},
+			},
+		},
+		{
+			name:      "error: invalid regex",
+			filter:    commands.KeyFilterFlags{Regex: "("},
+			delete:    true,
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, []types.Item{
+				{Key: "flags/a", Value: "off"},
+				{Key: "flags/b", Value: "off"},
+				{Key: "redirects/a", Value: "/old"},
+				{Key: "redirects/b", Value: "/old"},
+			})
+
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			cmd := &commands.SyncSubCmd{Name: "kvs-name", File: source, Delete: c.delete, Yes: true, KeyFilterFlags: c.filter}
+			err := cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), kvsARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectItems, items.Data)
+		})
+	}
+}
+
+func Test_SyncSubCmd_Run_detailedExitCode(t *testing.T) {
+	cases := []struct {
+		name         string
+		seed         []types.Item
+		cmd          *commands.SyncSubCmd
+		wantError    bool
+		wantExitCode int
+	}{
+		{
+			name: "ok: in sync",
+			seed: []types.Item{{Key: "key-1", Value: "v 1"}, {Key: "key-2", Value: "value-2"}, {Key: "key-4", Value: "v 4"}},
+			cmd:  &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Delete: true, DetailedExitCode: true},
+		},
+		{
+			name:         "changes pending",
+			seed:         []types.Item{{Key: "key-1", Value: "edited by hand"}, {Key: "key-2", Value: "value-2"}, {Key: "key-4", Value: "v 4"}},
+			cmd:          &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Delete: true, DetailedExitCode: true},
+			wantExitCode: commands.ExitCodeChangesPending,
+		},
+		{
+			name: "ok: changes pending without detailed-exitcode",
+			seed: []types.Item{{Key: "key-1", Value: "edited by hand"}},
+			cmd:  &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Delete: true},
+		},
+		{
+			name:      "error: with yes",
+			seed:      []types.Item{{Key: "key-1", Value: "edited by hand"}},
+			cmd:       &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Yes: true, DetailedExitCode: true},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, _ := newTestKVS(tt, c.seed)
+
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			var exitErr *commands.ExitError
+			switch {
+			case c.wantExitCode != 0:
+				if asst.True(errors.As(err, &exitErr), err) {
+					asst.Equal(c.wantExitCode, exitErr.Code)
+					asst.Nil(exitErr.Err)
+				}
+			case c.wantError:
+				asst.Error(err)
+				asst.False(errors.As(err, &exitErr))
+			default:
+				asst.NoError(err)
+			}
+		})
+	}
+}
+
+func Test_SyncSubCmd_Run_snapshot(t *testing.T) {
+	cases := []struct {
+		name            string
+		seed            []types.Item
+		yes             bool
+		wantError       bool
+		expectSnapshots int
+	}{
+		{
+			name:            "ok: snapshot before applying",
+			seed:            []types.Item{{Key: "key-1", Value: "edited by hand"}},
+			yes:             true,
+			expectSnapshots: 1,
+		},
+		{
+			name:            "ok: no snapshot without changes",
+			seed:            []types.Item{{Key: "key-1", Value: "v 1"}, {Key: "key-2", Value: "value-2"}, {Key: "key-4", Value: "v 4"}},
+			yes:             true,
+			expectSnapshots: 0,
+		},
+		{
+			name:      "error: without yes",
+			seed:      []types.Item{{Key: "key-1", Value: "edited by hand"}},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, _ := newTestKVS(tt, c.seed)
+
+			log := &bytes.Buffer{}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  &bytes.Buffer{},
+				LogTarget:                     log,
+			}
+
+			dir := tt.TempDir()
+			cmd := &commands.SyncSubCmd{
+				Name:               "kvs-name",
+				File:               "../../testdata/valid.json",
+				Delete:             true,
+				Yes:                c.yes,
+				Snapshot:           true,
+				SnapshotStoreFlags: commands.SnapshotStoreFlags{Dir: dir},
+			}
+			err := cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			store := &libs.LocalSnapshotStore{Dir: dir}
+			list, err := store.ListSnapshots(ctx, "kvs-name")
+			asst.NoError(err)
+			asst.Len(list, c.expectSnapshots)
+			if c.expectSnapshots == 0 {
+				return
+			}
+
+			// the snapshot has the items before sync
+			asst.Contains(log.String(), "Saved the snapshot ")
+			snapshot, err := store.GetSnapshot(ctx, "kvs-name", libs.LatestSnapshotID)
+			asst.NoError(err)
+			asst.Equal(c.seed, snapshot.Items)
+		})
+	}
+}
+
+func Test_SyncSubCmd_Run_output(t *testing.T) {
+	expectDoc := &types.DiffDocument{
+		Added:   []types.Item{{Key: "key-2", Value: "value-2"}, {Key: "key-4", Value: "v 4"}},
+		Updated: []types.UpdatedItem{{Key: "key-1", Before: "old", After: "v 1"}},
+		Deleted: []types.Item{{Key: "other", Value: "v"}},
+		Summary: types.DiffSummary{Added: 2, Updated: 1, Deleted: 1, Total: 4},
+	}
+
+	cases := []struct {
+		name       string
+		outputType output.OutputType
+		unmarshal  func(b []byte, v any) error
+		expectText string
+	}{
+		{
+			name:       "json",
+			outputType: output.OutputTypeJson,
+			unmarshal:  json.Unmarshal,
+		},
+		{
+			name:       "yaml",
+			outputType: output.OutputTypeYaml,
+			unmarshal: func(b []byte, v any) error {
+				// the YAML document has the same field names as JSON
+				var doc any
+				if err := yaml.Unmarshal(b, &doc); err != nil {
+					return err
+				}
+				j, err := json.Marshal(doc)
+				if err != nil {
+					return err
+				}
+				return json.Unmarshal(j, v)
+			},
+		},
+		{
+			name:       "table",
+			outputType: output.OutputTypeTable,
+			expectText: "[UPDATED] Following items will be updated.",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, _ := newTestKVS(tt, []types.Item{{Key: "key-1", Value: "old"}, {Key: "other", Value: "v"}})
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        c.outputType,
+				OutputTarget:                  out,
+				LogTarget:                     &bytes.Buffer{},
+			}
+
+			cmd := &commands.SyncSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Delete: true}
+			asst.NoError(cmd.Run(globals))
+
+			planCmd := &commands.PlanSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Delete: true, Out: filepath.Join(tt.TempDir(), "plan.json")}
+			asst.NoError(planCmd.Run(globals))
+
+			if c.unmarshal == nil {
+				asst.Contains(out.String(), c.expectText)
+				asst.Contains(out.String(), "Saved the plan to ")
+				return
+			}
+
+			// the output of sync and plan is a stream of two diff documents, and nothing else
+			docs := []string{}
+			if c.outputType == output.OutputTypeYaml {
+				docs = strings.Split(strings.TrimPrefix(out.String(), "---\n"), "---\n")
+			} else {
+				dec := json.NewDecoder(out)
+				for dec.More() {
+					var raw json.RawMessage
+					asst.NoError(dec.Decode(&raw))
+					docs = append(docs, string(raw))
+				}
+			}
+			asst.Len(docs, 2)
+			for _, d := range docs {
+				doc := &types.DiffDocument{}
+				asst.NoError(c.unmarshal([]byte(d), doc))
+				asst.Equal(expectDoc, doc)
+			}
+		})
+	}
+}
+
+func Test_ExportSubCmd_Run(t *testing.T) {
+	listKeysMock := func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+		m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+		m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
+			Return(&kvs.ListKeysOutput{
+				Items: []kvsTypes.ListKeysResponseListItem{
+					{Key: aws.String("k"), Value: aws.String("v")},
+				},
+			}, nil)
+		return m
+	}
+
+	cases := []struct {
+		name      string
+		cmd       *commands.ExportSubCmd
+		cfcMock   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient
+		kvscMock  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient
+		s3cMock   func(ctrl *gomock.Controller) *libs.MockS3Client
+		expect    string
+		wantError bool
+	}{
+		{
+			name: "ok: to file",
+			cmd: &commands.ExportSubCmd{
+				Name: "kvs-name",
+				File: "out.json",
+			},
+			cfcMock:  noErrorMockCloudFrontClient,
+			kvscMock: listKeysMock,
+			s3cMock:  func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			expect:   "Exported 1 items to ",
+		},
+		{
+			name: "ok: to S3 object",
+			cmd: &commands.ExportSubCmd{
+				Name:      "kvs-name",
+				Bucket:    "bucket",
+				ObjectKey: "object-key",
+			},
+			cfcMock:  noErrorMockCloudFrontClient,
+			kvscMock: listKeysMock,
+			s3cMock: func(ctrl *gomock.Controller) *libs.MockS3Client {
+				m := libs.NewMockS3Client(ctrl)
+				m.EXPECT().PutObject(gomock.Any(), gomock.Any()).Return(&s3.PutObjectOutput{}, nil)
+				return m
+			},
+			expect: "Exported 1 items to s3://bucket/object-key\n",
+		},
+		{
+			name: "error: Name is empty",
+			cmd: &commands.ExportSubCmd{
+				File: "out.json",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: bucket is empty",
+			cmd: &commands.ExportSubCmd{
+				Name:      "kvs-name",
+				ObjectKey: "object-key",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: object-key is empty",
+			cmd: &commands.ExportSubCmd{
+				Name:   "kvs-name",
+				Bucket: "bucket",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: getKVSArn returns error",
+			cmd: &commands.ExportSubCmd{
+				Name: "kvs-name",
+				File: "out.json",
+			},
+			cfcMock:   errorMockCloudFrontClient,
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: libs.ListItems returns error",
+			cmd: &commands.ExportSubCmd{
+				Name: "kvs-name",
+				File: "out.json",
+			},
+			cfcMock: noErrorMockCloudFrontClient,
+			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+				return m
+			},
+			s3cMock:   func(ctrl *gomock.Controller) *libs.MockS3Client { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: libs.PutKeyValueStoreData returns error",
+			cmd: &commands.ExportSubCmd{
+				Name:      "kvs-name",
+				Bucket:    "bucket",
+				ObjectKey: "object-key",
+			},
+			cfcMock:  noErrorMockCloudFrontClient,
+			kvscMock: listKeysMock,
+			s3cMock: func(ctrl *gomock.Controller) *libs.MockS3Client {
+				m := libs.NewMockS3Client(ctrl)
+				m.EXPECT().PutObject(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+				return m
+			},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			if c.cmd.File != "" {
+				c.cmd.File = filepath.Join(tt.TempDir(), c.cmd.File)
+			}
+
+			ctrl := gomock.NewController(tt)
+			cfcMock := c.cfcMock(ctrl)
+			kvscMock := c.kvscMock(ctrl)
+			s3cMock := c.s3cMock(ctrl)
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              cfcMock,
+				CloudFrontKeyValueStoreClient: kvscMock,
+				S3Client:                      s3cMock,
+				OutputTarget:                  out,
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			if c.cmd.File != "" {
+				asst.Equal(c.expect+c.cmd.File+"\n", out.String())
+
+				data, err := libs.GetKeyValueStoreDataFromFile(c.cmd.File, types.DataFormatAuto)
+				asst.NoError(err)
+				asst.Len(*data.Data, 1)
+				return
+			}
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
+
+func Test_PlanSubCmd_Run(t *testing.T) {
+	cases := []struct {
+		name      string
+		cmd       *commands.PlanSubCmd
+		cfcMock   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient
+		kvscMock  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient
+		wantError bool
+	}{
+		{
+			name: "ok",
+			cmd: &commands.PlanSubCmd{
+				Name: "kvs-name",
+				File: "../../testdata/valid.json",
+				Out:  "plan.json",
+			},
+			cfcMock: noErrorMockCloudFrontClient,
+			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				gomock.InOrder(
+					m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+						Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, nil),
+					m.EXPECT().ListKeys(gomock.Any(), gomock.Any()).
+						Return(&kvs.ListKeysOutput{Items: []kvsTypes.ListKeysResponseListItem{}}, nil),
+				)
+				return m
+			},
+		},
+		{
+			name: "error: Name is empty",
+			cmd: &commands.PlanSubCmd{
+				File: "../../testdata/valid.json",
+				Out:  "plan.json",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: bucket is empty",
+			cmd: &commands.PlanSubCmd{
+				Name:      "kvs-name",
+				ObjectKey: "object-key",
+				Out:       "plan.json",
+			},
+			cfcMock:   func(ctrl *gomock.Controller) *libs.MockCloudFrontClient { return nil },
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: getKVSArn returns error",
+			cmd: &commands.PlanSubCmd{
+				Name: "kvs-name",
+				File: "../../testdata/valid.json",
+				Out:  "plan.json",
+			},
+			cfcMock:   errorMockCloudFrontClient,
+			kvscMock:  func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient { return nil },
+			wantError: true,
+		},
+		{
+			name: "error: libs.GetKeyValueStoreETag returns error",
+			cmd: &commands.PlanSubCmd{
+				Name: "kvs-name",
+				File: "../../testdata/valid.json",
+				Out:  "plan.json",
+			},
+			cfcMock: noErrorMockCloudFrontClient,
+			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+				m.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(nil, errors.New("error"))
+				return m
+			},
+			wantError: true,
+		},
+		{
+			name: "error: file not found",
+			cmd: &commands.PlanSubCmd{
+				Name: "kvs-name",
+				File: "../../testdata/notfound.json",
+				Out:  "plan.json",
+			},
+			cfcMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontClient {
+				return libs.NewMockCloudFrontClient(ctrl)
+			},
+			kvscMock: func(ctrl *gomock.Controller) *libs.MockCloudFrontKeyValueStoreClient {
+				return libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			c.cmd.Out = filepath.Join(tt.TempDir(), c.cmd.Out)
+
+			ctrl := gomock.NewController(tt)
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              c.cfcMock(ctrl),
+				CloudFrontKeyValueStoreClient: c.kvscMock(ctrl),
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			err := c.cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				asst.NoFileExists(c.cmd.Out)
+				return
+			}
+
+			asst.NoError(err)
+
+			plan, err := libs.GetPlanFromFile(c.cmd.Out)
+			asst.NoError(err)
+			asst.Equal("kvs-arn", plan.KVSARN)
+			asst.Equal("etag", plan.ETag)
+			asst.Len(plan.Diff.Add, 3)
+		})
+	}
+}
+
+func Test_ApplySubCmd_Run(t *testing.T) {
+	cases := []struct {
+		name string
+		// change is called between plan and apply
+		change    func(tt *testing.T, e *emulator.Emulator, kvsARN string)
+		wantError bool
+	}{
+		{
+			name: "ok",
+		},
+		{
+			name: "error: the key value store has been changed",
+			change: func(tt *testing.T, e *emulator.Emulator, kvsARN string) {
+				if _, err := libs.PutItem(context.Background(), e.KeyValueStore(), kvsARN, "key-x", "value-x"); err != nil {
+					tt.Fatal(err)
+				}
+			},
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			e, kvsARN := newTestKVS(tt, nil)
+
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  &bytes.Buffer{},
+			}
+
+			planFile := filepath.Join(tt.TempDir(), "plan.json")
+			plan := &commands.PlanSubCmd{Name: "kvs-name", File: "../../testdata/valid.json", Out: planFile}
+			asst.NoError(plan.Run(globals))
+
+			if c.change != nil {
+				c.change(tt, e, kvsARN)
+			}
+
+			apply := &commands.ApplySubCmd{Plan: planFile}
+			err := apply.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+
+			items, err := libs.ListItems(context.Background(), e.KeyValueStore(), kvsARN)
+			asst.NoError(err)
+			asst.Len(items.Data, 3)
+
+			// the same plan cannot be applied twice
+			asst.Error(apply.Run(globals))
+		})
+	}
+
+	asst := assert.New(t)
+	apply := &commands.ApplySubCmd{Plan: "../../testdata/notfound.json"}
+	asst.Error(apply.Run(&commands.Globals{}))
+}
@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+)
+
+type ManifestApplyCmd struct {
+	File string `name:"file" short:"f" help:"Path to the manifest file that declares key value stores and the sources of their items." default:"cfkvs.yaml"`
+	Yes  bool   `name:"yes" short:"y" help:"Execute apply. If not specified, only show the plan."`
+
+	DetailedExitCode bool          `name:"detailed-exitcode" help:"Exit with 0 if there is no change, 2 if there are changes to apply, or 1 on error. Only for a dry run without --yes."`
+	WaitTimeout      time.Duration `name:"wait-timeout" help:"Maximum time to wait until a created key value store is READY." default:"10m"`
+}
+
+// manifestChange is the change to a key value store in the manifest, to apply it after the plan is shown.
+type manifestChange struct {
+	store  types.ManifestStore
+	arn    string
+	create bool
+	// commentBefore is the current comment, if the comment is updated.
+	commentBefore *string
+	after         *types.ItemList
+	filter        *types.KeyFilter
+	diff          *types.ItemListDiff
+	// eTag is the ETag that the diff is computed against, or empty if the key value store is created.
+	eTag string
+}
+
+func (c *ManifestApplyCmd) Run(globals *Globals) error {
+	if c.DetailedExitCode && c.Yes {
+		return errors.New("detailed-exitcode cannot be specified with yes")
+	}
+
+	manifest, err := libs.GetManifestFromFile(c.File)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.TODO()
+	changes, err := planManifest(ctx, globals, manifest)
+	if err != nil {
+		return err
+	}
+
+	plan := &types.ManifestPlan{Stores: []types.ManifestStorePlan{}}
+	for _, change := range changes {
+		plan.Stores = append(plan.Stores, change.plan())
+	}
+	// show plan
+	if err := globals.render(plan); err != nil {
+		return err
+	}
+
+	if !c.Yes {
+		if c.DetailedExitCode && !plan.IsEmpty() {
+			return &ExitError{Code: ExitCodeChangesPending}
+		}
+		return nil
+	}
+
+	for _, change := range changes {
+		if err := c.apply(ctx, globals, change); err != nil {
+			return fmt.Errorf("[%s] %w", change.store.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// planManifest computes the changes to all key value stores in the manifest, before any of them is applied.
+func planManifest(ctx context.Context, globals *Globals, manifest *types.Manifest) ([]*manifestChange, error) {
+	out, err := libs.ListKeyValueStore(ctx, globals.CloudFrontClient)
+	if err != nil {
+		return nil, err
+	}
+	kvsList := types.KVSList{}
+	if err := kvsList.Parse(out); err != nil {
+		return nil, err
+	}
+	existing := map[string]types.KVS{}
+	for _, kvs := range kvsList {
+		existing[kvs.Name] = kvs
+	}
+
+	changes := []*manifestChange{}
+	for _, s := range manifest.Stores {
+		format, err := s.DataFormat()
+		if err != nil {
+			return nil, err
+		}
+		filter, err := s.KeyFilter()
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := loadSyncSource(ctx, globals, s.Bucket, s.ObjectKey, s.File, format)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] %w", s.Name, err)
+		}
+		after = filterSyncSource(globals, after, filter)
+
+		change := &manifestChange{store: s, after: after, filter: filter}
+		kvs, ok := existing[s.Name]
+		if !ok {
+			change.create = true
+			change.diff = types.NewItemList(nil).Diff(after, s.Delete)
+		} else {
+			change.arn = kvs.ARN
+			if s.Comment != nil && *s.Comment != kvs.Comment {
+				change.commentBefore = aws.String(kvs.Comment)
+			}
+			if change.diff, change.eTag, err = syncDiff(ctx, globals, kvs.ARN, after, s.Delete, filter); err != nil {
+				return nil, fmt.Errorf("[%s] %w", s.Name, err)
+			}
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// plan returns the change in the combined plan.
+func (m *manifestChange) plan() types.ManifestStorePlan {
+	p := types.ManifestStorePlan{
+		Name:   m.store.Name,
+		Create: m.create,
+		Diff:   m.diff.Document(),
+	}
+	if m.commentBefore != nil {
+		p.Comment = &types.UpdatedComment{Before: *m.commentBefore, After: aws.ToString(m.store.Comment)}
+	}
+
+	return p
+}
+
+// apply creates the key value store, updates its comment and syncs its items, as planned.
+func (c *ManifestApplyCmd) apply(ctx context.Context, globals *Globals, change *manifestChange) error {
+	name := change.store.Name
+
+	if change.create {
+		created, err := libs.CreateKeyValueStore(ctx, globals.CloudFrontClient, name, aws.ToString(change.store.Comment), nil)
+		if err != nil {
+			return err
+		}
+		if created.KeyValueStore == nil {
+			return fmt.Errorf("cloudfront.CreateKeyValueStoreOutput.KeyValueStore is nil")
+		}
+		change.arn = aws.ToString(created.KeyValueStore.ARN)
+		globals.logf("[%s] created\n", name)
+
+		if _, err := waitKeyValueStore(ctx, globals, name, "READY", c.WaitTimeout); err != nil {
+			return err
+		}
+
+		// the diff was planned against no items, so it is computed again to get the ETag that it is applied to
+		diff, eTag, err := syncDiff(ctx, globals, change.arn, change.after, change.store.Delete, change.filter)
+		if err != nil {
+			return err
+		}
+		if !diff.IsSubsetOf(change.diff) {
+			return errors.New("the created key value store has been changed by another write, and the diff is different from the one shown\nrun apply again to review the new diff")
+		}
+		change.diff, change.eTag = diff, eTag
+	}
+
+	if change.commentBefore != nil {
+		if _, err := libs.UpdateKeyValueStore(ctx, globals.CloudFrontClient, name, aws.ToString(change.store.Comment)); err != nil {
+			return err
+		}
+		globals.logf("[%s] updated the comment\n", name)
+	}
+
+	if change.diff.IsEmpty() {
+		return nil
+	}
+
+	return applyDiffWithConflictRetry(ctx, globals, change.arn, change.diff, change.eTag, "apply", func() (*types.ItemListDiff, string, error) {
+		return syncDiff(ctx, globals, change.arn, change.after, change.store.Delete, change.filter)
+	})
+}
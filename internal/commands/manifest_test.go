@@ -0,0 +1,155 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/michimani/cfkvs/emulator"
+	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ManifestApplyCmd_Run(t *testing.T) {
+	dir := t.TempDir()
+	missingSource := filepath.Join(dir, "cfkvs.yaml")
+	if err := os.WriteFile(missingSource, []byte("version: 1\nstores:\n  - name: kvs-1\n    file: notfound.json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	validItems := []types.Item{{Key: "key-1", Value: "v 1"}, {Key: "key-2", Value: "value-2"}, {Key: "key-4", Value: "v 4"}}
+
+	cases := []struct {
+		name         string
+		cmd          *commands.ManifestApplyCmd
+		wantError    bool
+		wantExitCode int
+		expectPlan   *types.ManifestPlan
+		expectKVS1   []types.Item
+		expectKVS2   []types.Item
+	}{
+		{
+			name: "ok: dry run",
+			cmd:  &commands.ManifestApplyCmd{File: "../../testdata/cfkvs.yaml"},
+			expectPlan: &types.ManifestPlan{Stores: []types.ManifestStorePlan{
+				{
+					Name:    "kvs-1",
+					Comment: &types.UpdatedComment{Before: "old", After: "synced from valid.json"},
+					Diff: &types.DiffDocument{
+						Added:   []types.Item{{Key: "key-2", Value: "value-2"}, {Key: "key-4", Value: "v 4"}},
+						Updated: []types.UpdatedItem{{Key: "key-1", Before: "edited by hand", After: "v 1"}},
+						Deleted: []types.Item{{Key: "extra", Value: "x"}},
+						Summary: types.DiffSummary{Added: 2, Updated: 1, Deleted: 1, Total: 4},
+					},
+				},
+				{
+					Name:   "kvs-2",
+					Create: true,
+					Diff: &types.DiffDocument{
+						Added:   []types.Item{{Key: "key-1", Value: "v 1"}},
+						Updated: []types.UpdatedItem{},
+						Deleted: []types.Item{},
+						Summary: types.DiffSummary{Added: 1, Total: 1},
+					},
+				},
+			}},
+			expectKVS1: []types.Item{{Key: "extra", Value: "x"}, {Key: "key-1", Value: "edited by hand"}},
+		},
+		{
+			name:         "changes pending",
+			cmd:          &commands.ManifestApplyCmd{File: "../../testdata/cfkvs.yaml", DetailedExitCode: true},
+			wantExitCode: commands.ExitCodeChangesPending,
+			expectKVS1:   []types.Item{{Key: "extra", Value: "x"}, {Key: "key-1", Value: "edited by hand"}},
+		},
+		{
+			name:       "ok: apply",
+			cmd:        &commands.ManifestApplyCmd{File: "../../testdata/cfkvs.yaml", Yes: true},
+			expectKVS1: validItems,
+			expectKVS2: []types.Item{{Key: "key-1", Value: "v 1"}},
+		},
+		{
+			name:       "error: detailed-exitcode with yes",
+			cmd:        &commands.ManifestApplyCmd{File: "../../testdata/cfkvs.yaml", Yes: true, DetailedExitCode: true},
+			wantError:  true,
+			expectKVS1: []types.Item{{Key: "extra", Value: "x"}, {Key: "key-1", Value: "edited by hand"}},
+		},
+		{
+			name:       "error: manifest not found",
+			cmd:        &commands.ManifestApplyCmd{File: "../../testdata/notfound.yaml", Yes: true},
+			wantError:  true,
+			expectKVS1: []types.Item{{Key: "extra", Value: "x"}, {Key: "key-1", Value: "edited by hand"}},
+		},
+		{
+			name:       "error: source not found",
+			cmd:        &commands.ManifestApplyCmd{File: missingSource, Yes: true},
+			wantError:  true,
+			expectKVS1: []types.Item{{Key: "extra", Value: "x"}, {Key: "key-1", Value: "edited by hand"}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e := emulator.New()
+			kvs1ARN := createTestKVS(tt, e, "kvs-1", "old", []types.Item{{Key: "key-1", Value: "edited by hand"}, {Key: "extra", Value: "x"}})
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        output.OutputTypeJson,
+				OutputTarget:                  out,
+			}
+
+			err := c.cmd.Run(globals)
+			var exitErr *commands.ExitError
+			switch {
+			case c.wantExitCode != 0:
+				if asst.True(errors.As(err, &exitErr), err) {
+					asst.Equal(c.wantExitCode, exitErr.Code)
+				}
+			case c.wantError:
+				asst.Error(err)
+			default:
+				asst.NoError(err)
+			}
+
+			if c.expectPlan != nil {
+				plan := &types.ManifestPlan{}
+				asst.NoError(json.Unmarshal(out.Bytes(), plan))
+				asst.Equal(c.expectPlan, plan)
+			}
+
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), kvs1ARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectKVS1, items.Data)
+
+			kvs2ARN, err := libs.GetKeyValueStoreArn(ctx, e.CloudFront(), "kvs-2")
+			if c.expectKVS2 == nil {
+				asst.Error(err)
+				return
+			}
+			asst.NoError(err)
+			items, err = libs.ListItems(ctx, e.KeyValueStore(), kvs2ARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectKVS2, items.Data)
+
+			kvs1, err := libs.DescribeKeyValueStore(ctx, e.CloudFront(), e.KeyValueStore(), "kvs-1")
+			asst.NoError(err)
+			asst.Equal("synced from valid.json", kvs1.Comment)
+
+			// nothing to apply after apply
+			globals.Output = output.OutputTypeTable
+			asst.NoError((&commands.ManifestApplyCmd{File: c.cmd.File, DetailedExitCode: true}).Run(globals))
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+)
+
+// SnapshotStoreFlags are the flags of where snapshots are saved.
+// The S3 bucket takes precedence over the local directory.
+type SnapshotStoreFlags struct {
+	Dir          string `name:"dir" help:"Local directory of snapshots, used unless the S3 bucket is specified." default:".cfkvs/snapshots"`
+	Bucket       string `name:"bucket" help:"S3 bucket of snapshots, instead of the local directory."`
+	ObjectPrefix string `name:"object-prefix" help:"Prefix of the S3 object keys of snapshots."`
+}
+
+func (f *SnapshotStoreFlags) snapshotStore(globals *Globals) libs.SnapshotStore {
+	if f.Bucket != "" {
+		return &libs.S3SnapshotStore{
+			Client: globals.S3Client,
+			Bucket: f.Bucket,
+			Prefix: f.ObjectPrefix,
+		}
+	}
+
+	return &libs.LocalSnapshotStore{Dir: f.Dir}
+}
+
+type SnapshotSubCmd struct {
+	Name string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+
+	SnapshotStoreFlags `embed:""`
+}
+
+type SnapshotsCmd struct {
+	List SnapshotsListSubCmd `cmd:"" help:"List the snapshots of the key value store, from the oldest to the latest."`
+}
+
+type SnapshotsListSubCmd struct {
+	Name string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+
+	SnapshotStoreFlags `embed:""`
+}
+
+type RestoreSubCmd struct {
+	Name     string `name:"name" help:"Name, ID or ARN of the key value store." required:""`
+	Snapshot string `name:"snapshot" help:"ID of the snapshot to restore, or latest." required:""`
+	Yes      bool   `name:"yes" short:"y" help:"Execute restore. If not specified, only show the diff to restore the snapshot."`
+
+	SnapshotStoreFlags `embed:""`
+}
+
+func (c *SnapshotSubCmd) Run(globals *Globals) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	snapshot, location, err := saveSnapshot(ctx, globals, c.snapshotStore(globals), name)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(globals.OutputTarget, "Saved the snapshot %s of %d items to %s\n", snapshot.ID, len(snapshot.Items), location)
+
+	return nil
+}
+
+func (c *SnapshotsListSubCmd) Run(globals *Globals) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	list, err := c.snapshotStore(globals).ListSnapshots(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	return globals.render(&list)
+}
+
+func (c *RestoreSubCmd) Run(globals *Globals) error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.Snapshot == "" {
+		return errors.New("snapshot is required")
+	}
+
+	ctx := context.TODO()
+	name, err := getKVSName(ctx, globals.CloudFrontClient, c.Name)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := c.snapshotStore(globals).GetSnapshot(ctx, name, c.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	kvsARN, err := getKVSArn(ctx, globals.CloudFrontClient, name)
+	if err != nil {
+		return err
+	}
+	if snapshot.KeyValueStore.ARN != "" && snapshot.KeyValueStore.ARN != kvsARN {
+		globals.logf("the snapshot %s was taken of %s, that is not the current key value store %s\n", snapshot.ID, snapshot.KeyValueStore.ARN, kvsARN)
+	}
+
+	after := snapshot.ItemList()
+	diff, eTag, err := syncDiff(ctx, globals, kvsARN, after, true, nil)
+	if err != nil {
+		return err
+	}
+
+	// show diff
+	if err := renderDiff(globals, diff); err != nil {
+		return err
+	}
+
+	if !c.Yes {
+		return nil
+	}
+
+	return applyDiffWithConflictRetry(ctx, globals, kvsARN, diff, eTag, "restore", func() (*types.ItemListDiff, string, error) {
+		return syncDiff(ctx, globals, kvsARN, after, true, nil)
+	})
+}
+
+// saveSnapshot takes a snapshot of the key value store and saves it to the store.
+// It returns the snapshot and its location.
+func saveSnapshot(ctx context.Context, globals *Globals, store libs.SnapshotStore, name string) (*types.Snapshot, string, error) {
+	snapshot, err := libs.CreateSnapshot(ctx, globals.CloudFrontClient, globals.CloudFrontKeyValueStoreClient, name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	location, err := store.PutSnapshot(ctx, name, snapshot)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return snapshot, location, nil
+}
@@ -0,0 +1,197 @@
+package commands_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/michimani/cfkvs/internal/commands"
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SnapshotSubCmd_Run(t *testing.T) {
+	cases := []struct {
+		name          string
+		kvsName       string
+		wantError     bool
+		expectMessage string
+	}{
+		{
+			name:          "ok",
+			kvsName:       "kvs-name",
+			expectMessage: "Saved the snapshot ",
+		},
+		{
+			name:      "error: key value store not found",
+			kvsName:   "not-found",
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			items := []types.Item{{Key: "key1", Value: "value1"}, {Key: "key2", Value: "value2"}}
+			e, kvsARN := newTestKVS(tt, items)
+			dir := tt.TempDir()
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				Output:                        output.OutputTypeTable,
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				OutputTarget:                  out,
+			}
+
+			cmd := &commands.SnapshotSubCmd{Name: c.kvsName, SnapshotStoreFlags: commands.SnapshotStoreFlags{Dir: dir}}
+			err := cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+				asst.Empty(out.String())
+				return
+			}
+
+			asst.NoError(err)
+			asst.Contains(out.String(), c.expectMessage)
+			asst.Contains(out.String(), "of 2 items to "+dir)
+
+			snapshot, err := (&libs.LocalSnapshotStore{Dir: dir}).GetSnapshot(context.Background(), "kvs-name", libs.LatestSnapshotID)
+			asst.NoError(err)
+			asst.Equal(items, snapshot.Items)
+			asst.Equal(kvsARN, snapshot.KeyValueStore.ARN)
+		})
+	}
+}
+
+func Test_SnapshotsListSubCmd_Run(t *testing.T) {
+	asst := assert.New(t)
+
+	e, _ := newTestKVS(t, nil)
+	dir := t.TempDir()
+	store := &libs.LocalSnapshotStore{Dir: dir}
+	for _, createdAt := range []time.Time{
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if _, err := store.PutSnapshot(context.Background(), "kvs-name", types.NewSnapshot(nil, "etag", nil, createdAt)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := &bytes.Buffer{}
+	globals := &commands.Globals{
+		CloudFrontClient:              e.CloudFront(),
+		CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+		Output:                        output.OutputTypeJson,
+		OutputTarget:                  out,
+	}
+
+	cmd := &commands.SnapshotsListSubCmd{Name: "kvs-name", SnapshotStoreFlags: commands.SnapshotStoreFlags{Dir: dir}}
+	asst.NoError(cmd.Run(globals))
+
+	list := types.SnapshotSummaryList{}
+	asst.NoError(json.Unmarshal(out.Bytes(), &list))
+	if asst.Len(list, 2) {
+		asst.Equal("20240101T000000.000Z", list[0].ID)
+		asst.Equal("20240102T000000.000Z", list[1].ID)
+	}
+
+	// snapshots are listed by the name, even if the key value store does not exist
+	out.Reset()
+	cmd = &commands.SnapshotsListSubCmd{Name: "deleted", SnapshotStoreFlags: commands.SnapshotStoreFlags{Dir: dir}}
+	asst.NoError(cmd.Run(globals))
+	asst.Equal("[]\n", out.String())
+}
+
+func Test_RestoreSubCmd_Run(t *testing.T) {
+	snapshotItems := []types.Item{{Key: "key1", Value: "value1"}, {Key: "key2", Value: "value2"}}
+	currentItems := []types.Item{{Key: "key1", Value: "changed"}, {Key: "key3", Value: "value3"}}
+
+	cases := []struct {
+		name        string
+		snapshot    string
+		yes         bool
+		wantError   bool
+		expectItems []types.Item
+	}{
+		{
+			name:        "ok: dry run",
+			snapshot:    libs.LatestSnapshotID,
+			expectItems: currentItems,
+		},
+		{
+			name:        "ok: restore the latest",
+			snapshot:    libs.LatestSnapshotID,
+			yes:         true,
+			expectItems: snapshotItems,
+		},
+		{
+			name:        "ok: restore by ID",
+			snapshot:    "20240101T000000.000Z",
+			yes:         true,
+			expectItems: snapshotItems,
+		},
+		{
+			name:        "error: snapshot not found",
+			snapshot:    "20240102T000000.000Z",
+			yes:         true,
+			wantError:   true,
+			expectItems: currentItems,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctx := context.Background()
+			e, kvsARN := newTestKVS(tt, snapshotItems)
+			dir := tt.TempDir()
+
+			// take a snapshot, then change the items
+			snapshot, err := libs.CreateSnapshot(ctx, e.CloudFront(), e.KeyValueStore(), "kvs-name")
+			if err != nil {
+				tt.Fatal(err)
+			}
+			snapshot.ID = "20240101T000000.000Z"
+			if _, err := (&libs.LocalSnapshotStore{Dir: dir}).PutSnapshot(ctx, "kvs-name", snapshot); err != nil {
+				tt.Fatal(err)
+			}
+			if _, err := libs.SyncItems(ctx, e.KeyValueStore(), kvsARN, currentItems, []types.Item{{Key: "key2"}}); err != nil {
+				tt.Fatal(err)
+			}
+
+			out := &bytes.Buffer{}
+			globals := &commands.Globals{
+				CloudFrontClient:              e.CloudFront(),
+				CloudFrontKeyValueStoreClient: e.KeyValueStore(),
+				Output:                        output.OutputTypeJson,
+				OutputTarget:                  out,
+			}
+
+			cmd := &commands.RestoreSubCmd{
+				Name:               "kvs-name",
+				Snapshot:           c.snapshot,
+				Yes:                c.yes,
+				SnapshotStoreFlags: commands.SnapshotStoreFlags{Dir: dir},
+			}
+			err = cmd.Run(globals)
+			if c.wantError {
+				asst.Error(err)
+			} else {
+				asst.NoError(err)
+				asst.Contains(out.String(), `"total": 3`)
+			}
+
+			items, err := libs.ListItems(ctx, e.KeyValueStore(), kvsARN)
+			asst.NoError(err)
+			asst.ElementsMatch(c.expectItems, items.Data)
+		})
+	}
+}
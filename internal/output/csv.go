@@ -0,0 +1,90 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/michimani/cfkvs/types"
+)
+
+// RenderAsCsv renders the data as CSV with a header row.
+func RenderAsCsv(data any, o io.Writer) error {
+	return renderAsDelimited(data, ',', o)
+}
+
+// RenderAsTsv renders the data as TSV with a header row.
+// Fields that have a tab, a double quote or a newline are quoted in the same way as CSV.
+func RenderAsTsv(data any, o io.Writer) error {
+	return renderAsDelimited(data, '\t', o)
+}
+
+func renderAsDelimited(data any, comma rune, o io.Writer) error {
+	records, err := toRecords(data)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(o)
+	w.Comma = comma
+	return w.WriteAll(records)
+}
+
+// toRecords flattens the data into the rows of a single table, and the first row is the header.
+func toRecords(data any) ([][]string, error) {
+	if diff, ok := data.(*types.ItemListDiff); ok {
+		// the diff is rendered as one table of changes, instead of a table for each kind of change
+		records := [][]string{{"Change", "Key", "Before Value", "After Value"}}
+		for _, d := range diff.Add {
+			records = append(records, []string{"add", d.After.Key, "", d.After.Value})
+		}
+		for _, d := range diff.Update {
+			records = append(records, []string{"update", d.Before.Key, d.Before.Value, d.After.Value})
+		}
+		for _, d := range diff.Delete {
+			records = append(records, []string{"delete", d.Before.Key, d.Before.Value, ""})
+		}
+		return records, nil
+	}
+
+	if plan, ok := data.(*types.ManifestPlan); ok {
+		// the plan is rendered as one table of changes of all key value stores
+		records := [][]string{{"Store", "Change", "Key", "Before Value", "After Value"}}
+		for _, s := range plan.Stores {
+			if s.Create {
+				records = append(records, []string{s.Name, "create", "", "", ""})
+			}
+			if s.Comment != nil {
+				records = append(records, []string{s.Name, "comment", "", s.Comment.Before, s.Comment.After})
+			}
+			for _, r := range manifestPlanItemRecords(s.Diff) {
+				records = append(records, append([]string{s.Name}, r...))
+			}
+		}
+		return records, nil
+	}
+
+	tables, err := toTables(data)
+	if err != nil {
+		return nil, err
+	}
+
+	records := [][]string{}
+	for _, t := range tables {
+		for _, row := range append(t.Headers, t.Rows...) {
+			record := make([]string, len(row))
+			for i, v := range row {
+				switch v := v.(type) {
+				case time.Time:
+					record[i] = v.Format(time.RFC3339)
+				default:
+					record[i] = fmt.Sprint(v)
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
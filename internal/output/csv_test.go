@@ -0,0 +1,194 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderAsCsv(t *testing.T) {
+	t1 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(1 * time.Hour)
+
+	cases := []struct {
+		name    string
+		data    any
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "ok: types.KVS",
+			data:   &types.KVS{Id: "id", Name: "name", Comment: "comment, with comma", Status: "status", ARN: "arn"},
+			expect: "ID,Name,Comment,Status,ARN\nid,name,\"comment, with comma\",status,arn\n",
+		},
+		{
+			name: "ok: types.KVSList",
+			data: &types.KVSList{
+				{Id: "id1", Name: "name1", Comment: "comment1", Status: "status1", ARN: "arn1"},
+				{Id: "id2", Name: "name2", Comment: "comment2", Status: "status2", ARN: "arn2"},
+			},
+			expect: "ID,Name,Comment,Status,ARN\nid1,name1,comment1,status1,arn1\nid2,name2,comment2,status2,arn2\n",
+		},
+		{
+			name: "ok: types.ItemList",
+			data: types.NewItemList([]types.Item{
+				{Key: "key1", Value: `{"a": "b"}`},
+				{Key: "key2", Value: "line1\nline2"},
+			}),
+			expect: "Key,Value\nkey1,\"{\"\"a\"\": \"\"b\"\"}\"\nkey2,\"line1\nline2\"\n",
+		},
+		{
+			name:   "ok: types.Item",
+			data:   &types.Item{Key: "key", Value: "value"},
+			expect: "Key,Value\nkey,value\n",
+		},
+		{
+			name:   "ok: types.KVSSimple",
+			data:   &types.KVSSimple{ItemCount: 1, TotalSize: 2},
+			expect: "ItemCount,TotalSizeInBytes\n1,2\n",
+		},
+		{
+			name: "ok: types.KeyValueStoreFull",
+			data: &types.KeyValueStoreFull{
+				ID:               "id",
+				ARN:              "arn",
+				Name:             "name",
+				Comment:          "comment",
+				Status:           "status",
+				ItemCount:        1,
+				TotalSizeInBytes: 2,
+				Created:          t1,
+				LastModified:     t2,
+				FailureReason:    "",
+				ETag:             "eTag",
+			},
+			expect: "ID,ARN,Name,Comment,Status,ItemCount,TotalSizeInBytes,Created,LastModified,FailureReason,ETag\n" +
+				"id,arn,name,comment,status,1,2,2021-01-01T00:00:00Z,2021-01-01T01:00:00Z,,eTag\n",
+		},
+		{
+			name: "ok: ItemListDiff",
+			data: &types.ItemListDiff{
+				Add: []types.ItemDiff{
+					{Before: nil, After: &types.Item{Key: "key1", Value: "value1"}},
+				},
+				Update: []types.ItemDiff{
+					{Before: &types.Item{Key: "key2", Value: "value2"}, After: &types.Item{Key: "key2", Value: "v2"}},
+				},
+				Delete: []types.ItemDiff{
+					{Before: &types.Item{Key: "key3", Value: "value3"}, After: nil},
+				},
+			},
+			expect: "Change,Key,Before Value,After Value\nadd,key1,,value1\nupdate,key2,value2,v2\ndelete,key3,value3,\n",
+		},
+		{
+			name:   "ok: ItemListDiff (empty)",
+			data:   &types.ItemListDiff{},
+			expect: "Change,Key,Before Value,After Value\n",
+		},
+		{
+			name: "ok: ManifestPlan",
+			data: &types.ManifestPlan{Stores: []types.ManifestStorePlan{
+				{
+					Name:   "kvs-1",
+					Create: true,
+					Diff:   &types.DiffDocument{Added: []types.Item{{Key: "key1", Value: "value1"}}},
+				},
+				{
+					Name:    "kvs-2",
+					Comment: &types.UpdatedComment{Before: "old", After: "new"},
+					Diff: &types.DiffDocument{
+						Updated: []types.UpdatedItem{{Key: "key2", Before: "value2", After: "v2"}},
+						Deleted: []types.Item{{Key: "key3", Value: "value3"}},
+					},
+				},
+				{Name: "kvs-3", Diff: &types.DiffDocument{}},
+			}},
+			expect: "Store,Change,Key,Before Value,After Value\nkvs-1,create,,,\nkvs-1,add,key1,,value1\nkvs-2,comment,,old,new\nkvs-2,update,key2,value2,v2\nkvs-2,delete,key3,value3,\n",
+		},
+		{
+			name:    "invalid data",
+			data:    make(chan int),
+			wantErr: true,
+		},
+	}
+
+	out := new(bytes.Buffer)
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			tt.Cleanup(func() {
+				out.Truncate(0)
+			})
+
+			asst := assert.New(tt)
+
+			err := output.RenderAsCsv(c.data, out)
+			if c.wantErr {
+				asst.Error(err)
+				asst.Empty(out.String())
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
+
+func Test_RenderAsTsv(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    any
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "ok: types.ItemList",
+			data: types.NewItemList([]types.Item{
+				{Key: "key1", Value: "value, with comma"},
+				{Key: "key2", Value: "tab\there"},
+			}),
+			expect: "Key\tValue\nkey1\tvalue, with comma\nkey2\t\"tab\there\"\n",
+		},
+		{
+			name: "ok: ItemListDiff",
+			data: &types.ItemListDiff{
+				Update: []types.ItemDiff{
+					{Before: &types.Item{Key: "key2", Value: "value2"}, After: &types.Item{Key: "key2", Value: "v2"}},
+				},
+			},
+			expect: "Change\tKey\tBefore Value\tAfter Value\nupdate\tkey2\tvalue2\tv2\n",
+		},
+		{
+			name:    "invalid data",
+			data:    make(chan int),
+			wantErr: true,
+		},
+	}
+
+	out := new(bytes.Buffer)
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			tt.Cleanup(func() {
+				out.Truncate(0)
+			})
+
+			asst := assert.New(tt)
+
+			err := output.RenderAsTsv(c.data, out)
+			if c.wantErr {
+				asst.Error(err)
+				asst.Empty(out.String())
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
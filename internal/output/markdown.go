@@ -0,0 +1,41 @@
+package output
+
+import (
+	"io"
+
+	"github.com/jedib0t/go-pretty/table"
+)
+
+// RenderAsMarkdown renders the data as Markdown tables, e.g. to paste the diff of sync into a pull request comment.
+// Pipes in the values are escaped, and newlines are replaced with <br/>.
+func RenderAsMarkdown(data any, o io.Writer) error {
+	tables, err := toTables(data)
+	if err != nil {
+		return err
+	}
+
+	for _, tableData := range tables {
+		for _, desc := range tableData.Descriptions {
+			_, _ = o.Write([]byte(desc + "\n"))
+		}
+
+		if len(tableData.Rows) == 0 {
+			continue
+		}
+
+		if len(tableData.Descriptions) > 0 {
+			// a table cannot follow a paragraph without a blank line
+			_, _ = o.Write([]byte("\n"))
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(o)
+		for _, header := range tableData.Headers {
+			t.AppendHeader(header)
+		}
+		t.AppendRows(tableData.Rows)
+		t.RenderMarkdown()
+	}
+
+	return nil
+}
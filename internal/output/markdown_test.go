@@ -0,0 +1,94 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderAsMarkdown(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    any
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "ok: types.KVS",
+			data: &types.KVS{Id: "id", Name: "name", Comment: "comment", Status: "status", ARN: "arn"},
+			expect: `| ID | Name | Comment | Status | ARN |
+| --- | --- | --- | --- | --- |
+| id | name | comment | status | arn |
+`,
+		},
+		{
+			name: "ok: types.ItemList with pipes and newlines",
+			data: types.NewItemList([]types.Item{
+				{Key: "key1", Value: "a|b"},
+				{Key: "key2", Value: "line1\nline2"},
+			}),
+			expect: `| Key | Value |
+| --- | --- |
+| key1 | a\|b |
+| key2 | line1<br/>line2 |
+`,
+		},
+		{
+			name: "ok: ItemListDiff",
+			data: &types.ItemListDiff{
+				Add: []types.ItemDiff{
+					{Before: nil, After: &types.Item{Key: "key1", Value: "value1"}},
+				},
+				Update: []types.ItemDiff{},
+				Delete: []types.ItemDiff{
+					{Before: &types.Item{Key: "key3", Value: "value3"}, After: nil},
+				},
+			},
+			expect: `
+[ADDED] Following items will be added.
+
+| # | Key | Value |
+| ---:| --- | --- |
+| 1 | key1 | value1 |
+
+[UPDATED] No items will be updated.
+
+[DELETED] Following items will be deleted.
+
+| # | Key | Value |
+| ---:| --- | --- |
+| 1 | key3 | value3 |
+`,
+		},
+		{
+			name:    "invalid data",
+			data:    make(chan int),
+			wantErr: true,
+		},
+	}
+
+	out := new(bytes.Buffer)
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			tt.Cleanup(func() {
+				out.Truncate(0)
+			})
+
+			asst := assert.New(tt)
+
+			err := output.RenderAsMarkdown(c.data, out)
+			if c.wantErr {
+				asst.Error(err)
+				asst.Empty(out.String())
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/jmespath/go-jmespath"
+)
+
+// QueryResult is the result of a JMESPath query to the JSON form of the data.
+// Its Value is one of the types that encoding/json decodes JSON into.
+type QueryResult struct {
+	Value any
+}
+
+func (r *QueryResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Value)
+}
+
+// Query applies the JMESPath expression to the JSON form of the data,
+// that is the same as the output of RenderAsJson.
+func Query(data any, expression string) (*QueryResult, error) {
+	j, err := json.Marshal(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	if err := json.Unmarshal(j, &v); err != nil {
+		return nil, err
+	}
+
+	result, err := jmespath.Search(expression, v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply the query %q: %w", expression, err)
+	}
+
+	return &QueryResult{Value: result}, nil
+}
+
+// queryResultTables returns tables of the query result.
+// An object is a row with its keys as the header, and a list of objects is rows with all of their keys as the header.
+// Any other value is rendered without a header, a row for each element of a list.
+func queryResultTables(v any) []Table {
+	switch v := v.(type) {
+	case nil:
+		return []Table{}
+
+	case map[string]any:
+		keys := sortedKeys(v)
+		return []Table{{
+			Headers: []table.Row{toRow(keys)},
+			Rows:    []table.Row{objectRow(v, keys)},
+		}}
+
+	case []any:
+		objects := []map[string]any{}
+		for _, e := range v {
+			if o, ok := e.(map[string]any); ok {
+				objects = append(objects, o)
+			}
+		}
+
+		if len(v) > 0 && len(objects) == len(v) {
+			keys := sortedKeys(objects...)
+			t := Table{Headers: []table.Row{toRow(keys)}}
+			for _, o := range objects {
+				t.Rows = append(t.Rows, objectRow(o, keys))
+			}
+			return []Table{t}
+		}
+
+		t := Table{}
+		for _, e := range v {
+			t.Rows = append(t.Rows, table.Row{cell(e)})
+		}
+		return []Table{t}
+
+	default:
+		return []Table{{Rows: []table.Row{{cell(v)}}}}
+	}
+}
+
+func sortedKeys(objects ...map[string]any) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, o := range objects {
+		for k := range o {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func toRow(keys []string) table.Row {
+	row := table.Row{}
+	for _, k := range keys {
+		row = append(row, k)
+	}
+	return row
+}
+
+func objectRow(o map[string]any, keys []string) table.Row {
+	row := table.Row{}
+	for _, k := range keys {
+		row = append(row, cell(o[k]))
+	}
+	return row
+}
+
+// cell returns the value as a string in a cell.
+// Objects and lists are rendered as JSON.
+func cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
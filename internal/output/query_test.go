@@ -0,0 +1,132 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Query(t *testing.T) {
+	kvsList := &types.KVSList{
+		{Id: "id1", Name: "name1", Comment: "comment1", Status: "READY", ARN: "arn1"},
+		{Id: "id2", Name: "name2", Comment: "", Status: "PROVISIONING", ARN: "arn2"},
+	}
+	itemList := types.NewItemList([]types.Item{
+		{Key: "flags/a", Value: "on"},
+		{Key: "redirects/a", Value: "/new"},
+	})
+
+	cases := []struct {
+		name       string
+		data       any
+		query      string
+		outputType output.OutputType
+		expect     string
+		wantErr    bool
+	}{
+		{
+			name:       "scalar as json",
+			data:       &types.KVS{Id: "id", Name: "name", ARN: "arn"},
+			query:      "arn",
+			outputType: output.OutputTypeJson,
+			expect:     "\"arn\"\n",
+		},
+		{
+			name:       "scalar as tsv",
+			data:       &types.KVS{Id: "id", Name: "name", ARN: "arn"},
+			query:      "arn",
+			outputType: output.OutputTypeTsv,
+			expect:     "arn\n",
+		},
+		{
+			name:       "list of scalars as csv",
+			data:       kvsList,
+			query:      "[].arn",
+			outputType: output.OutputTypeCsv,
+			expect:     "arn1\narn2\n",
+		},
+		{
+			name:       "filtered list of objects as table",
+			data:       kvsList,
+			query:      "[?status=='READY'].{name: name, arn: arn}",
+			outputType: output.OutputTypeTable,
+			expect: `+------+-------+
+| ARN  | NAME  |
++------+-------+
+| arn1 | name1 |
++------+-------+
+`,
+		},
+		{
+			name:       "object as markdown",
+			data:       &types.KVSSimple{ItemCount: 2, TotalSize: 1024},
+			query:      "{count: itemCount, size: totalSize}",
+			outputType: output.OutputTypeMarkdown,
+			expect: `| count | size |
+| --- | --- |
+| 2 | 1024 |
+`,
+		},
+		{
+			name:       "items filtered by key as yaml",
+			data:       itemList,
+			query:      "Data[?starts_with(key, 'flags/')]",
+			outputType: output.OutputTypeYaml,
+			expect: `---
+- key: flags/a
+  value: "on"
+`,
+		},
+		{
+			name:       "nested value in a cell",
+			data:       itemList,
+			query:      "{first: Data[0]}",
+			outputType: output.OutputTypeCsv,
+			expect:     "first\n\"{\"\"key\"\":\"\"flags/a\"\",\"\"value\"\":\"\"on\"\"}\"\n",
+		},
+		{
+			name:       "no match",
+			data:       kvsList,
+			query:      "[?status=='FAILED'] | [0]",
+			outputType: output.OutputTypeTable,
+			expect:     "",
+		},
+		{
+			name:    "invalid expression",
+			data:    kvsList,
+			query:   "[?status==",
+			wantErr: true,
+		},
+		{
+			name:    "invalid data",
+			data:    make(chan int),
+			query:   "arn",
+			wantErr: true,
+		},
+	}
+
+	out := new(bytes.Buffer)
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			tt.Cleanup(func() {
+				out.Truncate(0)
+			})
+
+			asst := assert.New(tt)
+
+			result, err := output.Query(c.data, c.query)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.NoError(output.Render(result, c.outputType, out))
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
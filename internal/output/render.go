@@ -1,21 +1,51 @@
 package output
 
-import "io"
+import (
+	"errors"
+	"fmt"
+	"io"
+)
 
 type OutputType string
 
 const (
-	OutputTypeJson  OutputType = "json"
-	OutputTypeTable OutputType = "table"
+	OutputTypeJson     OutputType = "json"
+	OutputTypeTable    OutputType = "table"
+	OutputTypeYaml     OutputType = "yaml"
+	OutputTypeCsv      OutputType = "csv"
+	OutputTypeTsv      OutputType = "tsv"
+	OutputTypeMarkdown OutputType = "markdown"
+	OutputTypeTemplate OutputType = "template"
 )
 
+// IsStructured reports whether the output type is for tools to parse,
+// that any other message must not be mixed in.
+func (t OutputType) IsStructured() bool {
+	switch t {
+	case OutputTypeJson, OutputTypeYaml, OutputTypeCsv, OutputTypeTsv, OutputTypeTemplate:
+		return true
+	default:
+		return false
+	}
+}
+
 func Render(data any, outputType OutputType, w io.Writer) error {
 	switch outputType {
 	case OutputTypeJson:
 		return RenderAsJson(data, w)
+	case OutputTypeYaml:
+		return RenderAsYaml(data, w)
+	case OutputTypeCsv:
+		return RenderAsCsv(data, w)
+	case OutputTypeTsv:
+		return RenderAsTsv(data, w)
+	case OutputTypeMarkdown:
+		return RenderAsMarkdown(data, w)
+	case OutputTypeTemplate:
+		return errors.New("template output needs a template, use RenderAsTemplate")
 	case OutputTypeTable:
 		return RenderAsTable(data, w)
 	default:
-		return RenderAsTable(data, w)
+		return fmt.Errorf("unsupported output type: %s", outputType)
 	}
 }
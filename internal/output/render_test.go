@@ -9,6 +9,28 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func Test_OutputType_IsStructured(t *testing.T) {
+	cases := []struct {
+		outputType output.OutputType
+		expect     bool
+	}{
+		{outputType: output.OutputTypeTable, expect: false},
+		{outputType: output.OutputTypeMarkdown, expect: false},
+		{outputType: output.OutputTypeJson, expect: true},
+		{outputType: output.OutputTypeYaml, expect: true},
+		{outputType: output.OutputTypeCsv, expect: true},
+		{outputType: output.OutputTypeTsv, expect: true},
+		{outputType: output.OutputTypeTemplate, expect: true},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.outputType), func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, c.outputType.IsStructured())
+		})
+	}
+}
+
 func Test_Render(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -42,16 +64,50 @@ func Test_Render(t *testing.T) {
 `,
 		},
 		{
-			name:       "ok: types.Item, invalid OutputType (render as table)",
+			name:       "ok: types.KVS, OutputTypeYaml",
+			data:       &types.KVS{Id: "id", Name: "name", Comment: "comment", Status: "status", ARN: "arn"},
+			outputType: output.OutputTypeYaml,
+			expect: `---
+id: id
+name: name
+comment: comment
+status: status
+arn: arn
+`,
+		},
+		{
+			name:       "ok: types.Item, OutputTypeCsv",
 			data:       &types.Item{Key: "key", Value: "value"},
-			outputType: output.OutputType("invalid"),
-			expect: `+-----+-------+
-| KEY | VALUE |
-+-----+-------+
+			outputType: output.OutputTypeCsv,
+			expect:     "Key,Value\nkey,value\n",
+		},
+		{
+			name:       "ok: types.Item, OutputTypeTsv",
+			data:       &types.Item{Key: "key", Value: "value"},
+			outputType: output.OutputTypeTsv,
+			expect:     "Key\tValue\nkey\tvalue\n",
+		},
+		{
+			name:       "ok: types.Item, OutputTypeMarkdown",
+			data:       &types.Item{Key: "key", Value: "value"},
+			outputType: output.OutputTypeMarkdown,
+			expect: `| Key | Value |
+| --- | --- |
 | key | value |
-+-----+-------+
 `,
 		},
+		{
+			name:       "error: OutputTypeTemplate without a template",
+			data:       &types.Item{Key: "key", Value: "value"},
+			outputType: output.OutputTypeTemplate,
+			wantErr:    true,
+		},
+		{
+			name:       "error: invalid OutputType",
+			data:       &types.Item{Key: "key", Value: "value"},
+			outputType: output.OutputType("invalid"),
+			wantErr:    true,
+		},
 	}
 
 	out := new(bytes.Buffer)
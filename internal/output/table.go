@@ -40,6 +40,26 @@ func toTables(data any) ([]Table, error) {
 		}
 		return []Table{tableData}, nil
 
+	case *types.FunctionAssociationList:
+		// List of CloudFront Functions associated with the Key Value Store
+		tableData.Headers = []table.Row{{"Name", "Stage", "Status", "ARN"}}
+		for _, fa := range *data {
+			tableData.Rows = append(
+				tableData.Rows,
+				table.Row{fa.Name, fa.Stage, fa.Status, fa.ARN})
+		}
+		return []Table{tableData}, nil
+
+	case *types.SnapshotSummaryList:
+		// List of snapshots of the Key Value Store
+		tableData.Headers = []table.Row{{"ID", "Created At", "Size", "Location"}}
+		for _, s := range *data {
+			tableData.Rows = append(
+				tableData.Rows,
+				table.Row{s.ID, s.CreatedAt, s.Size, s.Location})
+		}
+		return []Table{tableData}, nil
+
 	case *types.ItemList:
 		// List of Items in the Key Value Store
 		tableData.Headers = []table.Row{{"Key", "Value"}}
@@ -162,11 +182,70 @@ func toTables(data any) ([]Table, error) {
 
 		return tables, nil
 
+	case *types.ManifestPlan:
+		// Combined plan of the Key Value Stores in the manifest
+		return manifestPlanTables(data), nil
+
+	case *QueryResult:
+		return queryResultTables(data.Value), nil
+
 	default:
 		return nil, fmt.Errorf("failed to render as table due to unexpected type: %s", reflect.TypeOf(data).String())
 	}
 }
 
+// manifestPlanTables renders the plan of each key value store as a table of its item changes.
+func manifestPlanTables(plan *types.ManifestPlan) []Table {
+	tables := []Table{}
+	for _, s := range plan.Stores {
+		t := Table{}
+
+		switch {
+		case s.Create:
+			t.Descriptions = append(t.Descriptions, fmt.Sprintf("\n[%s] The key value store will be created.", s.Name))
+		case s.IsEmpty():
+			t.Descriptions = append(t.Descriptions, fmt.Sprintf("\n[%s] No changes.", s.Name))
+		default:
+			t.Descriptions = append(t.Descriptions, fmt.Sprintf("\n[%s] The key value store will be updated.", s.Name))
+		}
+		if s.Comment != nil {
+			t.Descriptions = append(t.Descriptions, fmt.Sprintf("Comment: %q -> %q", s.Comment.Before, s.Comment.After))
+		}
+
+		t.Headers = []table.Row{{"#", "Change", "Key", "Before Value", "After Value"}}
+		for _, r := range manifestPlanItemRecords(s.Diff) {
+			t.Rows = append(t.Rows, table.Row{len(t.Rows) + 1, r[0], r[1], r[2], r[3]})
+		}
+		if len(t.Rows) == 0 && !s.IsEmpty() {
+			t.Descriptions = append(t.Descriptions, "No items will be changed.")
+		}
+
+		tables = append(tables, t)
+	}
+
+	return tables
+}
+
+// manifestPlanItemRecords returns the item changes in the diff as records of change, key, before value and after value.
+func manifestPlanItemRecords(diff *types.DiffDocument) [][]string {
+	records := [][]string{}
+	if diff == nil {
+		return records
+	}
+
+	for _, item := range diff.Added {
+		records = append(records, []string{"add", item.Key, "", item.Value})
+	}
+	for _, item := range diff.Updated {
+		records = append(records, []string{"update", item.Key, item.Before, item.After})
+	}
+	for _, item := range diff.Deleted {
+		records = append(records, []string{"delete", item.Key, item.Value, ""})
+	}
+
+	return records
+}
+
 func RenderAsTable(data any, o io.Writer) error {
 	tables, err := toTables(data)
 	if err != nil {
@@ -179,7 +258,7 @@ func RenderAsTable(data any, o io.Writer) error {
 			_, _ = o.Write([]byte(desc + "\n"))
 		}
 
-		if len(tableData.Headers) == 0 || len(tableData.Rows) == 0 {
+		if len(tableData.Rows) == 0 {
 			continue
 		}
 
@@ -43,6 +43,62 @@ func Test_RenderAsTable(t *testing.T) {
 | id1 | name1 | comment1 | status1 | arn1 |
 | id2 | name2 | comment2 | status2 | arn2 |
 +-----+-------+----------+---------+------+
+`,
+		},
+		{
+			name: "ok: types.FunctionAssociationList",
+			data: &types.FunctionAssociationList{
+				{Name: "fn", Stage: "DEVELOPMENT", Status: "UNPUBLISHED", ARN: "arn1"},
+				{Name: "fn", Stage: "LIVE", Status: "DEPLOYED", ARN: "arn1"},
+			},
+			expect: `+------+-------------+-------------+------+
+| NAME | STAGE       | STATUS      | ARN  |
++------+-------------+-------------+------+
+| fn   | DEVELOPMENT | UNPUBLISHED | arn1 |
+| fn   | LIVE        | DEPLOYED    | arn1 |
++------+-------------+-------------+------+
+`,
+		},
+		{
+			name: "ok: types.SnapshotSummaryList",
+			data: &types.SnapshotSummaryList{
+				{ID: "20240101T000000.000Z", CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Size: 10, Location: "dir/kvs/20240101T000000.000Z.json"},
+			},
+			expect: `+----------------------+-------------------------------+------+-----------------------------------+
+| ID                   | CREATED AT                    | SIZE | LOCATION                          |
++----------------------+-------------------------------+------+-----------------------------------+
+| 20240101T000000.000Z | 2024-01-01 00:00:00 +0000 UTC |   10 | dir/kvs/20240101T000000.000Z.json |
++----------------------+-------------------------------+------+-----------------------------------+
+`,
+		},
+		{
+			name: "ok: types.ManifestPlan",
+			data: &types.ManifestPlan{Stores: []types.ManifestStorePlan{
+				{
+					Name:   "kvs-1",
+					Create: true,
+					Diff:   &types.DiffDocument{Added: []types.Item{{Key: "key1", Value: "value1"}}},
+				},
+				{
+					Name:    "kvs-2",
+					Comment: &types.UpdatedComment{Before: "old", After: "new"},
+					Diff:    &types.DiffDocument{},
+				},
+				{Name: "kvs-3", Diff: &types.DiffDocument{}},
+			}},
+			expect: `
+[kvs-1] The key value store will be created.
++---+--------+------+--------------+-------------+
+| # | CHANGE | KEY  | BEFORE VALUE | AFTER VALUE |
++---+--------+------+--------------+-------------+
+| 1 | add    | key1 |              | value1      |
++---+--------+------+--------------+-------------+
+
+[kvs-2] The key value store will be updated.
+Comment: "old" -> "new"
+No items will be changed.
+
+[kvs-3] No changes.
 `,
 		},
 		{
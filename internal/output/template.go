@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// templateFuncs are the functions available in the template output, in addition to the built-in ones.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	},
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"trunc": func(n int, s string) string {
+		if n < 0 || utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n])
+	},
+}
+
+// NewTemplate parses the text of a Go text/template for the template output.
+func NewTemplate(text string) (*template.Template, error) {
+	return template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// RenderAsTemplate executes the template with the data.
+// The data is the same struct as the other output formats receive,
+// or the value of the QueryResult if a query has been applied.
+func RenderAsTemplate(data any, tmpl *template.Template, o io.Writer) error {
+	if r, ok := data.(*QueryResult); ok {
+		data = r.Value
+	}
+
+	return tmpl.Execute(o, data)
+}
@@ -0,0 +1,91 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderAsTemplate(t *testing.T) {
+	itemList := types.NewItemList([]types.Item{
+		{Key: "/old", Value: "/new"},
+		{Key: "flag", Value: `{"enabled": true}`},
+	})
+
+	cases := []struct {
+		name       string
+		data       any
+		text       string
+		expect     string
+		wantErr    bool
+		wantRunErr bool
+	}{
+		{
+			name:   "key=value",
+			data:   itemList,
+			text:   `{{range .Data}}{{.Key}}={{.Value}}{{"\n"}}{{end}}`,
+			expect: "/old=/new\nflag={\"enabled\": true}\n",
+		},
+		{
+			name:   "nginx map",
+			data:   itemList,
+			text:   `{{range .Data}}{{printf "%s %s;\n" .Key .Value}}{{end}}`,
+			expect: "/old /new;\nflag {\"enabled\": true};\n",
+		},
+		{
+			name:   "functions",
+			data:   &types.KVS{Name: "name", Status: "ready", ARN: "arn:aws:cloudfront::123456789012:key-value-store/id"},
+			text:   `{{.Name | upper}} {{.Status | lower}} {{trunc 7 .ARN}} {{replace "ready" "READY" .Status}} {{json .Name}}`,
+			expect: `NAME ready arn:aws READY "name"`,
+		},
+		{
+			name:   "query result",
+			data:   &output.QueryResult{Value: []any{"a", "b"}},
+			text:   `{{range .}}{{.}},{{end}}`,
+			expect: "a,b,",
+		},
+		{
+			name:    "error: invalid template",
+			data:    itemList,
+			text:    `{{range .Data}`,
+			wantErr: true,
+		},
+		{
+			name:       "error: missing field",
+			data:       itemList,
+			text:       `{{.Items}}`,
+			wantRunErr: true,
+		},
+	}
+
+	out := new(bytes.Buffer)
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			tt.Cleanup(func() {
+				out.Truncate(0)
+			})
+
+			asst := assert.New(tt)
+
+			tmpl, err := output.NewTemplate(c.text)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+			asst.NoError(err)
+
+			err = output.RenderAsTemplate(c.data, tmpl, out)
+			if c.wantRunErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const yamlOutputIndent = 2
+
+// RenderAsYaml renders the data as a YAML document.
+// The data is converted through JSON, so that the field names and their order are the same as RenderAsJson.
+// The document starts with "---", so that the output of several renders is a valid YAML stream.
+func RenderAsYaml(data any, o io.Writer) error {
+	j, err := json.Marshal(&data)
+	if err != nil {
+		return err
+	}
+
+	// JSON is valid YAML, so it can be decoded into a node keeping the order of the fields.
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(j, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	_, _ = o.Write([]byte("---\n"))
+	enc := yaml.NewEncoder(o)
+	enc.SetIndent(yamlOutputIndent)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// resetStyle clears the flow and quoted styles of the JSON,
+// so that the node is encoded in the block style with quotes only where needed.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str" {
+		// A string is quoted in the same way as a Go string,
+		// e.g. "on" is quoted because YAML 1.1 parsers read it as a boolean.
+		s := yaml.Node{}
+		if err := s.Encode(node.Value); err == nil {
+			node.Style = s.Style
+		}
+	}
+	for _, n := range node.Content {
+		resetStyle(n)
+	}
+}
@@ -0,0 +1,85 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/michimani/cfkvs/internal/output"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RenderAsYaml(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    any
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			data: types.KVS{
+				Id:      "123",
+				Name:    "name",
+				Comment: "line1\nline2",
+				Status:  "",
+				ARN:     "arn",
+			},
+			expect: `---
+id: "123"
+name: name
+comment: |-
+  line1
+  line2
+status: ""
+arn: arn
+`,
+		},
+		{
+			name: "ok: diff document",
+			data: types.NewItemList([]types.Item{{Key: "k1", Value: "true"}}).
+				Diff(types.NewItemList([]types.Item{{Key: "k2", Value: "on"}}), true).Document(),
+			expect: `---
+added:
+  - key: k2
+    value: "on"
+updated: []
+deleted:
+  - key: k1
+    value: "true"
+summary:
+  added: 1
+  updated: 0
+  deleted: 1
+  total: 2
+`,
+		},
+		{
+			name:    "invalid data",
+			data:    make(chan int),
+			wantErr: true,
+		},
+	}
+
+	out := new(bytes.Buffer)
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			tt.Cleanup(func() {
+				out.Truncate(0)
+			})
+
+			asst := assert.New(tt)
+
+			err := output.RenderAsYaml(c.data, out)
+			if c.wantErr {
+				asst.Error(err)
+				asst.Empty(out.String())
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, out.String())
+		})
+	}
+}
@@ -3,23 +3,19 @@ package libs
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
 	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
 	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
 	"github.com/michimani/cfkvs/types"
 )
 
-func NewCloudFrontClient(ctx context.Context) (*cloudfront.Client, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	c := cloudfront.NewFromConfig(cfg)
-	return c, nil
+func NewCloudFrontClient(cfg aws.Config) *cloudfront.Client {
+	return cloudfront.NewFromConfig(cfg)
 }
 
 type CloudFrontClient interface {
@@ -27,27 +23,109 @@ type CloudFrontClient interface {
 	CreateKeyValueStore(ctx context.Context, params *cloudfront.CreateKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.CreateKeyValueStoreOutput, error)
 	DeleteKeyValueStore(ctx context.Context, params *cloudfront.DeleteKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DeleteKeyValueStoreOutput, error)
 	DescribeKeyValueStore(ctx context.Context, params *cloudfront.DescribeKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeKeyValueStoreOutput, error)
+	UpdateKeyValueStore(ctx context.Context, params *cloudfront.UpdateKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.UpdateKeyValueStoreOutput, error)
+	ListFunctions(ctx context.Context, params *cloudfront.ListFunctionsInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListFunctionsOutput, error)
+	DescribeFunction(ctx context.Context, params *cloudfront.DescribeFunctionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeFunctionOutput, error)
 }
 
+// GetKeyValueStoreArn returns the ARN of the key value store specified by its name, ID or ARN.
+// An ARN is returned as it is without calling the API.
 func GetKeyValueStoreArn(ctx context.Context, c CloudFrontClient, kvsName string) (string, error) {
-	input := &cloudfront.ListKeyValueStoresInput{}
-	out, err := c.ListKeyValueStores(ctx, input)
+	if isKeyValueStoreARN(kvsName) {
+		return kvsName, nil
+	}
+
+	kvs, err := findKeyValueStore(ctx, c, kvsName)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(kvs.ARN), nil
+}
+
+// GetKeyValueStoreName returns the name of the key value store specified by its name, ID or ARN,
+// for the CloudFront APIs that take only a name.
+// A name is returned as it is without calling the API.
+func GetKeyValueStoreName(ctx context.Context, c CloudFrontClient, kvsName string) (string, error) {
+	if !isKeyValueStoreARN(kvsName) && !kvsIDPattern.MatchString(kvsName) {
+		return kvsName, nil
+	}
+
+	kvs, err := findKeyValueStore(ctx, c, kvsName)
 	if err != nil {
 		return "", err
 	}
 
-	for _, kvs := range out.KeyValueStoreList.Items {
-		if *kvs.Name == kvsName {
-			return *kvs.ARN, nil
+	return aws.ToString(kvs.Name), nil
+}
+
+var kvsIDPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+func isKeyValueStoreARN(s string) bool {
+	return strings.HasPrefix(s, "arn:")
+}
+
+// findKeyValueStore finds the key value store that has the name, ID or ARN in all pages of ListKeyValueStores.
+func findKeyValueStore(ctx context.Context, c CloudFrontClient, kvsName string) (*cfTypes.KeyValueStore, error) {
+	out, err := ListKeyValueStore(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if out.KeyValueStoreList != nil {
+		// A name takes precedence over an ID, because an ID is also a valid name.
+		var byID *cfTypes.KeyValueStore
+		for i, kvs := range out.KeyValueStoreList.Items {
+			switch kvsName {
+			case aws.ToString(kvs.Name), aws.ToString(kvs.ARN):
+				return &out.KeyValueStoreList.Items[i], nil
+			case aws.ToString(kvs.Id):
+				byID = &out.KeyValueStoreList.Items[i]
+			}
+		}
+		if byID != nil {
+			return byID, nil
 		}
 	}
 
-	return "", fmt.Errorf("the key value store '%s' is not found", kvsName)
+	return nil, fmt.Errorf("the key value store '%s' is not found", kvsName)
 }
 
+// ListKeyValueStore lists all key value stores in the account.
+// The items of all pages are merged into the KeyValueStoreList of the first page.
 func ListKeyValueStore(ctx context.Context, c CloudFrontClient) (*cloudfront.ListKeyValueStoresOutput, error) {
-	input := &cloudfront.ListKeyValueStoresInput{}
-	return c.ListKeyValueStores(ctx, input)
+	var list *cloudfront.ListKeyValueStoresOutput
+	var marker *string
+	for {
+		input := &cloudfront.ListKeyValueStoresInput{
+			Marker: marker,
+		}
+		out, err := c.ListKeyValueStores(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			return nil, fmt.Errorf("cloudfront.ListKeyValueStoresOutput is nil")
+		}
+
+		if list == nil {
+			list = out
+		} else if out.KeyValueStoreList != nil {
+			list.KeyValueStoreList.Items = append(list.KeyValueStoreList.Items, out.KeyValueStoreList.Items...)
+		}
+
+		if out.KeyValueStoreList == nil || out.KeyValueStoreList.NextMarker == nil {
+			break
+		}
+		marker = out.KeyValueStoreList.NextMarker
+	}
+
+	if list.KeyValueStoreList != nil {
+		list.KeyValueStoreList.NextMarker = nil
+		list.KeyValueStoreList.Quantity = aws.Int32(int32(len(list.KeyValueStoreList.Items)))
+	}
+
+	return list, nil
 }
 
 type KVSImportSource interface {
@@ -84,15 +162,50 @@ func CreateKeyValueStore(ctx context.Context, c CloudFrontClient, name string, c
 	return c.CreateKeyValueStore(ctx, input)
 }
 
+// KeyValueStoreInUseError is returned by DeleteKeyValueStore when functions are associated with the key value store,
+// because CloudFront rejects the delete.
+type KeyValueStoreInUseError struct {
+	Name      string
+	Functions []cfTypes.FunctionSummary
+}
+
+func (e *KeyValueStoreInUseError) Error() string {
+	functions := make([]string, len(e.Functions))
+	for i, fn := range e.Functions {
+		stage := cfTypes.FunctionStage("")
+		if fn.FunctionMetadata != nil {
+			stage = fn.FunctionMetadata.Stage
+		}
+		functions[i] = fmt.Sprintf("%s (%s)", aws.ToString(fn.Name), stage)
+	}
+
+	return fmt.Sprintf("the key value store '%s' cannot be deleted while it is associated with functions: %s. Remove the associations from the functions first", e.Name, strings.Join(functions, ", "))
+}
+
+// DeleteKeyValueStore deletes the key value store.
+// It fails with KeyValueStoreInUseError without calling the delete API if any function is associated with the key value store.
 func DeleteKeyValueStore(ctx context.Context, c CloudFrontClient, kvsName string) error {
-	eTag, err := getETagByCloudFront(ctx, c, kvsName)
+	dOut, err := c.DescribeKeyValueStore(ctx, &cloudfront.DescribeKeyValueStoreInput{
+		Name: aws.String(kvsName),
+	})
+	if err != nil {
+		return err
+	}
+	if dOut == nil || dOut.KeyValueStore == nil {
+		return fmt.Errorf("cloudfront.DescribeKeyValueStoreOutput.KeyValueStore is nil")
+	}
+
+	functions, err := ListFunctionAssociations(ctx, c, aws.ToString(dOut.KeyValueStore.ARN))
 	if err != nil {
 		return err
 	}
+	if len(functions) > 0 {
+		return &KeyValueStoreInUseError{Name: kvsName, Functions: functions}
+	}
 
 	input := &cloudfront.DeleteKeyValueStoreInput{
 		Name:    aws.String(kvsName),
-		IfMatch: eTag,
+		IfMatch: dOut.ETag,
 	}
 
 	if out, err := c.DeleteKeyValueStore(ctx, input); err != nil {
@@ -104,6 +217,30 @@ func DeleteKeyValueStore(ctx context.Context, c CloudFrontClient, kvsName string
 	return nil
 }
 
+// UpdateKeyValueStore updates the comment of the key value store.
+func UpdateKeyValueStore(ctx context.Context, c CloudFrontClient, kvsName, comment string) (*cloudfront.UpdateKeyValueStoreOutput, error) {
+	eTag, err := getETagByCloudFront(ctx, c, kvsName)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &cloudfront.UpdateKeyValueStoreInput{
+		Name:    aws.String(kvsName),
+		Comment: aws.String(comment),
+		IfMatch: eTag,
+	}
+
+	out, err := c.UpdateKeyValueStore(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, fmt.Errorf("cloudfront.UpdateKeyValueStoreOutput is nil")
+	}
+
+	return out, nil
+}
+
 // DescribeKeyValueStore describes the key value store.
 // The response of this function is a merge of CloudFront:DescribeKeyValueStore and CloudFrontKeyValueStore:DescribeKeyValueStore.
 func DescribeKeyValueStore(ctx context.Context, cfc CloudFrontClient, kvsc CloudFrontKeyValueStoreClient, kvsName string) (*types.KeyValueStoreFull, error) {
@@ -147,6 +284,174 @@ func DescribeKeyValueStore(ctx context.Context, cfc CloudFrontClient, kvsc Cloud
 	}, nil
 }
 
+// ListFunctions lists all functions in the account.
+// A function that has been published is listed for each of the DEVELOPMENT and LIVE stages.
+func ListFunctions(ctx context.Context, c CloudFrontClient) ([]cfTypes.FunctionSummary, error) {
+	functions := []cfTypes.FunctionSummary{}
+	var marker *string
+	for {
+		out, err := c.ListFunctions(ctx, &cloudfront.ListFunctionsInput{
+			Marker: marker,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			return nil, fmt.Errorf("cloudfront.ListFunctionsOutput is nil")
+		}
+		if out.FunctionList == nil {
+			break
+		}
+
+		functions = append(functions, out.FunctionList.Items...)
+
+		if out.FunctionList.NextMarker == nil {
+			break
+		}
+		marker = out.FunctionList.NextMarker
+	}
+
+	return functions, nil
+}
+
+// ListFunctionAssociations returns the functions that are associated with the key value store, a summary for each stage.
+// A function is described by CloudFront:DescribeFunction if its summary in the list does not have the associations.
+func ListFunctionAssociations(ctx context.Context, c CloudFrontClient, kvsARN string) ([]cfTypes.FunctionSummary, error) {
+	functions, err := ListFunctions(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	associated := []cfTypes.FunctionSummary{}
+	for _, fn := range functions {
+		if fn.FunctionConfig == nil || fn.FunctionConfig.KeyValueStoreAssociations == nil {
+			input := &cloudfront.DescribeFunctionInput{Name: fn.Name}
+			if fn.FunctionMetadata != nil {
+				input.Stage = fn.FunctionMetadata.Stage
+			}
+			out, err := c.DescribeFunction(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			if out == nil || out.FunctionSummary == nil {
+				return nil, fmt.Errorf("cloudfront.DescribeFunctionOutput.FunctionSummary is nil")
+			}
+			fn = *out.FunctionSummary
+		}
+
+		if isAssociated(&fn, kvsARN) {
+			associated = append(associated, fn)
+		}
+	}
+
+	return associated, nil
+}
+
+func isAssociated(fn *cfTypes.FunctionSummary, kvsARN string) bool {
+	if fn.FunctionConfig == nil || fn.FunctionConfig.KeyValueStoreAssociations == nil {
+		return false
+	}
+
+	for _, a := range fn.FunctionConfig.KeyValueStoreAssociations.Items {
+		if aws.ToString(a.KeyValueStoreARN) == kvsARN {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeyValueStoreStatusFailed is the status of a key value store whose import has failed.
+const KeyValueStoreStatusFailed = "FAILED"
+
+type WaitKeyValueStoreOptions struct {
+	// MinDelay is the delay before the second poll. The delay doubles after each poll up to MaxDelay.
+	MinDelay time.Duration
+	MaxDelay time.Duration
+
+	// OnPoll is called with the status of the key value store after each poll.
+	OnPoll func(status string)
+}
+
+// KeyValueStoreFailedError is returned by WaitKeyValueStore when the key value store becomes FAILED.
+type KeyValueStoreFailedError struct {
+	Name          string
+	FailureReason string
+}
+
+func (e *KeyValueStoreFailedError) Error() string {
+	reason := e.FailureReason
+	if reason == "" {
+		reason = "unknown reason"
+	}
+
+	return fmt.Sprintf("the key value store '%s' has failed: %s", e.Name, reason)
+}
+
+// WaitKeyValueStore polls CloudFront:DescribeKeyValueStore until the key value store has the status.
+// The polling stops with an error when the key value store becomes FAILED, or the context is done.
+func WaitKeyValueStore(ctx context.Context, cfc CloudFrontClient, kvsc CloudFrontKeyValueStoreClient, kvsName, status string, optFns ...func(*WaitKeyValueStoreOptions)) (*cloudfront.DescribeKeyValueStoreOutput, error) {
+	opts := WaitKeyValueStoreOptions{
+		MinDelay: 2 * time.Second,
+		MaxDelay: 30 * time.Second,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	delay := opts.MinDelay
+	for {
+		out, err := cfc.DescribeKeyValueStore(ctx, &cloudfront.DescribeKeyValueStoreInput{
+			Name: aws.String(kvsName),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if out == nil || out.KeyValueStore == nil {
+			return nil, fmt.Errorf("cloudfront.DescribeKeyValueStoreOutput.KeyValueStore is nil")
+		}
+
+		current := aws.ToString(out.KeyValueStore.Status)
+		if opts.OnPoll != nil {
+			opts.OnPoll(current)
+		}
+
+		if current == status {
+			return out, nil
+		}
+		if current == KeyValueStoreStatusFailed {
+			return nil, &KeyValueStoreFailedError{
+				Name:          kvsName,
+				FailureReason: getFailureReason(ctx, kvsc, aws.ToString(out.KeyValueStore.ARN)),
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for the key value store '%s' to be %s (current status: %s): %w", kvsName, status, current, ctx.Err())
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > opts.MaxDelay {
+			delay = opts.MaxDelay
+		}
+	}
+}
+
+// getFailureReason returns the reason why the key value store has failed.
+// It returns an empty string if the reason cannot be got, since it is only used in an error message.
+func getFailureReason(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN string) string {
+	out, err := c.DescribeKeyValueStore(ctx, &kvs.DescribeKeyValueStoreInput{
+		KvsARN: aws.String(kvsARN),
+	})
+	if err != nil || out == nil {
+		return ""
+	}
+
+	return aws.ToString(out.FailureReason)
+}
+
 func getETagByCloudFront(ctx context.Context, c CloudFrontClient, name string) (*string, error) {
 	input := &cloudfront.DescribeKeyValueStoreInput{
 		Name: aws.String(name),
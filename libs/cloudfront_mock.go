@@ -81,6 +81,26 @@ func (mr *MockCloudFrontClientMockRecorder) DeleteKeyValueStore(ctx, params any,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteKeyValueStore", reflect.TypeOf((*MockCloudFrontClient)(nil).DeleteKeyValueStore), varargs...)
 }
 
+// DescribeFunction mocks base method.
+func (m *MockCloudFrontClient) DescribeFunction(ctx context.Context, params *cloudfront.DescribeFunctionInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeFunctionOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DescribeFunction", varargs...)
+	ret0, _ := ret[0].(*cloudfront.DescribeFunctionOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeFunction indicates an expected call of DescribeFunction.
+func (mr *MockCloudFrontClientMockRecorder) DescribeFunction(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeFunction", reflect.TypeOf((*MockCloudFrontClient)(nil).DescribeFunction), varargs...)
+}
+
 // DescribeKeyValueStore mocks base method.
 func (m *MockCloudFrontClient) DescribeKeyValueStore(ctx context.Context, params *cloudfront.DescribeKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeKeyValueStoreOutput, error) {
 	m.ctrl.T.Helper()
@@ -101,6 +121,26 @@ func (mr *MockCloudFrontClientMockRecorder) DescribeKeyValueStore(ctx, params an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeKeyValueStore", reflect.TypeOf((*MockCloudFrontClient)(nil).DescribeKeyValueStore), varargs...)
 }
 
+// ListFunctions mocks base method.
+func (m *MockCloudFrontClient) ListFunctions(ctx context.Context, params *cloudfront.ListFunctionsInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListFunctionsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListFunctions", varargs...)
+	ret0, _ := ret[0].(*cloudfront.ListFunctionsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFunctions indicates an expected call of ListFunctions.
+func (mr *MockCloudFrontClientMockRecorder) ListFunctions(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFunctions", reflect.TypeOf((*MockCloudFrontClient)(nil).ListFunctions), varargs...)
+}
+
 // ListKeyValueStores mocks base method.
 func (m *MockCloudFrontClient) ListKeyValueStores(ctx context.Context, params *cloudfront.ListKeyValueStoresInput, optFns ...func(*cloudfront.Options)) (*cloudfront.ListKeyValueStoresOutput, error) {
 	m.ctrl.T.Helper()
@@ -121,6 +161,26 @@ func (mr *MockCloudFrontClientMockRecorder) ListKeyValueStores(ctx, params any,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListKeyValueStores", reflect.TypeOf((*MockCloudFrontClient)(nil).ListKeyValueStores), varargs...)
 }
 
+// UpdateKeyValueStore mocks base method.
+func (m *MockCloudFrontClient) UpdateKeyValueStore(ctx context.Context, params *cloudfront.UpdateKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.UpdateKeyValueStoreOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, params}
+	for _, a := range optFns {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateKeyValueStore", varargs...)
+	ret0, _ := ret[0].(*cloudfront.UpdateKeyValueStoreOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateKeyValueStore indicates an expected call of UpdateKeyValueStore.
+func (mr *MockCloudFrontClientMockRecorder) UpdateKeyValueStore(ctx, params any, optFns ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, params}, optFns...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateKeyValueStore", reflect.TypeOf((*MockCloudFrontClient)(nil).UpdateKeyValueStore), varargs...)
+}
+
 // MockKVSImportSource is a mock of KVSImportSource interface.
 type MockKVSImportSource struct {
 	ctrl     *gomock.Controller
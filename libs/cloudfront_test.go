@@ -3,6 +3,7 @@ package libs_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -80,99 +81,190 @@ func Test_getETagByCloudFront(t *testing.T) {
 }
 
 func Test_NewCloudFrontClient(t *testing.T) {
+	asst := assert.New(t)
+
+	c := libs.NewCloudFrontClient(aws.Config{Region: "ap-northeast-1"})
+	asst.NotNil(c)
+}
+
+// kvsPages returns ListKeyValueStores outputs that have the key value stores two per page.
+func kvsPages(names ...string) []*cloudfront.ListKeyValueStoresOutput {
+	pages := []*cloudfront.ListKeyValueStoresOutput{}
+	for i := 0; i < len(names); i += 2 {
+		items := []cfTypes.KeyValueStore{}
+		for _, n := range names[i:min(i+2, len(names))] {
+			items = append(items, cfTypes.KeyValueStore{
+				Name: aws.String(n),
+				Id:   aws.String("id_" + n),
+				ARN:  aws.String("arn:aws:cloudfront::123456789012:key-value-store/id_" + n),
+			})
+		}
+		list := &cfTypes.KeyValueStoreList{Items: items}
+		if i+2 < len(names) {
+			list.NextMarker = aws.String(fmt.Sprintf("marker_%d", i+2))
+		}
+		pages = append(pages, &cloudfront.ListKeyValueStoresOutput{KeyValueStoreList: list})
+	}
+	return pages
+}
+
+// expectListKeyValueStores sets the expectations of ListKeyValueStores that returns the pages in order.
+func expectListKeyValueStores(m *libs.MockCloudFrontClient, pages []*cloudfront.ListKeyValueStoresOutput, err error) {
+	if err != nil {
+		m.EXPECT().ListKeyValueStores(gomock.Any(), &cloudfront.ListKeyValueStoresInput{}).Return(nil, err)
+		return
+	}
+
+	calls := []any{}
+	var marker *string
+	for _, p := range pages {
+		calls = append(calls, m.EXPECT().
+			ListKeyValueStores(gomock.Any(), &cloudfront.ListKeyValueStoresInput{Marker: marker}).
+			Return(p, nil))
+		if p.KeyValueStoreList != nil {
+			marker = p.KeyValueStoreList.NextMarker
+		}
+	}
+	gomock.InOrder(calls...)
+}
+
+func Test_GetKeyValueStoreArn(t *testing.T) {
 	cases := []struct {
-		name    string
-		ctx     context.Context
-		envs    map[string]string
-		wantErr bool
+		name     string
+		pages    []*cloudfront.ListKeyValueStoresOutput
+		listErr  error
+		noListed bool
+		kvsName  string
+		wantArn  string
+		wantErr  bool
 	}{
 		{
-			name: "success",
-			ctx:  context.Background(),
-			envs: map[string]string{
-				"AWS_ACCESS_KEY_ID":     "dummy_key_id",
-				"AWS_SECRET_ACCESS_KEY": "dummy_secret_key",
-				"AWS_SESSION_TOKEN":     "dummy_session_token",
-				"AWS_REGION":            "ap-northeast-1",
-			},
+			name:    "success",
+			pages:   kvsPages("kvs_name"),
+			kvsName: "kvs_name",
+			wantArn: "arn:aws:cloudfront::123456789012:key-value-store/id_kvs_name",
 			wantErr: false,
 		},
 		{
-			name: "failed to load default config",
-			ctx:  context.Background(),
-			envs: map[string]string{
-				"AWS_PROFILE": "invalid",
-				"AWS_REGION":  "ap-northeast-1",
-			},
+			name:    "success: in the last page",
+			pages:   kvsPages("kvs1", "kvs2", "kvs3", "kvs4", "kvs5"),
+			kvsName: "kvs5",
+			wantArn: "arn:aws:cloudfront::123456789012:key-value-store/id_kvs5",
+			wantErr: false,
+		},
+		{
+			name:    "success: ID",
+			pages:   kvsPages("kvs1", "kvs2", "kvs3"),
+			kvsName: "id_kvs3",
+			wantArn: "arn:aws:cloudfront::123456789012:key-value-store/id_kvs3",
+			wantErr: false,
+		},
+		{
+			name:    "success: name takes precedence over ID",
+			pages:   kvsPages("id_kvs2", "kvs2"),
+			kvsName: "id_kvs2",
+			wantArn: "arn:aws:cloudfront::123456789012:key-value-store/id_id_kvs2",
+			wantErr: false,
+		},
+		{
+			name:     "success: ARN without calling the API",
+			noListed: true,
+			kvsName:  "arn:aws:cloudfront::123456789012:key-value-store/id_kvs1",
+			wantArn:  "arn:aws:cloudfront::123456789012:key-value-store/id_kvs1",
+			wantErr:  false,
+		},
+		{
+			name:    "not found",
+			pages:   kvsPages("kvs1", "kvs2", "kvs3"),
+			kvsName: "kvs_name",
+			wantErr: true,
+		},
+		{
+			name:    "failed to list key value stores",
+			listErr: errors.New("failed to list key value stores"),
+			kvsName: "kvs_name",
+			wantArn: "",
 			wantErr: true,
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(tt *testing.T) {
-			for k, v := range c.envs {
-				tt.Setenv(k, v)
-			}
-
 			asst := assert.New(tt)
+			ctx := context.Background()
 
-			sc, err := libs.NewCloudFrontClient(c.ctx)
+			ctrl := gomock.NewController(tt)
+			m := libs.NewMockCloudFrontClient(ctrl)
+			if !c.noListed {
+				expectListKeyValueStores(m, c.pages, c.listErr)
+			}
+
+			arn, err := libs.GetKeyValueStoreArn(ctx, m, c.kvsName)
 			if c.wantErr {
 				asst.Error(err)
-				asst.Nil(sc)
+				asst.Empty(arn)
 				return
 			}
 
 			asst.NoError(err)
-			asst.NotNil(sc)
+			asst.Equal(c.wantArn, arn)
 		})
 	}
 }
 
-func Test_GetKeyValueStoreArn(t *testing.T) {
+func Test_GetKeyValueStoreName(t *testing.T) {
 	cases := []struct {
-		name      string
-		clientOut struct {
-			ListKeyValueStoresOutput *cloudfront.ListKeyValueStoresOutput
-			Error                    error
-		}
-		kvsName string
-		wantArn string
-		wantErr bool
+		name     string
+		pages    []*cloudfront.ListKeyValueStoresOutput
+		listErr  error
+		noListed bool
+		kvsName  string
+		wantName string
+		wantErr  bool
 	}{
 		{
-			name: "success",
-			clientOut: struct {
-				ListKeyValueStoresOutput *cloudfront.ListKeyValueStoresOutput
-				Error                    error
-			}{
-				ListKeyValueStoresOutput: &cloudfront.ListKeyValueStoresOutput{
+			name:     "success: name without calling the API",
+			noListed: true,
+			kvsName:  "kvs_name",
+			wantName: "kvs_name",
+		},
+		{
+			name:     "success: ARN",
+			pages:    kvsPages("kvs1", "kvs2", "kvs3"),
+			kvsName:  "arn:aws:cloudfront::123456789012:key-value-store/id_kvs3",
+			wantName: "kvs3",
+		},
+		{
+			name: "success: ID",
+			pages: []*cloudfront.ListKeyValueStoresOutput{
+				{
 					KeyValueStoreList: &cfTypes.KeyValueStoreList{
 						Items: []cfTypes.KeyValueStore{
 							{
-								Name: aws.String("kvs_name"),
-								ARN:  aws.String("arn"),
+								Name: aws.String("kvs1"),
+								Id:   aws.String("a1b2c3d4-5678-90ab-cdef-EXAMPLE11111"),
+							},
+							{
+								Name: aws.String("kvs2"),
+								Id:   aws.String("a1b2c3d4-5678-90ab-cdef-000000000002"),
 							},
 						},
 					},
 				},
-				Error: nil,
 			},
-			kvsName: "kvs_name",
-			wantArn: "arn",
-			wantErr: false,
+			kvsName:  "a1b2c3d4-5678-90ab-cdef-000000000002",
+			wantName: "kvs2",
 		},
 		{
-			name: "failed to list key value stores",
-			clientOut: struct {
-				ListKeyValueStoresOutput *cloudfront.ListKeyValueStoresOutput
-				Error                    error
-			}{
-				ListKeyValueStoresOutput: nil,
-				Error:                    errors.New("failed to list key value stores"),
-			},
-			kvsName: "kvs_name",
-			wantArn: "",
+			name:    "not found",
+			pages:   kvsPages("kvs1"),
+			kvsName: "arn:aws:cloudfront::123456789012:key-value-store/id_kvs3",
+			wantErr: true,
+		},
+		{
+			name:    "failed to list key value stores",
+			listErr: errors.New("failed to list key value stores"),
+			kvsName: "arn:aws:cloudfront::123456789012:key-value-store/id_kvs3",
 			wantErr: true,
 		},
 	}
@@ -184,19 +276,19 @@ func Test_GetKeyValueStoreArn(t *testing.T) {
 
 			ctrl := gomock.NewController(tt)
 			m := libs.NewMockCloudFrontClient(ctrl)
-			m.EXPECT().
-				ListKeyValueStores(ctx, &cloudfront.ListKeyValueStoresInput{}).
-				Return(c.clientOut.ListKeyValueStoresOutput, c.clientOut.Error)
+			if !c.noListed {
+				expectListKeyValueStores(m, c.pages, c.listErr)
+			}
 
-			arn, err := libs.GetKeyValueStoreArn(ctx, m, c.kvsName)
+			name, err := libs.GetKeyValueStoreName(ctx, m, c.kvsName)
 			if c.wantErr {
 				asst.Error(err)
-				asst.Empty(arn)
+				asst.Empty(name)
 				return
 			}
 
 			asst.NoError(err)
-			asst.Equal(c.wantArn, arn)
+			asst.Equal(c.wantName, name)
 		})
 	}
 }
@@ -204,32 +296,25 @@ func Test_GetKeyValueStoreArn(t *testing.T) {
 func Test_ListKeyValueStore(t *testing.T) {
 	cases := []struct {
 		name      string
-		clientOut struct {
-			ListKeyValueStoresOutput *cloudfront.ListKeyValueStoresOutput
-			Error                    error
-		}
-		wantErr bool
+		pages     []*cloudfront.ListKeyValueStoresOutput
+		listErr   error
+		wantNames []string
+		wantErr   bool
 	}{
 		{
-			name: "success",
-			clientOut: struct {
-				ListKeyValueStoresOutput *cloudfront.ListKeyValueStoresOutput
-				Error                    error
-			}{
-				ListKeyValueStoresOutput: &cloudfront.ListKeyValueStoresOutput{},
-				Error:                    nil,
-			},
+			name:    "success",
+			pages:   []*cloudfront.ListKeyValueStoresOutput{{}},
 			wantErr: false,
 		},
 		{
-			name: "failed to list key value stores",
-			clientOut: struct {
-				ListKeyValueStoresOutput *cloudfront.ListKeyValueStoresOutput
-				Error                    error
-			}{
-				ListKeyValueStoresOutput: nil,
-				Error:                    errors.New("failed to list key value stores"),
-			},
+			name:      "success: multiple pages",
+			pages:     kvsPages("kvs1", "kvs2", "kvs3", "kvs4", "kvs5"),
+			wantNames: []string{"kvs1", "kvs2", "kvs3", "kvs4", "kvs5"},
+			wantErr:   false,
+		},
+		{
+			name:    "failed to list key value stores",
+			listErr: errors.New("failed to list key value stores"),
 			wantErr: true,
 		},
 	}
@@ -241,9 +326,7 @@ func Test_ListKeyValueStore(t *testing.T) {
 
 			ctrl := gomock.NewController(tt)
 			m := libs.NewMockCloudFrontClient(ctrl)
-			m.EXPECT().
-				ListKeyValueStores(ctx, &cloudfront.ListKeyValueStoresInput{}).
-				Return(c.clientOut.ListKeyValueStoresOutput, c.clientOut.Error)
+			expectListKeyValueStores(m, c.pages, c.listErr)
 
 			out, err := libs.ListKeyValueStore(ctx, m)
 			if c.wantErr {
@@ -254,10 +337,206 @@ func Test_ListKeyValueStore(t *testing.T) {
 
 			asst.NoError(err)
 			asst.NotNil(out)
+			if c.wantNames == nil {
+				return
+			}
+
+			names := []string{}
+			for _, k := range out.KeyValueStoreList.Items {
+				names = append(names, aws.ToString(k.Name))
+			}
+			asst.Equal(c.wantNames, names)
+			asst.Nil(out.KeyValueStoreList.NextMarker)
+			asst.Equal(int32(len(c.wantNames)), aws.ToInt32(out.KeyValueStoreList.Quantity))
 		})
 	}
 }
 
+func Test_ListFunctions(t *testing.T) {
+	cases := []struct {
+		name      string
+		pages     []*cloudfront.ListFunctionsOutput
+		listErr   error
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "success: no function list",
+			pages:     []*cloudfront.ListFunctionsOutput{{}},
+			wantNames: []string{},
+		},
+		{
+			name: "success: multiple pages",
+			pages: []*cloudfront.ListFunctionsOutput{
+				{FunctionList: &cfTypes.FunctionList{
+					Items:      []cfTypes.FunctionSummary{{Name: aws.String("fn1")}, {Name: aws.String("fn2")}},
+					NextMarker: aws.String("fn3"),
+				}},
+				{FunctionList: &cfTypes.FunctionList{
+					Items: []cfTypes.FunctionSummary{{Name: aws.String("fn3")}},
+				}},
+			},
+			wantNames: []string{"fn1", "fn2", "fn3"},
+		},
+		{
+			name:    "error: failed to list functions",
+			pages:   []*cloudfront.ListFunctionsOutput{nil},
+			listErr: errors.New("failed to list functions"),
+			wantErr: true,
+		},
+		{
+			name:    "error: cloudfront.ListFunctionsOutput is nil",
+			pages:   []*cloudfront.ListFunctionsOutput{nil},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctrl := gomock.NewController(tt)
+			m := libs.NewMockCloudFrontClient(ctrl)
+			var prev *string
+			for _, page := range c.pages {
+				m.EXPECT().ListFunctions(gomock.Any(), &cloudfront.ListFunctionsInput{Marker: prev}).Return(page, c.listErr)
+				if page != nil && page.FunctionList != nil {
+					prev = page.FunctionList.NextMarker
+				}
+			}
+
+			out, err := libs.ListFunctions(context.Background(), m)
+			if c.wantErr {
+				asst.Error(err)
+				asst.Nil(out)
+				return
+			}
+
+			asst.NoError(err)
+			names := []string{}
+			for _, fn := range out {
+				names = append(names, aws.ToString(fn.Name))
+			}
+			asst.Equal(c.wantNames, names)
+		})
+	}
+}
+
+func Test_ListFunctionAssociations(t *testing.T) {
+	associations := func(arns ...string) *cfTypes.FunctionConfig {
+		items := []cfTypes.KeyValueStoreAssociation{}
+		for _, arn := range arns {
+			items = append(items, cfTypes.KeyValueStoreAssociation{KeyValueStoreARN: aws.String(arn)})
+		}
+		return &cfTypes.FunctionConfig{
+			KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{Quantity: aws.Int32(int32(len(items))), Items: items},
+		}
+	}
+	summary := func(name string, stage cfTypes.FunctionStage, config *cfTypes.FunctionConfig) cfTypes.FunctionSummary {
+		return cfTypes.FunctionSummary{
+			Name:             aws.String(name),
+			FunctionConfig:   config,
+			FunctionMetadata: &cfTypes.FunctionMetadata{Stage: stage},
+		}
+	}
+
+	described := summary("fn1", cfTypes.FunctionStageLive, associations("kvs_arn"))
+
+	cases := []struct {
+		name        string
+		functions   []cfTypes.FunctionSummary
+		describe    map[string]*cloudfront.DescribeFunctionOutput
+		describeErr error
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name: "ok",
+			functions: []cfTypes.FunctionSummary{
+				summary("fn1", cfTypes.FunctionStageDevelopment, associations("kvs_arn")),
+				summary("fn1", cfTypes.FunctionStageLive, associations()),
+				summary("fn2", cfTypes.FunctionStageLive, associations("other_arn", "kvs_arn")),
+				summary("fn3", cfTypes.FunctionStageLive, &cfTypes.FunctionConfig{KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{Quantity: aws.Int32(0)}}),
+			},
+			want: []string{"fn1 DEVELOPMENT", "fn2 LIVE"},
+		},
+		{
+			name: "ok: describe a function without associations in the list",
+			functions: []cfTypes.FunctionSummary{
+				summary("fn1", cfTypes.FunctionStageLive, nil),
+			},
+			describe: map[string]*cloudfront.DescribeFunctionOutput{
+				"fn1": {FunctionSummary: &described},
+			},
+			want: []string{"fn1 LIVE"},
+		},
+		{
+			name:      "ok: no function",
+			functions: []cfTypes.FunctionSummary{},
+			want:      []string{},
+		},
+		{
+			name: "error: failed to describe a function",
+			functions: []cfTypes.FunctionSummary{
+				summary("fn1", cfTypes.FunctionStageLive, nil),
+			},
+			describe:    map[string]*cloudfront.DescribeFunctionOutput{"fn1": nil},
+			describeErr: errors.New("failed to describe function"),
+			wantErr:     true,
+		},
+		{
+			name: "error: cloudfront.DescribeFunctionOutput is nil",
+			functions: []cfTypes.FunctionSummary{
+				summary("fn1", cfTypes.FunctionStageLive, nil),
+			},
+			describe: map[string]*cloudfront.DescribeFunctionOutput{"fn1": nil},
+			wantErr:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctrl := gomock.NewController(tt)
+			m := libs.NewMockCloudFrontClient(ctrl)
+			m.EXPECT().ListFunctions(gomock.Any(), gomock.Any()).Return(
+				&cloudfront.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{Items: c.functions}}, nil)
+			for name, out := range c.describe {
+				m.EXPECT().DescribeFunction(gomock.Any(), &cloudfront.DescribeFunctionInput{Name: aws.String(name), Stage: cfTypes.FunctionStageLive}).Return(out, c.describeErr)
+			}
+
+			out, err := libs.ListFunctionAssociations(context.Background(), m, "kvs_arn")
+			if c.wantErr {
+				asst.Error(err)
+				asst.Nil(out)
+				return
+			}
+
+			asst.NoError(err)
+			got := []string{}
+			for _, fn := range out {
+				got = append(got, aws.ToString(fn.Name)+" "+string(fn.FunctionMetadata.Stage))
+			}
+			asst.Equal(c.want, got)
+		})
+	}
+}
+
+func Test_KeyValueStoreInUseError_Error(t *testing.T) {
+	asst := assert.New(t)
+
+	err := &libs.KeyValueStoreInUseError{
+		Name: "kvs_name",
+		Functions: []cfTypes.FunctionSummary{
+			{Name: aws.String("fn1"), FunctionMetadata: &cfTypes.FunctionMetadata{Stage: cfTypes.FunctionStageDevelopment}},
+			{Name: aws.String("fn1"), FunctionMetadata: &cfTypes.FunctionMetadata{Stage: cfTypes.FunctionStageLive}},
+		},
+	}
+
+	asst.Equal("the key value store 'kvs_name' cannot be deleted while it is associated with functions: fn1 (DEVELOPMENT), fn1 (LIVE). Remove the associations from the functions first", err.Error())
+}
+
 func Test_KVSImportSourceS3_ARN(t *testing.T) {
 	cases := []struct {
 		name string
@@ -445,8 +724,10 @@ func Test_DeleteKeyValueStore(t *testing.T) {
 			Error                      error
 			DescribeKeyValueStoreError error
 		}
-		kvsName string
-		wantErr bool
+		functions          []cfTypes.FunctionSummary
+		listFunctionsError error
+		kvsName            string
+		wantErr            bool
 	}{
 		{
 			name: "ok",
@@ -504,6 +785,29 @@ func Test_DeleteKeyValueStore(t *testing.T) {
 			kvsName: "kvs_name",
 			wantErr: true,
 		},
+		{
+			name: "error: associated with functions",
+			functions: []cfTypes.FunctionSummary{
+				{
+					Name:             aws.String("fn"),
+					FunctionMetadata: &cfTypes.FunctionMetadata{Stage: cfTypes.FunctionStageLive},
+					FunctionConfig: &cfTypes.FunctionConfig{
+						KeyValueStoreAssociations: &cfTypes.KeyValueStoreAssociations{
+							Quantity: aws.Int32(1),
+							Items:    []cfTypes.KeyValueStoreAssociation{{KeyValueStoreARN: aws.String("kvs_arn")}},
+						},
+					},
+				},
+			},
+			kvsName: "kvs_name",
+			wantErr: true,
+		},
+		{
+			name:               "error: failed to list functions",
+			listFunctionsError: errors.New("failed to list functions"),
+			kvsName:            "kvs_name",
+			wantErr:            true,
+		},
 		{
 			name: "error: cloudfront.DeleteKeyValueStoreOutput is nil",
 			clientOut: struct {
@@ -529,10 +833,21 @@ func Test_DeleteKeyValueStore(t *testing.T) {
 			m.EXPECT().
 				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
 				Return(
-					&cloudfront.DescribeKeyValueStoreOutput{ETag: aws.String("etag")},
+					&cloudfront.DescribeKeyValueStoreOutput{
+						ETag:          aws.String("etag"),
+						KeyValueStore: &cfTypes.KeyValueStore{ARN: aws.String("kvs_arn")},
+					},
 					c.clientOut.DescribeKeyValueStoreError)
 
 			if c.clientOut.DescribeKeyValueStoreError == nil {
+				m.EXPECT().
+					ListFunctions(gomock.Any(), gomock.Any()).
+					Return(
+						&cloudfront.ListFunctionsOutput{FunctionList: &cfTypes.FunctionList{Items: c.functions}},
+						c.listFunctionsError)
+			}
+
+			if c.clientOut.DescribeKeyValueStoreError == nil && c.listFunctionsError == nil && len(c.functions) == 0 {
 				m.EXPECT().
 					DeleteKeyValueStore(gomock.Any(), gomock.Any()).
 					Return(c.clientOut.DeleteKeyValueStoreOutput, c.clientOut.Error)
@@ -549,6 +864,73 @@ func Test_DeleteKeyValueStore(t *testing.T) {
 	}
 }
 
+func Test_UpdateKeyValueStore(t *testing.T) {
+	cases := []struct {
+		name        string
+		describeErr error
+		updateOut   *cloudfront.UpdateKeyValueStoreOutput
+		updateErr   error
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			updateOut: &cloudfront.UpdateKeyValueStoreOutput{
+				KeyValueStore: &cfTypes.KeyValueStore{
+					Name:    aws.String("kvs_name"),
+					Comment: aws.String("new comment"),
+				},
+			},
+		},
+		{
+			name:        "error: failed to get etag",
+			describeErr: errors.New("failed to describe key value store"),
+			wantErr:     true,
+		},
+		{
+			name:      "error: failed to update key value store",
+			updateErr: errors.New("failed to update key value store"),
+			wantErr:   true,
+		},
+		{
+			name:    "error: cloudfront.UpdateKeyValueStoreOutput is nil",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctrl := gomock.NewController(tt)
+			m := libs.NewMockCloudFrontClient(ctrl)
+			m.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				Return(&cloudfront.DescribeKeyValueStoreOutput{ETag: aws.String("etag")}, c.describeErr)
+
+			if c.describeErr == nil {
+				m.EXPECT().
+					UpdateKeyValueStore(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, params *cloudfront.UpdateKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.UpdateKeyValueStoreOutput, error) {
+						asst.Equal("kvs_name", aws.ToString(params.Name))
+						asst.Equal("new comment", aws.ToString(params.Comment))
+						asst.Equal("etag", aws.ToString(params.IfMatch))
+						return c.updateOut, c.updateErr
+					})
+			}
+
+			out, err := libs.UpdateKeyValueStore(context.TODO(), m, "kvs_name", "new comment")
+			if c.wantErr {
+				asst.Error(err)
+				asst.Nil(out)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.updateOut, out)
+		})
+	}
+}
+
 func Test_DescribeKeyValueStore(t *testing.T) {
 	now := time.Now()
 	past := now.AddDate(0, -1, 0)
@@ -764,3 +1146,121 @@ func Test_DescribeKeyValueStore(t *testing.T) {
 		})
 	}
 }
+
+func Test_WaitKeyValueStore(t *testing.T) {
+	describeOut := func(status string) *cloudfront.DescribeKeyValueStoreOutput {
+		return &cloudfront.DescribeKeyValueStoreOutput{
+			KeyValueStore: &cfTypes.KeyValueStore{
+				Name:   aws.String("kvs_name"),
+				ARN:    aws.String("arn-for-kvs_name"),
+				Status: aws.String(status),
+			},
+		}
+	}
+
+	cases := []struct {
+		name          string
+		statuses      []string
+		cfcErr        error
+		kvscOut       *kvs.DescribeKeyValueStoreOutput
+		kvscErr       error
+		timeout       time.Duration
+		expectPolls   []string
+		wantError     bool
+		expectFailure string
+	}{
+		{
+			name:        "ready at once",
+			statuses:    []string{"READY"},
+			expectPolls: []string{"READY"},
+		},
+		{
+			name:        "ready after provisioning",
+			statuses:    []string{"PROVISIONING", "PROVISIONING", "READY"},
+			expectPolls: []string{"PROVISIONING", "PROVISIONING", "READY"},
+		},
+		{
+			name:          "failed",
+			statuses:      []string{"PROVISIONING", "FAILED"},
+			kvscOut:       &kvs.DescribeKeyValueStoreOutput{FailureReason: aws.String("the import source is invalid")},
+			expectPolls:   []string{"PROVISIONING", "FAILED"},
+			wantError:     true,
+			expectFailure: "the import source is invalid",
+		},
+		{
+			name:          "failed without reason",
+			statuses:      []string{"FAILED"},
+			kvscErr:       errors.New("failed to describe key value store"),
+			expectPolls:   []string{"FAILED"},
+			wantError:     true,
+			expectFailure: "",
+		},
+		{
+			name:      "failed to CloudFront:DescribeKeyValueStore",
+			cfcErr:    errors.New("failed to describe key value store"),
+			wantError: true,
+		},
+		{
+			name:      "timeout",
+			statuses:  []string{"PROVISIONING"},
+			timeout:   50 * time.Millisecond,
+			wantError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			cfc := libs.NewMockCloudFrontClient(ctrl)
+			polls := 0
+			cfc.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, params *cloudfront.DescribeKeyValueStoreInput, optFns ...func(*cloudfront.Options)) (*cloudfront.DescribeKeyValueStoreOutput, error) {
+					asst.Equal("kvs_name", aws.ToString(params.Name))
+					if c.cfcErr != nil {
+						return nil, c.cfcErr
+					}
+					status := c.statuses[min(polls, len(c.statuses)-1)]
+					polls++
+					return describeOut(status), nil
+				}).AnyTimes()
+
+			kvsc := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			if c.kvscOut != nil || c.kvscErr != nil {
+				kvsc.EXPECT().DescribeKeyValueStore(gomock.Any(), gomock.Any()).Return(c.kvscOut, c.kvscErr)
+			}
+
+			ctx := context.Background()
+			if c.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.timeout)
+				defer cancel()
+			}
+
+			got := []string{}
+			out, err := libs.WaitKeyValueStore(ctx, cfc, kvsc, "kvs_name", "READY", func(o *libs.WaitKeyValueStoreOptions) {
+				o.MinDelay = time.Millisecond
+				o.MaxDelay = 5 * time.Millisecond
+				o.OnPoll = func(status string) { got = append(got, status) }
+			})
+			if c.expectPolls != nil {
+				asst.Equal(c.expectPolls, got)
+			}
+			if c.wantError {
+				asst.Error(err)
+				asst.Nil(out)
+
+				var failed *libs.KeyValueStoreFailedError
+				if c.expectFailure != "" || c.kvscErr != nil {
+					asst.True(errors.As(err, &failed))
+					asst.Equal(c.expectFailure, failed.FailureReason)
+				}
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal("READY", aws.ToString(out.KeyValueStore.Status))
+		})
+	}
+}
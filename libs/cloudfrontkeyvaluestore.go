@@ -2,22 +2,45 @@ package libs
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
 	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/aws/smithy-go"
+	smithyendpoints "github.com/aws/smithy-go/endpoints"
 	"github.com/michimani/cfkvs/types"
 )
 
-func NewCloudFrontKeyValueStoreClient(ctx context.Context) (*kvs.Client, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+func NewCloudFrontKeyValueStoreClient(cfg aws.Config) *kvs.Client {
+	return kvs.NewFromConfig(cfg, func(o *kvs.Options) {
+		o.EndpointResolverV2 = &kvsEndpointResolver{EndpointResolverV2: kvs.NewDefaultEndpointResolverV2()}
+	})
+}
+
+// kvsEndpointResolver resolves the endpoint of the CloudFront KeyValueStore API.
+// The default resolver prepends the account ID of the key value store to the host of a custom endpoint,
+// but a custom endpoint such as a local emulator must be used as it is.
+type kvsEndpointResolver struct {
+	kvs.EndpointResolverV2
+}
+
+func (r *kvsEndpointResolver) ResolveEndpoint(ctx context.Context, params kvs.EndpointParameters) (smithyendpoints.Endpoint, error) {
+	endpoint, err := r.EndpointResolverV2.ResolveEndpoint(ctx, params)
+	if err != nil || params.Endpoint == nil {
+		return endpoint, err
+	}
+
+	u, err := url.Parse(*params.Endpoint)
 	if err != nil {
-		return nil, err
+		return endpoint, fmt.Errorf("failed to parse the endpoint: %w", err)
 	}
+	endpoint.URI = *u
 
-	c := kvs.NewFromConfig(cfg)
-	return c, nil
+	return endpoint, nil
 }
 
 type CloudFrontKeyValueStoreClient interface {
@@ -67,65 +90,339 @@ func GetItem(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key s
 	return c.GetKey(ctx, input)
 }
 
-func PutItem(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key, value string) (*kvs.PutKeyOutput, error) {
-	eTag, err := getETagByCloudFrontKeyValueStore(ctx, c, kvsARN)
-	if err != nil {
-		return nil, err
+type WriteItemOptions struct {
+	// Retries is the number of retries when the write conflicts with another write to the key value store.
+	// Each retry re-reads the ETag of the key value store.
+	Retries int
+	// RetryDelay is the delay before the first retry. It doubles on each retry.
+	RetryDelay time.Duration
+	// OnRetry is called before each retry with the 1-origin index of the retry and the error of the conflicted write.
+	OnRetry func(retry int, err error)
+
+	// IfNotExists, IfValue and IfETag are the conditions of the write. They are checked before each try,
+	// and the write is pinned to the ETag that the conditions are checked against.
+	// IfNotExists requires that the key does not exist.
+	IfNotExists bool
+	// IfValue requires that the current value of the key is this value.
+	IfValue *string
+	// IfETag requires that the current ETag of the key value store is this ETag.
+	IfETag *string
+}
+
+// PreconditionFailedError is returned by a conditional write when its condition is not satisfied.
+type PreconditionFailedError struct {
+	Key    string
+	Reason string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("precondition failed for the key '%s': %s", e.Key, e.Reason)
+}
+
+// IsConflictError reports whether the error is caused by a write with a stale ETag,
+// that is, another write to the key value store happened after the ETag was read.
+func IsConflictError(err error) bool {
+	var conflict *kvsTypes.ConflictException
+	if errors.As(err, &conflict) {
+		return true
 	}
 
-	input := &kvs.PutKeyInput{
-		IfMatch: eTag,
-		KvsARN:  aws.String(kvsARN),
-		Key:     aws.String(key),
-		Value:   aws.String(value),
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "ConflictException", "PreconditionFailed":
+			return true
+		}
 	}
-	return c.PutKey(ctx, input)
+
+	return false
 }
 
-func DeleteItem(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key string) (*kvs.DeleteKeyOutput, error) {
-	eTag, err := getETagByCloudFrontKeyValueStore(ctx, c, kvsARN)
-	if err != nil {
-		return nil, err
+// withConflictRetry calls write with the current ETag of the key value store,
+// and calls it again with a fresh ETag while it fails with a conflict, up to opts.Retries times.
+func withConflictRetry[T any](ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key string, optFns []func(*WriteItemOptions), write func(eTag *string) (T, error)) (T, error) {
+	opts := WriteItemOptions{
+		RetryDelay: 200 * time.Millisecond,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	var zero T
+	delay := opts.RetryDelay
+	for retry := 0; ; retry++ {
+		eTag, err := getETagByCloudFrontKeyValueStore(ctx, c, kvsARN)
+		if err != nil {
+			return zero, err
+		}
+		if err := checkWriteCondition(ctx, c, kvsARN, key, aws.ToString(eTag), opts); err != nil {
+			return zero, err
+		}
+
+		out, err := write(eTag)
+		if err == nil || !IsConflictError(err) || retry >= opts.Retries {
+			return out, err
+		}
+
+		if opts.OnRetry != nil {
+			opts.OnRetry(retry+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// checkWriteCondition checks the conditions of the write against the current state of the key value store.
+// If the key value store is changed after the check, the write fails with a conflict because it is pinned to eTag,
+// and the conditions are checked again on the retry.
+func checkWriteCondition(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key, eTag string, opts WriteItemOptions) error {
+	if opts.IfETag != nil && *opts.IfETag != eTag {
+		return &PreconditionFailedError{Key: key, Reason: fmt.Sprintf("the ETag of the key value store is %s, not %s", eTag, *opts.IfETag)}
+	}
+
+	if !opts.IfNotExists && opts.IfValue == nil {
+		return nil
+	}
+
+	out, err := c.GetKey(ctx, &kvs.GetKeyInput{
+		KvsARN: aws.String(kvsARN),
+		Key:    aws.String(key),
+	})
+	var notFound *kvsTypes.ResourceNotFoundException
+	exists := true
+	if errors.As(err, &notFound) {
+		exists = false
+	} else if err != nil {
+		return err
 	}
 
-	input := &kvs.DeleteKeyInput{
-		IfMatch: eTag,
-		KvsARN:  aws.String(kvsARN),
-		Key:     aws.String(key),
+	if opts.IfNotExists && exists {
+		return &PreconditionFailedError{Key: key, Reason: "the key already exists"}
+	}
+	if opts.IfValue != nil {
+		if !exists {
+			return &PreconditionFailedError{Key: key, Reason: "the key does not exist"}
+		}
+		if current := aws.ToString(out.Value); current != *opts.IfValue {
+			return &PreconditionFailedError{Key: key, Reason: fmt.Sprintf("the current value is '%s', not '%s'", current, *opts.IfValue)}
+		}
 	}
 
-	return c.DeleteKey(ctx, input)
+	return nil
 }
 
-func SyncItems(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN string, putList, deleteList []types.Item) (*kvs.UpdateKeysOutput, error) {
-	puts := []kvsTypes.PutKeyRequestListItem{}
-	for _, item := range putList {
-		puts = append(puts, kvsTypes.PutKeyRequestListItem{
-			Key:   aws.String(item.Key),
-			Value: aws.String(item.Value),
-		})
+func PutItem(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key, value string, optFns ...func(*WriteItemOptions)) (*kvs.PutKeyOutput, error) {
+	return withConflictRetry(ctx, c, kvsARN, key, optFns, func(eTag *string) (*kvs.PutKeyOutput, error) {
+		input := &kvs.PutKeyInput{
+			IfMatch: eTag,
+			KvsARN:  aws.String(kvsARN),
+			Key:     aws.String(key),
+			Value:   aws.String(value),
+		}
+		return c.PutKey(ctx, input)
+	})
+}
+
+func DeleteItem(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN, key string, optFns ...func(*WriteItemOptions)) (*kvs.DeleteKeyOutput, error) {
+	return withConflictRetry(ctx, c, kvsARN, key, optFns, func(eTag *string) (*kvs.DeleteKeyOutput, error) {
+		input := &kvs.DeleteKeyInput{
+			IfMatch: eTag,
+			KvsARN:  aws.String(kvsARN),
+			Key:     aws.String(key),
+		}
+		return c.DeleteKey(ctx, input)
+	})
+}
+
+// SyncBatch is a set of items that is applied by a single UpdateKeys request.
+type SyncBatch struct {
+	Puts    []types.Item
+	Deletes []types.Item
+}
+
+// SyncProgress is the progress of SyncItems, that is notified after each batch is applied.
+type SyncProgress struct {
+	// Batch is the 1-origin index of the applied batch.
+	Batch int
+	// Total is the number of batches.
+	Total   int
+	Puts    int
+	Deletes int
+	Output  *kvs.UpdateKeysOutput
+}
+
+type SyncItemsOptions struct {
+	// OnBatch is called after each batch is applied.
+	OnBatch func(p SyncProgress)
+
+	// IfMatch is the ETag that the first batch is applied to.
+	// If it is nil, the current ETag of the key value store is used.
+	IfMatch *string
+}
+
+// SyncBatchError is returned by SyncItems when a batch fails.
+// Batches before the failed one have already been applied to the key value store.
+type SyncBatchError struct {
+	// Batch is the 1-origin index of the failed batch.
+	Batch int
+	// Total is the number of batches.
+	Total int
+	Err   error
+}
+
+func (e *SyncBatchError) Error() string {
+	applied := "no batches were applied"
+	if e.Batch == 2 {
+		applied = "batch 1 was applied"
+	} else if e.Batch > 2 {
+		applied = fmt.Sprintf("batches 1-%d were applied", e.Batch-1)
+	}
+
+	return fmt.Sprintf("failed to sync batch %d/%d (%s): %v", e.Batch, e.Total, applied, e.Err)
+}
+
+func (e *SyncBatchError) Unwrap() error {
+	return e.Err
+}
+
+// SplitSyncBatches splits items to put and delete into batches
+// that stay within the quotas of a single UpdateKeys request.
+// Deletes are placed before puts so that the total size of the key value store does not grow temporarily.
+func SplitSyncBatches(putList, deleteList []types.Item) []SyncBatch {
+	batches := []SyncBatch{}
+	current := SyncBatch{}
+	count, size := 0, 0
+
+	add := func(item types.Item, itemSize int, isPut bool) {
+		if count > 0 && (count+1 > types.MaxItemsPerUpdateKeys || size+itemSize > types.MaxUpdateKeysRequestSize) {
+			batches = append(batches, current)
+			current = SyncBatch{}
+			count, size = 0, 0
+		}
+
+		if isPut {
+			current.Puts = append(current.Puts, item)
+		} else {
+			current.Deletes = append(current.Deletes, item)
+		}
+		count++
+		size += itemSize
 	}
 
-	delete := []kvsTypes.DeleteKeyRequestListItem{}
 	for _, item := range deleteList {
-		delete = append(delete, kvsTypes.DeleteKeyRequestListItem{
-			Key: aws.String(item.Key),
+		add(item, len(item.Key), false)
+	}
+	for _, item := range putList {
+		add(item, len(item.Key)+len(item.Value), true)
+	}
+
+	if count > 0 {
+		batches = append(batches, current)
+	}
+
+	return batches
+}
+
+// SyncItems puts and deletes items in the key value store.
+// Items are split into batches by SplitSyncBatches, and each batch is applied by an UpdateKeys request
+// with the ETag returned by the previous one.
+func SyncItems(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN string, putList, deleteList []types.Item, optFns ...func(*SyncItemsOptions)) (*kvs.UpdateKeysOutput, error) {
+	opts := SyncItemsOptions{}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	batches := SplitSyncBatches(putList, deleteList)
+	if len(batches) == 0 {
+		// nothing to sync, so returns the current state of the key value store
+		out, err := c.DescribeKeyValueStore(ctx, &kvs.DescribeKeyValueStoreInput{
+			KvsARN: aws.String(kvsARN),
 		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &kvs.UpdateKeysOutput{
+			ETag:             out.ETag,
+			ItemCount:        out.ItemCount,
+			TotalSizeInBytes: out.TotalSizeInBytes,
+		}, nil
+	}
+
+	eTag := opts.IfMatch
+	if eTag == nil {
+		var err error
+		if eTag, err = getETagByCloudFrontKeyValueStore(ctx, c, kvsARN); err != nil {
+			return nil, err
+		}
+	}
+
+	var out *kvs.UpdateKeysOutput
+	for i, batch := range batches {
+		puts := []kvsTypes.PutKeyRequestListItem{}
+		for _, item := range batch.Puts {
+			puts = append(puts, kvsTypes.PutKeyRequestListItem{
+				Key:   aws.String(item.Key),
+				Value: aws.String(item.Value),
+			})
+		}
+
+		deletes := []kvsTypes.DeleteKeyRequestListItem{}
+		for _, item := range batch.Deletes {
+			deletes = append(deletes, kvsTypes.DeleteKeyRequestListItem{
+				Key: aws.String(item.Key),
+			})
+		}
+
+		input := &kvs.UpdateKeysInput{
+			KvsARN:  aws.String(kvsARN),
+			Puts:    puts,
+			Deletes: deletes,
+			IfMatch: eTag,
+		}
+
+		var err error
+		out, err = c.UpdateKeys(ctx, input)
+		if err != nil {
+			return nil, &SyncBatchError{Batch: i + 1, Total: len(batches), Err: err}
+		}
+		if out == nil {
+			return nil, &SyncBatchError{Batch: i + 1, Total: len(batches), Err: fmt.Errorf("cloudfrontkeyvaluestore.UpdateKeysOutput is nil")}
+		}
+
+		// the next batch must be applied to the state after this batch
+		eTag = out.ETag
+
+		if opts.OnBatch != nil {
+			opts.OnBatch(SyncProgress{
+				Batch:   i + 1,
+				Total:   len(batches),
+				Puts:    len(batch.Puts),
+				Deletes: len(batch.Deletes),
+				Output:  out,
+			})
+		}
 	}
 
+	return out, nil
+}
+
+// GetKeyValueStoreETag returns the current ETag of the key value store, that changes whenever its items are updated.
+func GetKeyValueStoreETag(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN string) (string, error) {
 	eTag, err := getETagByCloudFrontKeyValueStore(ctx, c, kvsARN)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	input := &kvs.UpdateKeysInput{
-		KvsARN:  aws.String(kvsARN),
-		Puts:    puts,
-		Deletes: delete,
-		IfMatch: eTag,
+	if eTag == nil {
+		return "", fmt.Errorf("the ETag of the key value store '%s' is empty", kvsARN)
 	}
 
-	return c.UpdateKeys(ctx, input)
+	return *eTag, nil
 }
 
 func getETagByCloudFrontKeyValueStore(ctx context.Context, c CloudFrontKeyValueStoreClient, kvsARN string) (*string, error) {
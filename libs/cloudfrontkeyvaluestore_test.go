@@ -2,11 +2,16 @@ package libs_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
 	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/aws/smithy-go"
 	"github.com/michimani/cfkvs/libs"
 	"github.com/michimani/cfkvs/types"
 	"github.com/stretchr/testify/assert"
@@ -76,54 +81,11 @@ func Test_getETagByCloudFrontKeyValueStore(t *testing.T) {
 	}
 }
 
-func Test_NewNewCloudFrontKeyValueStoreClient(t *testing.T) {
-	cases := []struct {
-		name    string
-		ctx     context.Context
-		envs    map[string]string
-		wantErr bool
-	}{
-		{
-			name: "ok",
-			ctx:  context.Background(),
-			envs: map[string]string{
-				"AWS_ACCESS_KEY_ID":     "dummy_key_id",
-				"AWS_SECRET_ACCESS_KEY": "dummy_secret_key",
-				"AWS_SESSION_TOKEN":     "dummy_session_token",
-				"AWS_REGION":            "ap-northeast-1",
-			},
-			wantErr: false,
-		},
-		{
-			name: "failed to load default config",
-			ctx:  context.Background(),
-			envs: map[string]string{
-				"AWS_PROFILE": "invalid",
-				"AWS_REGION":  "ap-northeast-1",
-			},
-			wantErr: true,
-		},
-	}
-
-	for _, c := range cases {
-		t.Run(c.name, func(tt *testing.T) {
-			for k, v := range c.envs {
-				tt.Setenv(k, v)
-			}
+func Test_NewCloudFrontKeyValueStoreClient(t *testing.T) {
+	asst := assert.New(t)
 
-			asst := assert.New(tt)
-
-			sc, err := libs.NewCloudFrontKeyValueStoreClient(c.ctx)
-			if c.wantErr {
-				asst.Error(err)
-				asst.Nil(sc)
-				return
-			}
-
-			asst.NoError(err)
-			asst.NotNil(sc)
-		})
-	}
+	c := libs.NewCloudFrontKeyValueStoreClient(aws.Config{Region: "ap-northeast-1"})
+	asst.NotNil(c)
 }
 
 func Test_ListItems(t *testing.T) {
@@ -435,6 +397,254 @@ func Test_DeleteItem(t *testing.T) {
 	}
 }
 
+func Test_IsConflictError(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		expect bool
+	}{
+		{name: "conflict exception", err: &kvsTypes.ConflictException{}, expect: true},
+		{name: "wrapped conflict exception", err: fmt.Errorf("wrapped: %w", &kvsTypes.ConflictException{}), expect: true},
+		{name: "precondition failed", err: &smithy.GenericAPIError{Code: "PreconditionFailed"}, expect: true},
+		{name: "in a batch error", err: &libs.SyncBatchError{Batch: 1, Total: 1, Err: &kvsTypes.ConflictException{}}, expect: true},
+		{name: "validation exception", err: &kvsTypes.ValidationException{}, expect: false},
+		{name: "other error", err: errors.New("error"), expect: false},
+		{name: "nil", err: nil, expect: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, libs.IsConflictError(c.err))
+		})
+	}
+}
+
+func Test_WriteItem_ConflictRetry(t *testing.T) {
+	conflict := &kvsTypes.ConflictException{Message: aws.String("conflict")}
+
+	cases := []struct {
+		name          string
+		retries       int
+		errs          []error
+		expectETags   []string
+		expectRetries int
+		wantErr       bool
+	}{
+		{
+			name:        "ok: no conflict",
+			retries:     3,
+			errs:        []error{nil},
+			expectETags: []string{"etag-1"},
+		},
+		{
+			name:          "ok: retried with a fresh etag",
+			retries:       3,
+			errs:          []error{conflict, conflict, nil},
+			expectETags:   []string{"etag-1", "etag-2", "etag-3"},
+			expectRetries: 2,
+		},
+		{
+			name:          "error: retries are exhausted",
+			retries:       1,
+			errs:          []error{conflict, conflict},
+			expectETags:   []string{"etag-1", "etag-2"},
+			expectRetries: 1,
+			wantErr:       true,
+		},
+		{
+			name:        "error: no retries by default",
+			retries:     0,
+			errs:        []error{conflict},
+			expectETags: []string{"etag-1"},
+			wantErr:     true,
+		},
+		{
+			name:        "error: other errors are not retried",
+			retries:     3,
+			errs:        []error{errors.New("error")},
+			expectETags: []string{"etag-1"},
+			wantErr:     true,
+		},
+	}
+
+	for _, c := range cases {
+		for _, op := range []string{"put", "delete"} {
+			t.Run(c.name+" ("+op+")", func(tt *testing.T) {
+				asst := assert.New(tt)
+
+				ctrl := gomock.NewController(tt)
+				m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+
+				describes := 0
+				m.EXPECT().
+					DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, params *kvs.DescribeKeyValueStoreInput, optFns ...func(*kvs.Options)) (*kvs.DescribeKeyValueStoreOutput, error) {
+						describes++
+						return &kvs.DescribeKeyValueStoreOutput{ETag: aws.String(fmt.Sprintf("etag-%d", describes))}, nil
+					}).Times(len(c.errs))
+
+				eTags := []string{}
+				m.EXPECT().
+					PutKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, params *kvs.PutKeyInput, optFns ...func(*kvs.Options)) (*kvs.PutKeyOutput, error) {
+						eTags = append(eTags, aws.ToString(params.IfMatch))
+						if err := c.errs[len(eTags)-1]; err != nil {
+							return nil, err
+						}
+						return &kvs.PutKeyOutput{}, nil
+					}).AnyTimes()
+				m.EXPECT().
+					DeleteKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, params *kvs.DeleteKeyInput, optFns ...func(*kvs.Options)) (*kvs.DeleteKeyOutput, error) {
+						eTags = append(eTags, aws.ToString(params.IfMatch))
+						if err := c.errs[len(eTags)-1]; err != nil {
+							return nil, err
+						}
+						return &kvs.DeleteKeyOutput{}, nil
+					}).AnyTimes()
+
+				retries := 0
+				opt := func(o *libs.WriteItemOptions) {
+					o.Retries = c.retries
+					o.RetryDelay = time.Millisecond
+					o.OnRetry = func(retry int, err error) {
+						retries++
+						asst.Equal(retries, retry)
+					}
+				}
+
+				var err error
+				if op == "put" {
+					_, err = libs.PutItem(context.Background(), m, "dummy_arn", "key1", "value1", opt)
+				} else {
+					_, err = libs.DeleteItem(context.Background(), m, "dummy_arn", "key1", opt)
+				}
+
+				asst.Equal(c.expectETags, eTags)
+				asst.Equal(c.expectRetries, retries)
+				if c.wantErr {
+					asst.Error(err)
+					return
+				}
+				asst.NoError(err)
+			})
+		}
+	}
+}
+
+func Test_WriteItem_Condition(t *testing.T) {
+	notFound := &kvsTypes.ResourceNotFoundException{Message: aws.String("not found")}
+
+	cases := []struct {
+		name           string
+		opt            func(o *libs.WriteItemOptions)
+		currentValue   *string
+		getKeyErr      error
+		expectGetKey   bool
+		expectWrite    bool
+		wantPrecondErr bool
+		wantErr        bool
+	}{
+		{
+			name:        "ok: no condition",
+			opt:         func(o *libs.WriteItemOptions) {},
+			expectWrite: true,
+		},
+		{
+			name:         "ok: if not exists",
+			opt:          func(o *libs.WriteItemOptions) { o.IfNotExists = true },
+			getKeyErr:    notFound,
+			expectGetKey: true,
+			expectWrite:  true,
+		},
+		{
+			name:           "precondition failed: if not exists",
+			opt:            func(o *libs.WriteItemOptions) { o.IfNotExists = true },
+			currentValue:   aws.String("value0"),
+			expectGetKey:   true,
+			wantPrecondErr: true,
+		},
+		{
+			name:         "ok: if value",
+			opt:          func(o *libs.WriteItemOptions) { o.IfValue = aws.String("value0") },
+			currentValue: aws.String("value0"),
+			expectGetKey: true,
+			expectWrite:  true,
+		},
+		{
+			name:           "precondition failed: if value is different",
+			opt:            func(o *libs.WriteItemOptions) { o.IfValue = aws.String("value0") },
+			currentValue:   aws.String("other"),
+			expectGetKey:   true,
+			wantPrecondErr: true,
+		},
+		{
+			name:           "precondition failed: if value but not exists",
+			opt:            func(o *libs.WriteItemOptions) { o.IfValue = aws.String("value0") },
+			getKeyErr:      notFound,
+			expectGetKey:   true,
+			wantPrecondErr: true,
+		},
+		{
+			name:        "ok: if etag",
+			opt:         func(o *libs.WriteItemOptions) { o.IfETag = aws.String("etag-1") },
+			expectWrite: true,
+		},
+		{
+			name:           "precondition failed: if etag",
+			opt:            func(o *libs.WriteItemOptions) { o.IfETag = aws.String("etag-0") },
+			wantPrecondErr: true,
+		},
+		{
+			name:         "error: failed to get key",
+			opt:          func(o *libs.WriteItemOptions) { o.IfNotExists = true },
+			getKeyErr:    errors.New("error"),
+			expectGetKey: true,
+			wantErr:      true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			ctrl := gomock.NewController(tt)
+			m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			m.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag-1")}, nil)
+			if c.expectGetKey {
+				var out *kvs.GetKeyOutput
+				if c.currentValue != nil {
+					out = &kvs.GetKeyOutput{Key: aws.String("key1"), Value: c.currentValue}
+				}
+				m.EXPECT().GetKey(gomock.Any(), gomock.Any()).Return(out, c.getKeyErr)
+			}
+			if c.expectWrite {
+				m.EXPECT().
+					PutKey(gomock.Any(), &kvs.PutKeyInput{
+						IfMatch: aws.String("etag-1"),
+						KvsARN:  aws.String("dummy_arn"),
+						Key:     aws.String("key1"),
+						Value:   aws.String("value1"),
+					}).
+					Return(&kvs.PutKeyOutput{}, nil)
+			}
+
+			_, err := libs.PutItem(context.Background(), m, "dummy_arn", "key1", "value1", c.opt)
+
+			var precondErr *libs.PreconditionFailedError
+			asst.Equal(c.wantPrecondErr, errors.As(err, &precondErr))
+			if c.wantPrecondErr || c.wantErr {
+				asst.Error(err)
+				return
+			}
+			asst.NoError(err)
+		})
+	}
+}
+
 func Test_SyncItems(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -542,3 +752,326 @@ func Test_SyncItems(t *testing.T) {
 		})
 	}
 }
+
+func Test_SyncItems_Batches(t *testing.T) {
+	items := func(prefix string, n int) []types.Item {
+		list := []types.Item{}
+		for i := 0; i < n; i++ {
+			list = append(list, types.Item{Key: fmt.Sprintf("%s%d", prefix, i), Value: "value"})
+		}
+		return list
+	}
+
+	cases := []struct {
+		name         string
+		putList      []types.Item
+		deleteList   []types.Item
+		updateErrors []error
+		expectETags  []string
+		expectBatch  []libs.SyncProgress
+		expectErr    *libs.SyncBatchError
+	}{
+		{
+			name:        "ok: multiple batches",
+			putList:     items("put", 60),
+			deleteList:  items("del", 10),
+			expectETags: []string{"etag0", "etag1"},
+			expectBatch: []libs.SyncProgress{
+				{Batch: 1, Total: 2, Puts: 40, Deletes: 10},
+				{Batch: 2, Total: 2, Puts: 20, Deletes: 0},
+			},
+		},
+		{
+			name:         "error: second batch fails",
+			putList:      items("put", 120),
+			updateErrors: []error{nil, assert.AnError},
+			expectETags:  []string{"etag0", "etag1"},
+			expectBatch: []libs.SyncProgress{
+				{Batch: 1, Total: 3, Puts: 50, Deletes: 0},
+			},
+			expectErr: &libs.SyncBatchError{Batch: 2, Total: 3, Err: assert.AnError},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			m.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				Return(&kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag0")}, nil)
+
+			calls := []any{}
+			for i, eTag := range c.expectETags {
+				var err error
+				if i < len(c.updateErrors) {
+					err = c.updateErrors[i]
+				}
+
+				var out *kvs.UpdateKeysOutput
+				if err == nil {
+					out = &kvs.UpdateKeysOutput{ETag: aws.String(fmt.Sprintf("etag%d", i+1))}
+				}
+
+				calls = append(calls, m.EXPECT().
+					UpdateKeys(gomock.Any(), gomock.Cond(func(x any) bool {
+						return aws.ToString(x.(*kvs.UpdateKeysInput).IfMatch) == eTag
+					})).
+					Return(out, err))
+			}
+			gomock.InOrder(calls...)
+
+			progress := []libs.SyncProgress{}
+			got, err := libs.SyncItems(context.Background(), m, "dummy_arn", c.putList, c.deleteList, func(o *libs.SyncItemsOptions) {
+				o.OnBatch = func(p libs.SyncProgress) {
+					p.Output = nil
+					progress = append(progress, p)
+				}
+			})
+
+			asst.Equal(c.expectBatch, progress)
+
+			if c.expectErr != nil {
+				asst.Error(err)
+				asst.Nil(got)
+				var batchErr *libs.SyncBatchError
+				asst.ErrorAs(err, &batchErr)
+				asst.Equal(c.expectErr, batchErr)
+				asst.ErrorIs(err, c.expectErr.Err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(fmt.Sprintf("etag%d", len(c.expectETags)), aws.ToString(got.ETag))
+		})
+	}
+}
+
+func Test_SyncItems_NoChanges(t *testing.T) {
+	cases := []struct {
+		name    string
+		kvscOut struct {
+			Out   *kvs.DescribeKeyValueStoreOutput
+			Error error
+		}
+		expect  *kvs.UpdateKeysOutput
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			kvscOut: struct {
+				Out   *kvs.DescribeKeyValueStoreOutput
+				Error error
+			}{
+				Out: &kvs.DescribeKeyValueStoreOutput{
+					ETag:             aws.String("dummy_etag"),
+					ItemCount:        aws.Int32(2),
+					TotalSizeInBytes: aws.Int64(20),
+				},
+			},
+			expect: &kvs.UpdateKeysOutput{
+				ETag:             aws.String("dummy_etag"),
+				ItemCount:        aws.Int32(2),
+				TotalSizeInBytes: aws.Int64(20),
+			},
+		},
+		{
+			name: "failed to describe key value store",
+			kvscOut: struct {
+				Out   *kvs.DescribeKeyValueStoreOutput
+				Error error
+			}{
+				Error: assert.AnError,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			m.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				Return(c.kvscOut.Out, c.kvscOut.Error)
+
+			got, err := libs.SyncItems(context.Background(), m, "dummy_arn", []types.Item{}, []types.Item{})
+			if c.wantErr {
+				asst.Error(err)
+				asst.Nil(got)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, got)
+		})
+	}
+}
+
+func Test_SplitSyncBatches(t *testing.T) {
+	items := func(prefix string, n int, valueSize int) []types.Item {
+		list := []types.Item{}
+		for i := 0; i < n; i++ {
+			list = append(list, types.Item{Key: fmt.Sprintf("%s%03d", prefix, i), Value: strings.Repeat("v", valueSize)})
+		}
+		return list
+	}
+
+	cases := []struct {
+		name          string
+		putList       []types.Item
+		deleteList    []types.Item
+		expectPuts    []int
+		expectDeletes []int
+	}{
+		{
+			name:          "empty",
+			putList:       []types.Item{},
+			deleteList:    []types.Item{},
+			expectPuts:    []int{},
+			expectDeletes: []int{},
+		},
+		{
+			name:          "single batch",
+			putList:       items("p", 30, 1),
+			deleteList:    items("d", 20, 1),
+			expectPuts:    []int{30},
+			expectDeletes: []int{20},
+		},
+		{
+			name:          "split by number of items, deletes first",
+			putList:       items("p", 70, 1),
+			deleteList:    items("d", 40, 1),
+			expectPuts:    []int{10, 50, 10},
+			expectDeletes: []int{40, 0, 0},
+		},
+		{
+			name:          "split by request size",
+			putList:       items("p", 4, 1024*1024),
+			deleteList:    nil,
+			expectPuts:    []int{2, 2},
+			expectDeletes: []int{0, 0},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			batches := libs.SplitSyncBatches(c.putList, c.deleteList)
+
+			puts := []int{}
+			deletes := []int{}
+			for _, b := range batches {
+				puts = append(puts, len(b.Puts))
+				deletes = append(deletes, len(b.Deletes))
+				asst.LessOrEqual(len(b.Puts)+len(b.Deletes), types.MaxItemsPerUpdateKeys)
+			}
+
+			asst.Equal(c.expectPuts, puts)
+			asst.Equal(c.expectDeletes, deletes)
+		})
+	}
+}
+
+func Test_SyncBatchError_Error(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    *libs.SyncBatchError
+		expect string
+	}{
+		{
+			name:   "first batch",
+			err:    &libs.SyncBatchError{Batch: 1, Total: 3, Err: errors.New("error")},
+			expect: "failed to sync batch 1/3 (no batches were applied): error",
+		},
+		{
+			name:   "second batch",
+			err:    &libs.SyncBatchError{Batch: 2, Total: 3, Err: errors.New("error")},
+			expect: "failed to sync batch 2/3 (batch 1 was applied): error",
+		},
+		{
+			name:   "third batch",
+			err:    &libs.SyncBatchError{Batch: 3, Total: 3, Err: errors.New("error")},
+			expect: "failed to sync batch 3/3 (batches 1-2 were applied): error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, c.err.Error())
+		})
+	}
+}
+
+func Test_SyncItems_IfMatch(t *testing.T) {
+	asst := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	// DescribeKeyValueStore is not called, because the ETag is pinned.
+	m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+	m.EXPECT().
+		UpdateKeys(gomock.Any(), gomock.Cond(func(x any) bool {
+			return aws.ToString(x.(*kvs.UpdateKeysInput).IfMatch) == "pinned"
+		})).
+		Return(&kvs.UpdateKeysOutput{ETag: aws.String("etag1")}, nil)
+
+	out, err := libs.SyncItems(context.Background(), m, "arn", []types.Item{{Key: "key", Value: "value"}}, nil, func(o *libs.SyncItemsOptions) {
+		o.IfMatch = aws.String("pinned")
+	})
+	asst.NoError(err)
+	asst.Equal("etag1", aws.ToString(out.ETag))
+}
+
+func Test_GetKeyValueStoreETag(t *testing.T) {
+	cases := []struct {
+		name    string
+		out     *kvs.DescribeKeyValueStoreOutput
+		err     error
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "ok",
+			out:    &kvs.DescribeKeyValueStoreOutput{ETag: aws.String("etag")},
+			expect: "etag",
+		},
+		{
+			name:    "ETag is empty",
+			out:     &kvs.DescribeKeyValueStoreOutput{},
+			wantErr: true,
+		},
+		{
+			name:    "failed to describe key value store",
+			err:     assert.AnError,
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			m := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			m.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), &kvs.DescribeKeyValueStoreInput{KvsARN: aws.String("arn")}).
+				Return(c.out, c.err)
+
+			eTag, err := libs.GetKeyValueStoreETag(context.Background(), m, "arn")
+			if c.wantErr {
+				asst.Error(err)
+				asst.Empty(eTag)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, eTag)
+		})
+	}
+}
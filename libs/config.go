@@ -0,0 +1,61 @@
+package libs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// AWSConfigOptions are the options to load the AWS config shared by all clients.
+// Empty fields fall back to the default behavior of the AWS SDK (environment variables and shared config files).
+type AWSConfigOptions struct {
+	Profile     string
+	Region      string
+	EndpointURL string
+
+	// RoleARN is the ARN of the IAM role to assume with the credentials of the profile.
+	RoleARN string
+	// ExternalID is passed to sts:AssumeRole. It requires RoleARN.
+	ExternalID string
+}
+
+// LoadAWSConfig loads the AWS config with the options.
+func LoadAWSConfig(ctx context.Context, opts AWSConfigOptions) (aws.Config, error) {
+	if opts.ExternalID != "" && opts.RoleARN == "" {
+		return aws.Config{}, errors.New("role-arn is required when external-id is specified")
+	}
+
+	optFns := []func(*config.LoadOptions) error{}
+	if opts.Profile != "" {
+		optFns = append(optFns, config.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.Region != "" {
+		optFns = append(optFns, config.WithRegion(opts.Region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	// The role is assumed before the endpoint is overridden,
+	// because the endpoint is for CloudFront, CloudFront KeyValueStore and S3, not for STS.
+	if opts.RoleARN != "" {
+		provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), opts.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if opts.ExternalID != "" {
+				o.ExternalID = aws.String(opts.ExternalID)
+			}
+		})
+		cfg.Credentials = aws.NewCredentialsCache(provider)
+	}
+
+	if opts.EndpointURL != "" {
+		cfg.BaseEndpoint = aws.String(opts.EndpointURL)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,164 @@
+package libs_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/stretchr/testify/assert"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>assumed_key_id</AccessKeyId>
+      <SecretAccessKey>assumed_secret_key</SecretAccessKey>
+      <SessionToken>assumed_session_token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::123456789012:assumed-role/role/session</Arn>
+      <AssumedRoleId>ARO123EXAMPLE123:session</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+
+func Test_LoadAWSConfig(t *testing.T) {
+	sharedConfig := `[profile team-a]
+region = eu-west-1
+aws_access_key_id = team_a_key_id
+aws_secret_access_key = team_a_secret_key
+`
+
+	cases := []struct {
+		name         string
+		envs         map[string]string
+		opts         libs.AWSConfigOptions
+		expectRegion string
+		expectKeyID  string
+		expectForm   map[string]string
+		wantErr      bool
+	}{
+		{
+			name:         "ok: default",
+			opts:         libs.AWSConfigOptions{},
+			expectRegion: "ap-northeast-1",
+			expectKeyID:  "dummy_key_id",
+		},
+		{
+			name:         "ok: profile",
+			opts:         libs.AWSConfigOptions{Profile: "team-a"},
+			envs:         map[string]string{"AWS_REGION": ""},
+			expectRegion: "eu-west-1",
+			expectKeyID:  "team_a_key_id",
+		},
+		{
+			name:         "ok: region overrides profile",
+			opts:         libs.AWSConfigOptions{Profile: "team-a", Region: "us-west-2"},
+			expectRegion: "us-west-2",
+			expectKeyID:  "team_a_key_id",
+		},
+		{
+			name:         "ok: endpoint url",
+			opts:         libs.AWSConfigOptions{EndpointURL: "http://127.0.0.1:4599"},
+			expectRegion: "ap-northeast-1",
+			expectKeyID:  "dummy_key_id",
+		},
+		{
+			name: "ok: assume role with external id",
+			opts: libs.AWSConfigOptions{
+				RoleARN:     "arn:aws:iam::123456789012:role/role",
+				ExternalID:  "external_id",
+				EndpointURL: "http://127.0.0.1:4599",
+			},
+			expectRegion: "ap-northeast-1",
+			expectKeyID:  "assumed_key_id",
+			expectForm: map[string]string{
+				"Action":     "AssumeRole",
+				"RoleArn":    "arn:aws:iam::123456789012:role/role",
+				"ExternalId": "external_id",
+			},
+		},
+		{
+			name:    "error: profile not found",
+			opts:    libs.AWSConfigOptions{Profile: "invalid"},
+			wantErr: true,
+		},
+		{
+			name:    "error: external id without role arn",
+			opts:    libs.AWSConfigOptions{ExternalID: "external_id"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			var form map[string]string
+			sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				form = map[string]string{}
+				for k := range r.PostForm {
+					form[k] = r.PostForm.Get(k)
+				}
+				w.Header().Set("Content-Type", "text/xml")
+				_, _ = w.Write([]byte(assumeRoleResponse))
+			}))
+			defer sts.Close()
+
+			dir := tt.TempDir()
+			configFile := filepath.Join(dir, "config")
+			if err := os.WriteFile(configFile, []byte(sharedConfig), 0o600); err != nil {
+				tt.Fatal(err)
+			}
+
+			envs := map[string]string{
+				"AWS_CONFIG_FILE":             configFile,
+				"AWS_SHARED_CREDENTIALS_FILE": filepath.Join(dir, "credentials"),
+				"AWS_PROFILE":                 "",
+				"AWS_ACCESS_KEY_ID":           "dummy_key_id",
+				"AWS_SECRET_ACCESS_KEY":       "dummy_secret_key",
+				"AWS_SESSION_TOKEN":           "",
+				"AWS_REGION":                  "ap-northeast-1",
+				"AWS_ENDPOINT_URL":            "",
+				"AWS_ENDPOINT_URL_STS":        sts.URL,
+			}
+			for k, v := range c.envs {
+				envs[k] = v
+			}
+			for k, v := range envs {
+				tt.Setenv(k, v)
+			}
+
+			cfg, err := libs.LoadAWSConfig(context.Background(), c.opts)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expectRegion, cfg.Region)
+			if c.opts.EndpointURL != "" {
+				asst.Equal(c.opts.EndpointURL, aws.ToString(cfg.BaseEndpoint))
+			} else {
+				asst.Nil(cfg.BaseEndpoint)
+			}
+
+			creds, err := cfg.Credentials.Retrieve(context.Background())
+			asst.NoError(err)
+			asst.Equal(c.expectKeyID, creds.AccessKeyID)
+			for k, v := range c.expectForm {
+				asst.Equal(v, form[k], k)
+			}
+		})
+	}
+}
@@ -3,11 +3,15 @@ package libs
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/michimani/cfkvs/types"
 )
 
-func GetKeyValueStoreDataFromFile(path string) (*types.KeyValueStoreData, error) {
+// GetKeyValueStoreDataFromFile reads the key value store data from the file in the format.
+// If the format is DataFormatAuto, it is detected from the extension of the file.
+func GetKeyValueStoreDataFromFile(path string, format types.DataFormat) (*types.KeyValueStoreData, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("file not found: %s", path)
 	}
@@ -17,10 +21,112 @@ func GetKeyValueStoreDataFromFile(path string) (*types.KeyValueStoreData, error)
 		return nil, err
 	}
 
+	if format == types.DataFormatAuto {
+		format = types.DetectDataFormat(path)
+	}
+
 	kvsData := types.KeyValueStoreData{}
-	if err := kvsData.FromBytes(bodyBytes); err != nil {
+	if err := kvsData.FromBytesAs(bodyBytes, format); err != nil {
 		return nil, err
 	}
 
 	return &kvsData, nil
 }
+
+// GetValueFromFile reads the whole content of the file as the value of an item.
+func GetValueFromFile(path string) ([]byte, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	return os.ReadFile(path)
+}
+
+// GetKeysFromFile reads keys from the file, one key per line.
+// Leading and trailing white spaces of each line are trimmed, and empty lines are skipped.
+func GetKeysFromFile(path string) ([]string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	bodyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for _, line := range strings.Split(string(bodyBytes), "\n") {
+		if key := strings.TrimSpace(line); key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// PutKeyValueStoreDataToFile writes the key value store data to the file in the import format.
+// The file is overwritten if it exists.
+func PutKeyValueStoreDataToFile(path string, data *types.KeyValueStoreData) error {
+	b, err := data.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o644)
+}
+
+func GetPlanFromFile(path string) (*types.Plan, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	bodyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := types.Plan{}
+	if err := plan.FromBytes(bodyBytes); err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// PutPlanToFile writes the plan to the file.
+// The file is overwritten if it exists.
+func PutPlanToFile(path string, plan *types.Plan) error {
+	b, err := plan.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o644)
+}
+
+// GetManifestFromFile reads the manifest file.
+// Relative paths of the source files are resolved from the directory of the manifest file.
+func GetManifestFromFile(path string) (*types.Manifest, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file not found: %s", path)
+	}
+
+	bodyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := types.Manifest{}
+	if err := manifest.FromBytes(bodyBytes); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	for i, s := range manifest.Stores {
+		if s.File != "" && !filepath.IsAbs(s.File) {
+			manifest.Stores[i].File = filepath.Join(dir, s.File)
+		}
+	}
+
+	return &manifest, nil
+}
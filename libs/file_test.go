@@ -1,6 +1,8 @@
 package libs_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/michimani/cfkvs/libs"
@@ -12,6 +14,7 @@ func Test_GetKeyValueStoreDataFromFile(t *testing.T) {
 	cases := []struct {
 		name    string
 		path    string
+		format  types.DataFormat
 		want    *types.KeyValueStoreData
 		wantErr bool
 	}{
@@ -36,6 +39,140 @@ func Test_GetKeyValueStoreDataFromFile(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "ok: csv",
+			path: "../testdata/valid.csv",
+			want: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{
+						Key:   "key-1",
+						Value: "v 1",
+					},
+					{
+						Key:   "key-2",
+						Value: "value-2",
+					},
+					{
+						Key:   "key-4",
+						Value: "v 4",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ok: tsv",
+			path: "../testdata/valid.tsv",
+			want: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{
+						Key:   "key-1",
+						Value: "v 1",
+					},
+					{
+						Key:   "key-2",
+						Value: "value-2",
+					},
+					{
+						Key:   "key-4",
+						Value: "v 4",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ok: yaml",
+			path: "../testdata/valid.yaml",
+			want: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{
+						Key:   "key-1",
+						Value: "v 1",
+					},
+					{
+						Key:   "key-2",
+						Value: "value-2",
+					},
+					{
+						Key:   "key-4",
+						Value: "v 4",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ok: ndjson",
+			path: "../testdata/valid.ndjson",
+			want: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{
+						Key:   "key-1",
+						Value: "v 1",
+					},
+					{
+						Key:   "key-2",
+						Value: "value-2",
+					},
+					{
+						Key:   "key-4",
+						Value: "v 4",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ok: env",
+			path: "../testdata/valid.env",
+			want: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{
+						Key:   "key-1",
+						Value: "v 1",
+					},
+					{
+						Key:   "key-2",
+						Value: "value-2",
+					},
+					{
+						Key:   "key-4",
+						Value: "v 4",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "ok: format is specified",
+			path:   "../testdata/valid.yaml",
+			format: types.DataFormatYAML,
+			want: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{
+						Key:   "key-1",
+						Value: "v 1",
+					},
+					{
+						Key:   "key-2",
+						Value: "value-2",
+					},
+					{
+						Key:   "key-4",
+						Value: "v 4",
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "format does not match",
+			path:    "../testdata/valid.csv",
+			format:  types.DataFormatJSON,
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:    "file not found",
 			path:    "../testdata/notfound.json",
@@ -66,7 +203,7 @@ func Test_GetKeyValueStoreDataFromFile(t *testing.T) {
 		t.Run(c.name, func(tt *testing.T) {
 			asst := assert.New(tt)
 
-			got, err := libs.GetKeyValueStoreDataFromFile(c.path)
+			got, err := libs.GetKeyValueStoreDataFromFile(c.path, c.format)
 			if c.wantErr {
 				asst.Error(err)
 				asst.Nil(got)
@@ -78,3 +215,172 @@ func Test_GetKeyValueStoreDataFromFile(t *testing.T) {
 		})
 	}
 }
+
+func Test_GetValueFromFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "ok", path: "../testdata/valid.env"},
+		{name: "error: file not found", path: "../testdata/not-found.txt", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			b, err := libs.GetValueFromFile(c.path)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.NotEmpty(b)
+		})
+	}
+}
+
+func Test_GetKeysFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(path, []byte("key-1\n\n  key-2\t\r\nkey 3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		path    string
+		expect  []string
+		wantErr bool
+	}{
+		{name: "ok", path: path, expect: []string{"key-1", "key-2", "key 3"}},
+		{name: "error: file not found", path: "../testdata/not-found.txt", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			keys, err := libs.GetKeysFromFile(c.path)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, keys)
+		})
+	}
+}
+
+func Test_PutKeyValueStoreDataToFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		path    func(dir string) string
+		data    *types.KeyValueStoreData
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			path: func(dir string) string { return filepath.Join(dir, "out.json") },
+			data: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{Key: "key-1", Value: "v 1"},
+					{Key: "key-2", Value: "value-2"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "nil data",
+			path:    func(dir string) string { return filepath.Join(dir, "out.json") },
+			data:    &types.KeyValueStoreData{},
+			wantErr: true,
+		},
+		{
+			name: "directory not found",
+			path: func(dir string) string { return filepath.Join(dir, "notfound", "out.json") },
+			data: &types.KeyValueStoreData{
+				Data: &[]types.Item{},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			path := c.path(tt.TempDir())
+
+			err := libs.PutKeyValueStoreDataToFile(path, c.data)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+
+			got, err := libs.GetKeyValueStoreDataFromFile(path, types.DataFormatAuto)
+			asst.NoError(err)
+			asst.Equal(c.data, got)
+		})
+	}
+}
+
+func Test_PutPlanToFile_GetPlanFromFile(t *testing.T) {
+	asst := assert.New(t)
+	dir := t.TempDir()
+
+	plan := &types.Plan{
+		Version: types.PlanVersion,
+		KVSARN:  "arn",
+		ETag:    "etag",
+		Diff: &types.ItemListDiff{
+			Add:    []types.ItemDiff{{After: &types.Item{Key: "key1", Value: "value1"}}},
+			Update: []types.ItemDiff{},
+			Delete: []types.ItemDiff{},
+		},
+	}
+
+	path := filepath.Join(dir, "plan.json")
+	asst.NoError(libs.PutPlanToFile(path, plan))
+
+	got, err := libs.GetPlanFromFile(path)
+	asst.NoError(err)
+	asst.Equal(plan, got)
+
+	_, err = libs.GetPlanFromFile(filepath.Join(dir, "notfound.json"))
+	asst.Error(err)
+
+	_, err = libs.GetPlanFromFile("../testdata/valid.json")
+	asst.Error(err)
+
+	asst.Error(libs.PutPlanToFile(filepath.Join(dir, "notfound", "plan.json"), plan))
+}
+
+func Test_GetManifestFromFile(t *testing.T) {
+	asst := assert.New(t)
+
+	got, err := libs.GetManifestFromFile("../testdata/cfkvs.yaml")
+	asst.NoError(err)
+	if asst.Len(got.Stores, 2) {
+		asst.Equal("kvs-1", got.Stores[0].Name)
+		asst.Equal(filepath.Join("..", "testdata", "valid.json"), got.Stores[0].File)
+		asst.Equal(filepath.Join("..", "testdata", "valid.yaml"), got.Stores[1].File)
+	}
+
+	// an absolute path is kept
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cfkvs.yaml")
+	asst.NoError(os.WriteFile(path, []byte("version: 1\nstores:\n  - name: a\n    file: /data/a.json\n"), 0o644))
+	got, err = libs.GetManifestFromFile(path)
+	asst.NoError(err)
+	asst.Equal("/data/a.json", got.Stores[0].File)
+
+	_, err = libs.GetManifestFromFile(filepath.Join(dir, "notfound.yaml"))
+	asst.Error(err)
+
+	_, err = libs.GetManifestFromFile("../testdata/valid.yaml")
+	asst.Error(err)
+}
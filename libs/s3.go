@@ -1,31 +1,28 @@
 package libs
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"io"
 
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/michimani/cfkvs/types"
 )
 
-func NewS3Client(ctx context.Context) (*s3.Client, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	c := s3.NewFromConfig(cfg)
-	return c, nil
+func NewS3Client(cfg aws.Config) *s3.Client {
+	return s3.NewFromConfig(cfg)
 }
 
 type S3Client interface {
 	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
 }
 
-func GetKeyValueStoreData(ctx context.Context, c S3Client, bucket, key string) (*types.KeyValueStoreData, error) {
+// GetKeyValueStoreData reads the key value store data from the S3 object in the format.
+// If the format is DataFormatAuto, it is detected from the extension of the object key.
+func GetKeyValueStoreData(ctx context.Context, c S3Client, bucket, key string, format types.DataFormat) (*types.KeyValueStoreData, error) {
 	input := &s3.GetObjectInput{
 		Bucket: &bucket,
 		Key:    &key,
@@ -41,10 +38,35 @@ func GetKeyValueStoreData(ctx context.Context, c S3Client, bucket, key string) (
 		return nil, err
 	}
 
+	if format == types.DataFormatAuto {
+		format = types.DetectDataFormat(key)
+	}
+
 	kvsData := types.KeyValueStoreData{}
-	if err := json.Unmarshal(bodyBytes, &kvsData); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal key value store data: %w", err)
+	if err := kvsData.FromBytesAs(bodyBytes, format); err != nil {
+		return nil, err
 	}
 
 	return &kvsData, nil
 }
+
+// PutKeyValueStoreData puts the key value store data to the S3 object in the import format.
+func PutKeyValueStoreData(ctx context.Context, c S3Client, bucket, key string, data *types.KeyValueStoreData) error {
+	b, err := data.ToBytes()
+	if err != nil {
+		return err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	}
+
+	if _, err := c.PutObject(ctx, input); err != nil {
+		return err
+	}
+
+	return nil
+}
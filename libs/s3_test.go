@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/michimani/cfkvs/libs"
 	"github.com/michimani/cfkvs/types"
@@ -15,53 +16,10 @@ import (
 )
 
 func Test_NewS3Client(t *testing.T) {
-	cases := []struct {
-		name    string
-		ctx     context.Context
-		envs    map[string]string
-		wantErr bool
-	}{
-		{
-			name: "success",
-			ctx:  context.Background(),
-			envs: map[string]string{
-				"AWS_ACCESS_KEY_ID":     "dummy_key_id",
-				"AWS_SECRET_ACCESS_KEY": "dummy_secret_key",
-				"AWS_SESSION_TOKEN":     "dummy_session_token",
-				"AWS_REGION":            "ap-northeast-1",
-			},
-			wantErr: false,
-		},
-		{
-			name: "failed to load default config",
-			ctx:  context.Background(),
-			envs: map[string]string{
-				"AWS_PROFILE": "invalid",
-				"AWS_REGION":  "ap-northeast-1",
-			},
-			wantErr: true,
-		},
-	}
-
-	for _, c := range cases {
-		t.Run(c.name, func(tt *testing.T) {
-			for k, v := range c.envs {
-				tt.Setenv(k, v)
-			}
+	asst := assert.New(t)
 
-			asst := assert.New(tt)
-
-			sc, err := libs.NewS3Client(c.ctx)
-			if c.wantErr {
-				asst.Error(err)
-				asst.Nil(sc)
-				return
-			}
-
-			asst.NoError(err)
-			asst.NotNil(sc)
-		})
-	}
+	c := libs.NewS3Client(aws.Config{Region: "ap-northeast-1"})
+	asst.NotNil(c)
 }
 
 type errorReader struct{}
@@ -160,6 +118,40 @@ func Test_GetKeyValueStoreData(t *testing.T) {
 			expect:  nil,
 			wantErr: true,
 		},
+		{
+			name: "no data field",
+			ctx:  context.Background(),
+			clientOut: struct {
+				GetObjectOutput *s3.GetObjectOutput
+				Error           error
+			}{
+				GetObjectOutput: &s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader(`{"invalid": []}`)),
+				},
+				Error: nil,
+			},
+			bucket:  "test-bucket",
+			key:     "test-key",
+			expect:  nil,
+			wantErr: true,
+		},
+		{
+			name: "empty value",
+			ctx:  context.Background(),
+			clientOut: struct {
+				GetObjectOutput *s3.GetObjectOutput
+				Error           error
+			}{
+				GetObjectOutput: &s3.GetObjectOutput{
+					Body: io.NopCloser(strings.NewReader(`{"data": [{"key":"k", "value":""}]}`)),
+				},
+				Error: nil,
+			},
+			bucket:  "test-bucket",
+			key:     "test-key",
+			expect:  nil,
+			wantErr: true,
+		},
 	}
 
 	for _, c := range cases {
@@ -172,7 +164,7 @@ func Test_GetKeyValueStoreData(t *testing.T) {
 				Key:    &c.key,
 			}).Return(c.clientOut.GetObjectOutput, c.clientOut.Error)
 
-			kvsData, err := libs.GetKeyValueStoreData(c.ctx, m, c.bucket, c.key)
+			kvsData, err := libs.GetKeyValueStoreData(c.ctx, m, c.bucket, c.key, types.DataFormatAuto)
 			if c.wantErr {
 				asst.Error(err)
 				asst.Nil(kvsData)
@@ -184,3 +176,72 @@ func Test_GetKeyValueStoreData(t *testing.T) {
 		})
 	}
 }
+
+func Test_PutKeyValueStoreData(t *testing.T) {
+	cases := []struct {
+		name      string
+		data      *types.KeyValueStoreData
+		clientErr error
+		noCalled  bool
+		wantErr   bool
+	}{
+		{
+			name: "success",
+			data: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{Key: "key1", Value: "value1"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "nil data",
+			data:     &types.KeyValueStoreData{},
+			noCalled: true,
+			wantErr:  true,
+		},
+		{
+			name: "failed to put object",
+			data: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{Key: "key1", Value: "value1"},
+				},
+			},
+			clientErr: errors.New("failed to put object"),
+			wantErr:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctx := context.Background()
+			ctrl := gomock.NewController(tt)
+			m := libs.NewMockS3Client(ctrl)
+
+			var body []byte
+			if !c.noCalled {
+				m.EXPECT().PutObject(ctx, gomock.Cond(func(x any) bool {
+					in := x.(*s3.PutObjectInput)
+					return *in.Bucket == "test-bucket" && *in.Key == "test-key" && *in.ContentType == "application/json"
+				})).DoAndReturn(func(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+					body, _ = io.ReadAll(in.Body)
+					return &s3.PutObjectOutput{}, c.clientErr
+				})
+			}
+
+			err := libs.PutKeyValueStoreData(ctx, m, "test-bucket", "test-key", c.data)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+
+			// the object can be read as an import source
+			got := types.KeyValueStoreData{}
+			asst.NoError(got.FromBytes(body))
+			asst.Equal(*c.data, got)
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package libs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/michimani/cfkvs/types"
+)
+
+// LatestSnapshotID is the snapshot ID that refers to the latest snapshot of a key value store.
+const LatestSnapshotID = "latest"
+
+const snapshotExtension = ".json"
+
+// snapshotAttempts is the number of times to list the items when the key value store is changed while listing them.
+const snapshotAttempts = 3
+
+// SnapshotStore saves snapshots of key value stores, grouped by the name of the key value store.
+type SnapshotStore interface {
+	// PutSnapshot saves the snapshot and returns its location.
+	PutSnapshot(ctx context.Context, kvsName string, snapshot *types.Snapshot) (string, error)
+	// GetSnapshot reads the snapshot. The ID can be LatestSnapshotID.
+	GetSnapshot(ctx context.Context, kvsName, id string) (*types.Snapshot, error)
+	// ListSnapshots lists the snapshots from the oldest to the latest.
+	ListSnapshots(ctx context.Context, kvsName string) (types.SnapshotSummaryList, error)
+}
+
+// CreateSnapshot takes a snapshot of the items in the key value store.
+// The ETag is got before and after listing the items, and they are listed again if the key value store is changed meanwhile,
+// so that the snapshot is consistent with its ETag.
+func CreateSnapshot(ctx context.Context, cfc CloudFrontClient, kvsc CloudFrontKeyValueStoreClient, kvsName string) (*types.Snapshot, error) {
+	kvs, err := DescribeKeyValueStore(ctx, cfc, kvsc, kvsName)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < snapshotAttempts; attempt++ {
+		before, err := GetKeyValueStoreETag(ctx, kvsc, kvs.ARN)
+		if err != nil {
+			return nil, err
+		}
+
+		items, err := ListItems(ctx, kvsc, kvs.ARN)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := GetKeyValueStoreETag(ctx, kvsc, kvs.ARN)
+		if err != nil {
+			return nil, err
+		}
+
+		if before == after {
+			return types.NewSnapshot(kvs, after, items, time.Now()), nil
+		}
+	}
+
+	return nil, fmt.Errorf("the key value store '%s' kept being changed while listing its items, try again later", kvs.Name)
+}
+
+// validateSnapshotID validates that the ID is a snapshot ID, so that it is safe to use in a path.
+func validateSnapshotID(id string) (time.Time, error) {
+	t, err := time.Parse(types.SnapshotIDLayout, id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot ID: %s", id)
+	}
+	return t, nil
+}
+
+// resolveSnapshotID resolves LatestSnapshotID to the ID of the latest snapshot.
+func resolveSnapshotID(ctx context.Context, store SnapshotStore, kvsName, id string) (string, error) {
+	if id != LatestSnapshotID {
+		if _, err := validateSnapshotID(id); err != nil {
+			return "", err
+		}
+		return id, nil
+	}
+
+	list, err := store.ListSnapshots(ctx, kvsName)
+	if err != nil {
+		return "", err
+	}
+	if len(list) == 0 {
+		return "", fmt.Errorf("no snapshots of the key value store '%s'", kvsName)
+	}
+
+	return list[len(list)-1].ID, nil
+}
+
+func sortSnapshotSummaries(list types.SnapshotSummaryList) {
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+}
+
+// LocalSnapshotStore saves snapshots as files in <Dir>/<kvs name>/<id>.json.
+type LocalSnapshotStore struct {
+	Dir string
+}
+
+func (s *LocalSnapshotStore) path(kvsName, id string) string {
+	return filepath.Join(s.Dir, kvsName, id+snapshotExtension)
+}
+
+func (s *LocalSnapshotStore) PutSnapshot(ctx context.Context, kvsName string, snapshot *types.Snapshot) (string, error) {
+	b, err := snapshot.ToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	path := s.path(kvsName, snapshot.ID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (s *LocalSnapshotStore) GetSnapshot(ctx context.Context, kvsName, id string) (*types.Snapshot, error) {
+	id, err := resolveSnapshotID(ctx, s, kvsName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.path(kvsName, id)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot not found: %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := types.Snapshot{}
+	if err := snapshot.FromBytes(b); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (s *LocalSnapshotStore) ListSnapshots(ctx context.Context, kvsName string) (types.SnapshotSummaryList, error) {
+	dir := filepath.Join(s.Dir, kvsName)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return types.SnapshotSummaryList{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := types.SnapshotSummaryList{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), snapshotExtension)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		createdAt, err := validateSnapshotID(id)
+		if err != nil {
+			// not a snapshot
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, types.SnapshotSummary{
+			ID:        id,
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+			Location:  filepath.Join(dir, entry.Name()),
+		})
+	}
+	sortSnapshotSummaries(list)
+
+	return list, nil
+}
+
+// S3SnapshotStore saves snapshots as objects in s3://<Bucket>/<Prefix><kvs name>/<id>.json.
+type S3SnapshotStore struct {
+	Client S3Client
+	Bucket string
+	Prefix string
+}
+
+func (s *S3SnapshotStore) keyPrefix(kvsName string) string {
+	prefix := s.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix + kvsName + "/"
+}
+
+func (s *S3SnapshotStore) location(key string) string {
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, key)
+}
+
+func (s *S3SnapshotStore) PutSnapshot(ctx context.Context, kvsName string, snapshot *types.Snapshot) (string, error) {
+	b, err := snapshot.ToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	key := s.keyPrefix(kvsName) + snapshot.ID + snapshotExtension
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(b),
+		ContentType: aws.String("application/json"),
+	}
+	if _, err := s.Client.PutObject(ctx, input); err != nil {
+		return "", err
+	}
+
+	return s.location(key), nil
+}
+
+func (s *S3SnapshotStore) GetSnapshot(ctx context.Context, kvsName, id string) (*types.Snapshot, error) {
+	id, err := resolveSnapshotID(ctx, s, kvsName, id)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.keyPrefix(kvsName) + id + snapshotExtension),
+	}
+	out, err := s.Client.GetObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	b, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := types.Snapshot{}
+	if err := snapshot.FromBytes(b); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func (s *S3SnapshotStore) ListSnapshots(ctx context.Context, kvsName string) (types.SnapshotSummaryList, error) {
+	prefix := s.keyPrefix(kvsName)
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.Bucket),
+		Prefix: aws.String(prefix),
+	}
+
+	list := types.SnapshotSummaryList{}
+	for {
+		out, err := s.Client.ListObjectsV2(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range out.Contents {
+			key := aws.ToString(obj.Key)
+			id, ok := strings.CutSuffix(strings.TrimPrefix(key, prefix), snapshotExtension)
+			if !ok {
+				continue
+			}
+			createdAt, err := validateSnapshotID(id)
+			if err != nil {
+				// not a snapshot
+				continue
+			}
+
+			list = append(list, types.SnapshotSummary{
+				ID:        id,
+				CreatedAt: createdAt,
+				Size:      aws.ToInt64(obj.Size),
+				Location:  s.location(key),
+			})
+		}
+
+		if !aws.ToBool(out.IsTruncated) {
+			break
+		}
+		input.ContinuationToken = out.NextContinuationToken
+	}
+	sortSnapshotSummaries(list)
+
+	return list, nil
+}
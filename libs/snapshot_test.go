@@ -0,0 +1,384 @@
+package libs_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	kvs "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore"
+	kvsTypes "github.com/aws/aws-sdk-go-v2/service/cloudfrontkeyvaluestore/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/michimani/cfkvs/libs"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+	gomock "go.uber.org/mock/gomock"
+)
+
+func Test_CreateSnapshot(t *testing.T) {
+	cases := []struct {
+		name string
+		// eTags are returned in order by DescribeKeyValueStore of CloudFrontKeyValueStore,
+		// the first one is for the metadata of the key value store.
+		eTags     []string
+		listTimes int
+		expectTag string
+		wantErr   bool
+	}{
+		{
+			name:      "ok",
+			eTags:     []string{"etag1", "etag1", "etag1"},
+			listTimes: 1,
+			expectTag: "etag1",
+		},
+		{
+			name:      "ok: changed while listing once",
+			eTags:     []string{"etag1", "etag1", "etag2", "etag2", "etag2"},
+			listTimes: 2,
+			expectTag: "etag2",
+		},
+		{
+			name:      "error: kept being changed",
+			eTags:     []string{"etag1", "etag1", "etag2", "etag2", "etag3", "etag3", "etag4"},
+			listTimes: 3,
+			wantErr:   true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			cfc := libs.NewMockCloudFrontClient(ctrl)
+			cfc.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				Return(&cloudfront.DescribeKeyValueStoreOutput{
+					ETag: aws.String("cf_etag"),
+					KeyValueStore: &cfTypes.KeyValueStore{
+						ARN:  aws.String("dummy_arn"),
+						Name: aws.String("kvs-name"),
+					},
+				}, nil)
+
+			kvsc := libs.NewMockCloudFrontKeyValueStoreClient(ctrl)
+			calls := 0
+			kvsc.EXPECT().
+				DescribeKeyValueStore(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, in *kvs.DescribeKeyValueStoreInput, optFns ...func(*kvs.Options)) (*kvs.DescribeKeyValueStoreOutput, error) {
+					eTag := c.eTags[calls]
+					calls++
+					return &kvs.DescribeKeyValueStoreOutput{ETag: aws.String(eTag), ItemCount: aws.Int32(1)}, nil
+				}).
+				Times(len(c.eTags))
+			kvsc.EXPECT().
+				ListKeys(gomock.Any(), gomock.Any()).
+				Return(&kvs.ListKeysOutput{
+					Items: []kvsTypes.ListKeysResponseListItem{
+						{Key: aws.String("key1"), Value: aws.String("value1")},
+					},
+				}, nil).
+				Times(c.listTimes)
+
+			got, err := libs.CreateSnapshot(context.Background(), cfc, kvsc, "kvs-name")
+			if c.wantErr {
+				asst.Error(err)
+				asst.Nil(got)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expectTag, got.ETag)
+			asst.Equal("dummy_arn", got.KeyValueStore.ARN)
+			asst.Equal(int32(1), got.KeyValueStore.ItemCount)
+			asst.Equal([]types.Item{{Key: "key1", Value: "value1"}}, got.Items)
+			asst.Equal(types.ItemsChecksum(got.Items), got.Checksum)
+		})
+	}
+}
+
+func newTestSnapshot(createdAt time.Time, items ...types.Item) *types.Snapshot {
+	return types.NewSnapshot(&types.KeyValueStoreFull{Name: "kvs-name", ARN: "dummy_arn"}, "etag", types.NewItemList(items), createdAt)
+}
+
+func Test_LocalSnapshotStore(t *testing.T) {
+	asst := assert.New(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	store := &libs.LocalSnapshotStore{Dir: dir}
+
+	// no snapshots
+	list, err := store.ListSnapshots(ctx, "kvs-name")
+	asst.NoError(err)
+	asst.Empty(list)
+	_, err = store.GetSnapshot(ctx, "kvs-name", libs.LatestSnapshotID)
+	asst.Error(err)
+
+	older := newTestSnapshot(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), types.Item{Key: "key1", Value: "value1"})
+	newer := newTestSnapshot(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), types.Item{Key: "key1", Value: "value2"})
+	for _, s := range []*types.Snapshot{newer, older} {
+		location, err := store.PutSnapshot(ctx, "kvs-name", s)
+		asst.NoError(err)
+		asst.Equal(filepath.Join(dir, "kvs-name", s.ID+".json"), location)
+	}
+
+	// files that are not snapshots are ignored
+	asst.NoError(os.WriteFile(filepath.Join(dir, "kvs-name", "note.txt"), []byte("note"), 0o644))
+	asst.NoError(os.WriteFile(filepath.Join(dir, "kvs-name", "note.json"), []byte("{}"), 0o644))
+
+	list, err = store.ListSnapshots(ctx, "kvs-name")
+	asst.NoError(err)
+	if asst.Len(list, 2) {
+		asst.Equal(older.ID, list[0].ID)
+		asst.Equal(older.CreatedAt, list[0].CreatedAt)
+		asst.Equal(newer.ID, list[1].ID)
+		asst.Equal(filepath.Join(dir, "kvs-name", newer.ID+".json"), list[1].Location)
+		asst.Greater(list[1].Size, int64(0))
+	}
+
+	got, err := store.GetSnapshot(ctx, "kvs-name", older.ID)
+	asst.NoError(err)
+	asst.Equal(older, got)
+
+	got, err = store.GetSnapshot(ctx, "kvs-name", libs.LatestSnapshotID)
+	asst.NoError(err)
+	asst.Equal(newer, got)
+
+	// other key value stores
+	list, err = store.ListSnapshots(ctx, "other")
+	asst.NoError(err)
+	asst.Empty(list)
+
+	// not found
+	_, err = store.GetSnapshot(ctx, "kvs-name", "20240103T000000.000Z")
+	asst.Error(err)
+
+	// invalid ID
+	_, err = store.GetSnapshot(ctx, "kvs-name", "../../etc/passwd")
+	asst.Error(err)
+
+	// broken snapshot
+	b, err := os.ReadFile(filepath.Join(dir, "kvs-name", older.ID+".json"))
+	asst.NoError(err)
+	broken := strings.Replace(string(b), "value1", "edited", 1)
+	asst.NoError(os.WriteFile(filepath.Join(dir, "kvs-name", older.ID+".json"), []byte(broken), 0o644))
+	_, err = store.GetSnapshot(ctx, "kvs-name", older.ID)
+	asst.Error(err)
+}
+
+func Test_S3SnapshotStore_PutSnapshot(t *testing.T) {
+	cases := []struct {
+		name      string
+		prefix    string
+		putErr    error
+		expectKey string
+		wantErr   bool
+	}{
+		{
+			name:      "ok",
+			prefix:    "snapshots",
+			expectKey: "snapshots/kvs-name/20240101T000000.000Z.json",
+		},
+		{
+			name:      "ok: prefix with slash",
+			prefix:    "snapshots/",
+			expectKey: "snapshots/kvs-name/20240101T000000.000Z.json",
+		},
+		{
+			name:      "ok: no prefix",
+			expectKey: "kvs-name/20240101T000000.000Z.json",
+		},
+		{
+			name:    "error: failed to put object",
+			putErr:  assert.AnError,
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			snapshot := newTestSnapshot(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), types.Item{Key: "key1", Value: "value1"})
+
+			m := libs.NewMockS3Client(ctrl)
+			m.EXPECT().
+				PutObject(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, in *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+					if c.putErr != nil {
+						return nil, c.putErr
+					}
+					asst.Equal("bucket", aws.ToString(in.Bucket))
+					asst.Equal(c.expectKey, aws.ToString(in.Key))
+					b, err := io.ReadAll(in.Body)
+					asst.NoError(err)
+					got := types.Snapshot{}
+					asst.NoError(got.FromBytes(b))
+					asst.Equal(*snapshot, got)
+					return &s3.PutObjectOutput{}, nil
+				})
+
+			store := &libs.S3SnapshotStore{Client: m, Bucket: "bucket", Prefix: c.prefix}
+			location, err := store.PutSnapshot(context.Background(), "kvs-name", snapshot)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal("s3://bucket/"+c.expectKey, location)
+		})
+	}
+}
+
+func Test_S3SnapshotStore_ListSnapshots(t *testing.T) {
+	asst := assert.New(t)
+	ctrl := gomock.NewController(t)
+
+	m := libs.NewMockS3Client(ctrl)
+	gomock.InOrder(
+		m.EXPECT().
+			ListObjectsV2(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, in *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+				asst.Equal("snapshots/kvs-name/", aws.ToString(in.Prefix))
+				asst.Nil(in.ContinuationToken)
+				return &s3.ListObjectsV2Output{
+					Contents: []s3Types.Object{
+						{Key: aws.String("snapshots/kvs-name/20240102T000000.000Z.json"), Size: aws.Int64(20)},
+						{Key: aws.String("snapshots/kvs-name/note.json"), Size: aws.Int64(2)},
+					},
+					IsTruncated:           aws.Bool(true),
+					NextContinuationToken: aws.String("token"),
+				}, nil
+			}),
+		m.EXPECT().
+			ListObjectsV2(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, in *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+				asst.Equal("token", aws.ToString(in.ContinuationToken))
+				return &s3.ListObjectsV2Output{
+					Contents: []s3Types.Object{
+						{Key: aws.String("snapshots/kvs-name/20240101T000000.000Z.json"), Size: aws.Int64(10)},
+					},
+					IsTruncated: aws.Bool(false),
+				}, nil
+			}),
+	)
+
+	store := &libs.S3SnapshotStore{Client: m, Bucket: "bucket", Prefix: "snapshots"}
+	list, err := store.ListSnapshots(context.Background(), "kvs-name")
+	asst.NoError(err)
+	asst.Equal(types.SnapshotSummaryList{
+		{
+			ID:        "20240101T000000.000Z",
+			CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Size:      10,
+			Location:  "s3://bucket/snapshots/kvs-name/20240101T000000.000Z.json",
+		},
+		{
+			ID:        "20240102T000000.000Z",
+			CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			Size:      20,
+			Location:  "s3://bucket/snapshots/kvs-name/20240102T000000.000Z.json",
+		},
+	}, list)
+}
+
+func Test_S3SnapshotStore_GetSnapshot(t *testing.T) {
+	snapshot := newTestSnapshot(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), types.Item{Key: "key1", Value: "value1"})
+	b, err := snapshot.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		id       string
+		objects  []s3Types.Object
+		body     string
+		getErr   error
+		expectID string
+		wantErr  bool
+	}{
+		{
+			name:     "ok",
+			id:       snapshot.ID,
+			body:     string(b),
+			expectID: snapshot.ID,
+		},
+		{
+			name:     "ok: latest",
+			id:       libs.LatestSnapshotID,
+			objects:  []s3Types.Object{{Key: aws.String("kvs-name/" + snapshot.ID + ".json"), Size: aws.Int64(1)}},
+			body:     string(b),
+			expectID: snapshot.ID,
+		},
+		{
+			name:    "error: no snapshots for latest",
+			id:      libs.LatestSnapshotID,
+			objects: []s3Types.Object{},
+			wantErr: true,
+		},
+		{
+			name:    "error: invalid id",
+			id:      "invalid",
+			wantErr: true,
+		},
+		{
+			name:    "error: failed to get object",
+			id:      snapshot.ID,
+			getErr:  assert.AnError,
+			wantErr: true,
+		},
+		{
+			name:    "error: broken snapshot",
+			id:      snapshot.ID,
+			body:    strings.Replace(string(b), "value1", "edited", 1),
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			ctrl := gomock.NewController(tt)
+
+			m := libs.NewMockS3Client(ctrl)
+			if c.objects != nil {
+				m.EXPECT().
+					ListObjectsV2(gomock.Any(), gomock.Any()).
+					Return(&s3.ListObjectsV2Output{Contents: c.objects}, nil)
+			}
+			if c.body != "" || c.getErr != nil {
+				m.EXPECT().
+					GetObject(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, in *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+						if c.getErr != nil {
+							return nil, c.getErr
+						}
+						asst.Equal("kvs-name/"+snapshot.ID+".json", aws.ToString(in.Key))
+						return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(c.body))}, nil
+					})
+			}
+
+			store := &libs.S3SnapshotStore{Client: m, Bucket: "bucket"}
+			got, err := store.GetSnapshot(context.Background(), "kvs-name", c.id)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expectID, got.ID)
+			asst.Equal(snapshot.Items, got.Items)
+		})
+	}
+}
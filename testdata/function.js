@@ -0,0 +1,19 @@
+import cf from 'cloudfront';
+
+const kvsHandle = cf.kvs('kvs-id');
+
+async function handler(event) {
+  const request = event.request;
+
+  if (await kvsHandle.exists(request.uri)) {
+    const redirect = await kvsHandle.get(request.uri, { format: 'json' });
+    return {
+      statusCode: redirect.statusCode,
+      statusDescription: 'Found',
+      headers: { location: { value: redirect.location } },
+    };
+  }
+
+  console.log('no redirect for', request.uri);
+  return request;
+}
@@ -0,0 +1,62 @@
+package types
+
+// DiffDocument is the machine-readable form of ItemListDiff.
+// Its schema is stable, so that CI tools can parse the diff of sync.
+type DiffDocument struct {
+	Added   []Item        `json:"added"`
+	Updated []UpdatedItem `json:"updated"`
+	Deleted []Item        `json:"deleted"`
+	Summary DiffSummary   `json:"summary"`
+}
+
+// UpdatedItem is an item whose value is changed from Before to After.
+type UpdatedItem struct {
+	Key    string `json:"key"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// DiffSummary is the number of changes in the diff.
+type DiffSummary struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+	Total   int `json:"total"`
+}
+
+// Document returns the diff as a DiffDocument.
+// Lists in the document are not nil even if they are empty.
+func (ild *ItemListDiff) Document() *DiffDocument {
+	doc := &DiffDocument{
+		Added:   []Item{},
+		Updated: []UpdatedItem{},
+		Deleted: []Item{},
+	}
+
+	if ild == nil {
+		return doc
+	}
+
+	for _, diff := range ild.Add {
+		doc.Added = append(doc.Added, *diff.After)
+	}
+	for _, diff := range ild.Update {
+		doc.Updated = append(doc.Updated, UpdatedItem{
+			Key:    diff.Before.Key,
+			Before: diff.Before.Value,
+			After:  diff.After.Value,
+		})
+	}
+	for _, diff := range ild.Delete {
+		doc.Deleted = append(doc.Deleted, *diff.Before)
+	}
+
+	doc.Summary = DiffSummary{
+		Added:   len(doc.Added),
+		Updated: len(doc.Updated),
+		Deleted: len(doc.Deleted),
+		Total:   len(doc.Added) + len(doc.Updated) + len(doc.Deleted),
+	}
+
+	return doc
+}
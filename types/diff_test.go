@@ -0,0 +1,64 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ItemListDiff_Document(t *testing.T) {
+	cases := []struct {
+		name   string
+		diff   *types.ItemListDiff
+		expect string
+	}{
+		{
+			name: "ok",
+			diff: types.NewItemList([]types.Item{
+				{Key: "k1", Value: "v1"},
+				{Key: "k2", Value: "v2"},
+			}).Diff(types.NewItemList([]types.Item{
+				{Key: "k2", Value: "v2-new"},
+				{Key: "k3", Value: "v3"},
+			}), true),
+			expect: `{
+  "added": [{"key": "k3", "value": "v3"}],
+  "updated": [{"key": "k2", "before": "v2", "after": "v2-new"}],
+  "deleted": [{"key": "k1", "value": "v1"}],
+  "summary": {"added": 1, "updated": 1, "deleted": 1, "total": 3}
+}`,
+		},
+		{
+			name: "no changes",
+			diff: types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}}).Diff(types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}}), true),
+			expect: `{
+  "added": [],
+  "updated": [],
+  "deleted": [],
+  "summary": {"added": 0, "updated": 0, "deleted": 0, "total": 0}
+}`,
+		},
+		{
+			name: "nil",
+			diff: nil,
+			expect: `{
+  "added": [],
+  "updated": [],
+  "deleted": [],
+  "summary": {"added": 0, "updated": 0, "deleted": 0, "total": 0}
+}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			b, err := json.Marshal(c.diff.Document())
+			asst.NoError(err)
+			asst.JSONEq(c.expect, string(b))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// KeyFilter scopes items by their keys.
+// A key matches the filter if it matches all of the specified conditions.
+// A nil KeyFilter matches any key.
+type KeyFilter struct {
+	// Prefix matches keys that start with it.
+	Prefix string
+	// Glob matches keys with the pattern of path.Match, so "*" does not match "/".
+	Glob string
+	// Regex matches keys that contain a match of it.
+	Regex *regexp.Regexp
+}
+
+// NewKeyFilter returns a KeyFilter with the conditions, or nil if no condition is specified.
+func NewKeyFilter(prefix, glob, regex string) (*KeyFilter, error) {
+	if prefix == "" && glob == "" && regex == "" {
+		return nil, nil
+	}
+
+	f := &KeyFilter{Prefix: prefix, Glob: glob}
+
+	if glob != "" {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", glob, err)
+		}
+	}
+
+	if regex != "" {
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", regex, err)
+		}
+		f.Regex = re
+	}
+
+	return f, nil
+}
+
+// Match reports whether the key matches the filter.
+func (f *KeyFilter) Match(key string) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.Prefix != "" && !strings.HasPrefix(key, f.Prefix) {
+		return false
+	}
+	if f.Glob != "" {
+		// the pattern has been validated in NewKeyFilter
+		if ok, _ := path.Match(f.Glob, key); !ok {
+			return false
+		}
+	}
+	if f.Regex != nil && !f.Regex.MatchString(key) {
+		return false
+	}
+
+	return true
+}
+
+// Filter returns a new ItemList that has only the items whose keys match the filter.
+func (il *ItemList) Filter(f *KeyFilter) *ItemList {
+	if il == nil {
+		return nil
+	}
+
+	items := []Item{}
+	for _, item := range il.Data {
+		if f.Match(item.Key) {
+			items = append(items, item)
+		}
+	}
+
+	return NewItemList(items)
+}
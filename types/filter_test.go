@@ -0,0 +1,131 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewKeyFilter(t *testing.T) {
+	cases := []struct {
+		name      string
+		prefix    string
+		glob      string
+		regex     string
+		expectNil bool
+		wantErr   bool
+	}{
+		{name: "no condition", expectNil: true},
+		{name: "prefix", prefix: "flags/"},
+		{name: "glob", glob: "flags/*"},
+		{name: "regex", regex: "^flags/[a-z]+$"},
+		{name: "error: invalid glob", glob: "flags/[", wantErr: true},
+		{name: "error: invalid regex", regex: "flags/(", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			f, err := types.NewKeyFilter(c.prefix, c.glob, c.regex)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			if c.expectNil {
+				asst.Nil(f)
+				return
+			}
+			asst.NotNil(f)
+		})
+	}
+}
+
+func Test_KeyFilter_Match(t *testing.T) {
+	cases := []struct {
+		name   string
+		prefix string
+		glob   string
+		regex  string
+		key    string
+		expect bool
+	}{
+		{name: "nil filter", key: "any", expect: true},
+		{name: "prefix: match", prefix: "flags/", key: "flags/new-ui", expect: true},
+		{name: "prefix: not match", prefix: "flags/", key: "redirects/old", expect: false},
+		{name: "glob: match", glob: "flags/*", key: "flags/new-ui", expect: true},
+		{name: "glob: * does not match /", glob: "flags/*", key: "flags/team/new-ui", expect: false},
+		{name: "regex: match a part", regex: "new-", key: "flags/new-ui", expect: true},
+		{name: "regex: not match", regex: "^redirects/", key: "flags/new-ui", expect: false},
+		{name: "all conditions: match", prefix: "flags/", glob: "*/new-*", regex: "ui$", key: "flags/new-ui", expect: true},
+		{name: "all conditions: not match one of them", prefix: "flags/", glob: "*/new-*", regex: "api$", key: "flags/new-ui", expect: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			f, err := types.NewKeyFilter(c.prefix, c.glob, c.regex)
+			if err != nil {
+				tt.Fatal(err)
+			}
+
+			asst.Equal(c.expect, f.Match(c.key))
+		})
+	}
+}
+
+func Test_ItemList_Filter(t *testing.T) {
+	prefix := func(p string) *types.KeyFilter {
+		f, _ := types.NewKeyFilter(p, "", "")
+		return f
+	}
+
+	cases := []struct {
+		name   string
+		il     *types.ItemList
+		filter *types.KeyFilter
+		expect *types.ItemList
+	}{
+		{
+			name:   "ok",
+			il:     types.NewItemList([]types.Item{{Key: "flags/a", Value: "1"}, {Key: "redirects/a", Value: "2"}, {Key: "flags/b", Value: "3"}}),
+			filter: prefix("flags/"),
+			expect: types.NewItemList([]types.Item{{Key: "flags/a", Value: "1"}, {Key: "flags/b", Value: "3"}}),
+		},
+		{
+			name:   "no item matches",
+			il:     types.NewItemList([]types.Item{{Key: "redirects/a", Value: "2"}}),
+			filter: prefix("flags/"),
+			expect: types.NewItemList([]types.Item{}),
+		},
+		{
+			name:   "nil filter",
+			il:     types.NewItemList([]types.Item{{Key: "redirects/a", Value: "2"}}),
+			filter: nil,
+			expect: types.NewItemList([]types.Item{{Key: "redirects/a", Value: "2"}}),
+		},
+		{
+			name:   "nil",
+			il:     nil,
+			filter: prefix("flags/"),
+			expect: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			filtered := c.il.Filter(c.filter)
+			if c.expect == nil {
+				asst.Nil(filtered)
+				return
+			}
+			asst.Equal(c.expect.Data, filtered.Data)
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package types
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DataFormat is the format of the source data of key value store.
+type DataFormat string
+
+const (
+	// DataFormatAuto means that the format is detected from the extension of the file or the S3 object key.
+	DataFormatAuto   DataFormat = ""
+	DataFormatJSON   DataFormat = "json"
+	DataFormatCSV    DataFormat = "csv"
+	DataFormatTSV    DataFormat = "tsv"
+	DataFormatYAML   DataFormat = "yaml"
+	DataFormatNDJSON DataFormat = "ndjson"
+	DataFormatDotenv DataFormat = "dotenv"
+)
+
+var dataFormats = []DataFormat{
+	DataFormatJSON,
+	DataFormatCSV,
+	DataFormatTSV,
+	DataFormatYAML,
+	DataFormatNDJSON,
+	DataFormatDotenv,
+}
+
+// ParseDataFormat returns the data format that has the name.
+// An empty name or "auto" is DataFormatAuto.
+func ParseDataFormat(name string) (DataFormat, error) {
+	name = strings.ToLower(name)
+	if name == "" || name == "auto" {
+		return DataFormatAuto, nil
+	}
+
+	for _, f := range dataFormats {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+
+	return DataFormatAuto, fmt.Errorf("unsupported data format: %s", name)
+}
+
+// DetectDataFormat returns the data format of the file from its extension.
+// It falls back to JSON for an unknown extension.
+func DetectDataFormat(path string) DataFormat {
+	base := strings.ToLower(filepath.Base(path))
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return DataFormatDotenv
+	}
+
+	switch filepath.Ext(base) {
+	case ".csv":
+		return DataFormatCSV
+	case ".tsv":
+		return DataFormatTSV
+	case ".yaml", ".yml":
+		return DataFormatYAML
+	case ".ndjson", ".jsonl":
+		return DataFormatNDJSON
+	case ".env":
+		return DataFormatDotenv
+	default:
+		return DataFormatJSON
+	}
+}
+
+// FromBytesAs unmarshals the data in the format into the key value store data.
+// DataFormatAuto is treated as JSON, because there is no path to detect the format from.
+func (kd *KeyValueStoreData) FromBytesAs(b []byte, format DataFormat) error {
+	if kd == nil {
+		return fmt.Errorf("failed to unmarshal key value store data due to nil pointer")
+	}
+
+	var items []Item
+	var err error
+	switch format {
+	case DataFormatAuto, DataFormatJSON:
+		return kd.FromBytes(b)
+	case DataFormatCSV:
+		items, err = itemsFromDelimited(b, ',')
+	case DataFormatTSV:
+		items, err = itemsFromDelimited(b, '\t')
+	case DataFormatYAML:
+		items, err = itemsFromYAML(b)
+	case DataFormatNDJSON:
+		items, err = itemsFromNDJSON(b)
+	case DataFormatDotenv:
+		items, err = itemsFromDotenv(b)
+	default:
+		return fmt.Errorf("unsupported data format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal key value store data as %s: %w", format, err)
+	}
+
+	kd.Data = &items
+
+	return kd.validate()
+}
+
+// itemsFromDelimited reads CSV or TSV.
+// If the first row has "key" and "value" columns, it is a header and the columns are used.
+// Otherwise, every row must have exactly two columns of a key and a value.
+// A leading UTF-8 BOM, that Excel writes, is ignored.
+func itemsFromDelimited(b []byte, comma rune) ([]Item, error) {
+	b = bytes.TrimPrefix(b, []byte("\ufeff"))
+	r := csv.NewReader(bytes.NewReader(b))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	if comma == '\t' {
+		r.LazyQuotes = true
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	keyCol, valueCol := 0, 1
+	hasHeader := false
+	if len(records) > 0 {
+		k, v := -1, -1
+		for i, col := range records[0] {
+			switch strings.ToLower(strings.TrimSpace(col)) {
+			case "key":
+				k = i
+			case "value":
+				v = i
+			}
+		}
+		if k >= 0 && v >= 0 {
+			keyCol, valueCol, hasHeader = k, v, true
+		}
+	}
+
+	items := []Item{}
+	for i, record := range records {
+		if i == 0 && hasHeader {
+			continue
+		}
+		if !hasHeader && len(record) != 2 {
+			return nil, fmt.Errorf("line %d: expected 2 columns of key and value, but got %d", i+1, len(record))
+		}
+		if keyCol >= len(record) || valueCol >= len(record) {
+			return nil, fmt.Errorf("line %d: key or value column is missing", i+1)
+		}
+		items = append(items, Item{Key: record[keyCol], Value: record[valueCol]})
+	}
+
+	return items, nil
+}
+
+// itemsFromYAML reads a map of keys to values, a list of {key, value}, or the same envelope as JSON.
+func itemsFromYAML(b []byte) ([]Item, error) {
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return []Item{}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yaml.MappingNode && len(root.Content) == 2 && root.Content[0].Value == "data" && root.Content[1].Kind == yaml.SequenceNode {
+		root = root.Content[1]
+	}
+
+	items := []Item{}
+	switch root.Kind {
+	case yaml.MappingNode:
+		// keep the order of the keys in the document
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			k, v := root.Content[i], root.Content[i+1]
+			if v.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: the value of '%s' must be a scalar", v.Line, k.Value)
+			}
+			items = append(items, Item{Key: k.Value, Value: v.Value})
+		}
+	case yaml.SequenceNode:
+		for _, n := range root.Content {
+			item := Item{}
+			if err := n.Decode(&item); err != nil {
+				return nil, fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			items = append(items, item)
+		}
+	default:
+		return nil, fmt.Errorf("line %d: data must be a map or a list", root.Line)
+	}
+
+	return items, nil
+}
+
+// itemsFromNDJSON reads a {"key": ..., "value": ...} object per line.
+func itemsFromNDJSON(b []byte) ([]Item, error) {
+	items := []Item{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(make([]byte, 0, 64*1024), MaxKeyValueStoreSize)
+	for n := 1; s.Scan(); n++ {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		item := Item{}
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		items = append(items, item)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// itemsFromDotenv reads KEY=VALUE lines. Empty lines, comments and the "export" prefix are ignored,
+// quoted values are unquoted, and inline comments after values are removed.
+func itemsFromDotenv(b []byte) ([]Item, error) {
+	items := []Item{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(make([]byte, 0, 64*1024), MaxKeyValueStoreSize)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+		value, err := dotenvValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		items = append(items, Item{Key: strings.TrimSpace(key), Value: value})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// dotenvValue returns the value of a KEY=VALUE line, unquoted and without an inline comment.
+// In an unquoted value, "#" starts a comment only after a white space, so that values like "#fff" or URLs with fragments are kept.
+func dotenvValue(value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	var quoted, rest string
+	switch {
+	case strings.HasPrefix(value, `"`):
+		prefix, err := strconv.QuotedPrefix(value)
+		if err != nil {
+			return "", err
+		}
+		if quoted, err = strconv.Unquote(prefix); err != nil {
+			return "", err
+		}
+		rest = value[len(prefix):]
+	case strings.HasPrefix(value, "'"):
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("the single quote of the value is not closed")
+		}
+		quoted, rest = value[1:end+1], value[end+2:]
+	default:
+		for i := 1; i < len(value); i++ {
+			if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+				return strings.TrimSpace(value[:i]), nil
+			}
+		}
+		return value, nil
+	}
+
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("unexpected characters after the quoted value: %s", rest)
+	}
+	return quoted, nil
+}
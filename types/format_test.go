@@ -0,0 +1,259 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseDataFormat(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		expect  types.DataFormat
+		wantErr bool
+	}{
+		{name: "empty", in: "", expect: types.DataFormatAuto},
+		{name: "auto", in: "auto", expect: types.DataFormatAuto},
+		{name: "csv", in: "csv", expect: types.DataFormatCSV},
+		{name: "upper case", in: "YAML", expect: types.DataFormatYAML},
+		{name: "unsupported", in: "xml", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			f, err := types.ParseDataFormat(c.in)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, f)
+		})
+	}
+}
+
+func Test_DetectDataFormat(t *testing.T) {
+	cases := []struct {
+		path   string
+		expect types.DataFormat
+	}{
+		{path: "data.json", expect: types.DataFormatJSON},
+		{path: "dir/data.CSV", expect: types.DataFormatCSV},
+		{path: "data.tsv", expect: types.DataFormatTSV},
+		{path: "data.yaml", expect: types.DataFormatYAML},
+		{path: "data.yml", expect: types.DataFormatYAML},
+		{path: "data.ndjson", expect: types.DataFormatNDJSON},
+		{path: "data.jsonl", expect: types.DataFormatNDJSON},
+		{path: "flags.env", expect: types.DataFormatDotenv},
+		{path: ".env", expect: types.DataFormatDotenv},
+		{path: "dir/.env.production", expect: types.DataFormatDotenv},
+		{path: "data", expect: types.DataFormatJSON},
+		{path: "data.txt", expect: types.DataFormatJSON},
+	}
+
+	for _, c := range cases {
+		t.Run(c.path, func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, types.DetectDataFormat(c.path))
+		})
+	}
+}
+
+func Test_KeyValueStoreData_FromBytesAs(t *testing.T) {
+	cases := []struct {
+		name    string
+		kvsd    *types.KeyValueStoreData
+		format  types.DataFormat
+		in      string
+		expect  []types.Item
+		wantErr bool
+	}{
+		{
+			name:   "json",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatJSON,
+			in:     `{"data":[{"key":"k1","value":"v1"}]}`,
+			expect: []types.Item{{Key: "k1", Value: "v1"}},
+		},
+		{
+			name:   "auto is json",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatAuto,
+			in:     `{"data":[{"key":"k1","value":"v1"}]}`,
+			expect: []types.Item{{Key: "k1", Value: "v1"}},
+		},
+		{
+			name:   "csv with header in any order",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatCSV,
+			in:     "note,Value,Key\nfirst,v1,k1\nsecond,\"v,2\",k2\n",
+			expect: []types.Item{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v,2"}},
+		},
+		{
+			name:   "csv without header",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatCSV,
+			in:     "k1,v1\nk2,v2\n",
+			expect: []types.Item{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}},
+		},
+		{
+			name:    "csv without header has 3 columns",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatCSV,
+			in:      "k1,v1,x\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv with empty value",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatCSV,
+			in:      "key,value\nk1,\n",
+			wantErr: true,
+		},
+		{
+			name:   "csv with BOM",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatCSV,
+			in:     "\ufeffkey,value\r\n/a,/b\r\n",
+			expect: []types.Item{{Key: "/a", Value: "/b"}},
+		},
+		{
+			name:   "tsv with BOM",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatTSV,
+			in:     "\ufeffkey\tvalue\n/a\t/b\n",
+			expect: []types.Item{{Key: "/a", Value: "/b"}},
+		},
+		{
+			name:   "tsv with quote in value",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatTSV,
+			in:     "key\tvalue\nk1\t{\"a\": 1}\n",
+			expect: []types.Item{{Key: "k1", Value: `{"a": 1}`}},
+		},
+		{
+			name:   "yaml map keeps order and stringifies scalars",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatYAML,
+			in:     "zeta: 1\nalpha: true\n/old: /new\n",
+			expect: []types.Item{{Key: "zeta", Value: "1"}, {Key: "alpha", Value: "true"}, {Key: "/old", Value: "/new"}},
+		},
+		{
+			name:   "yaml list",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatYAML,
+			in:     "- key: k1\n  value: v1\n- key: k2\n  value: v2\n",
+			expect: []types.Item{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}},
+		},
+		{
+			name:   "yaml envelope",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatYAML,
+			in:     "data:\n  - key: k1\n    value: v1\n",
+			expect: []types.Item{{Key: "k1", Value: "v1"}},
+		},
+		{
+			name:    "yaml nested value",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatYAML,
+			in:      "k1:\n  nested: v1\n",
+			wantErr: true,
+		},
+		{
+			name:    "yaml scalar document",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatYAML,
+			in:      "value\n",
+			wantErr: true,
+		},
+		{
+			name:   "ndjson skips empty lines",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatNDJSON,
+			in:     "{\"key\":\"k1\",\"value\":\"v1\"}\n\n{\"key\":\"k2\",\"value\":\"v2\"}",
+			expect: []types.Item{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}},
+		},
+		{
+			name:    "ndjson invalid line",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatNDJSON,
+			in:      "{\"key\":\"k1\",\"value\":\"v1\"}\ninvalid\n",
+			wantErr: true,
+		},
+		{
+			name:   "dotenv",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatDotenv,
+			in:     "# comment\nK1=v1\nexport K2 = \"line1\\nline2\"\nK3='a=b'\n",
+			expect: []types.Item{{Key: "K1", Value: "v1"}, {Key: "K2", Value: "line1\nline2"}, {Key: "K3", Value: "a=b"}},
+		},
+		{
+			name:   "dotenv with inline comments",
+			kvsd:   &types.KeyValueStoreData{},
+			format: types.DataFormatDotenv,
+			in:     "K1=v1 # note\nK2=\"a # b\" # note\nK3='c'\t# note\nK4=#fff\nK5=https://example.com/#top\n",
+			expect: []types.Item{
+				{Key: "K1", Value: "v1"},
+				{Key: "K2", Value: "a # b"},
+				{Key: "K3", Value: "c"},
+				{Key: "K4", Value: "#fff"},
+				{Key: "K5", Value: "https://example.com/#top"},
+			},
+		},
+		{
+			name:    "dotenv with characters after the quoted value",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatDotenv,
+			in:      "K1=\"a\" b\n",
+			wantErr: true,
+		},
+		{
+			name:    "dotenv with unclosed single quote",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatDotenv,
+			in:      "K1='a\n",
+			wantErr: true,
+		},
+		{
+			name:    "dotenv without equal",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormatDotenv,
+			in:      "K1\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format",
+			kvsd:    &types.KeyValueStoreData{},
+			format:  types.DataFormat("xml"),
+			in:      "<data/>",
+			wantErr: true,
+		},
+		{
+			name:    "nil",
+			kvsd:    nil,
+			format:  types.DataFormatCSV,
+			in:      "k1,v1\n",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.kvsd.FromBytesAs([]byte(c.in), c.format)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, *c.kvsd.Data)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+)
+
+// FunctionAssociation is a stage of a CloudFront Function that is associated with a key value store.
+type FunctionAssociation struct {
+	Name   string `json:"name"`
+	Stage  string `json:"stage"`
+	Status string `json:"status"`
+	ARN    string `json:"arn"`
+}
+
+type FunctionAssociationList []FunctionAssociation
+
+func (fl *FunctionAssociationList) Parse(o []cfTypes.FunctionSummary) error {
+	if fl == nil {
+		return fmt.Errorf("FunctionAssociationList is nil")
+	}
+
+	if *fl == nil {
+		*fl = FunctionAssociationList{}
+	}
+
+	for _, fn := range o {
+		fa := FunctionAssociation{
+			Name:   aws.ToString(fn.Name),
+			Status: aws.ToString(fn.Status),
+		}
+		if fn.FunctionMetadata != nil {
+			fa.Stage = string(fn.FunctionMetadata.Stage)
+			fa.ARN = aws.ToString(fn.FunctionMetadata.FunctionARN)
+		}
+		*fl = append(*fl, fa)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	cfTypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FunctionAssociationList_Parse(t *testing.T) {
+	cases := []struct {
+		name    string
+		fl      *types.FunctionAssociationList
+		o       []cfTypes.FunctionSummary
+		expect  *types.FunctionAssociationList
+		wantErr bool
+	}{
+		{
+			name: "ok",
+			fl:   &types.FunctionAssociationList{},
+			o: []cfTypes.FunctionSummary{
+				{
+					Name:   aws.String("fn"),
+					Status: aws.String("DEPLOYED"),
+					FunctionMetadata: &cfTypes.FunctionMetadata{
+						Stage:       cfTypes.FunctionStageLive,
+						FunctionARN: aws.String("arn:aws:cloudfront::123456789012:function/fn"),
+					},
+				},
+				{
+					Name: aws.String("no-metadata"),
+				},
+			},
+			expect: &types.FunctionAssociationList{
+				{Name: "fn", Stage: "LIVE", Status: "DEPLOYED", ARN: "arn:aws:cloudfront::123456789012:function/fn"},
+				{Name: "no-metadata"},
+			},
+		},
+		{
+			name:   "ok: empty",
+			fl:     new(types.FunctionAssociationList),
+			o:      nil,
+			expect: &types.FunctionAssociationList{},
+		},
+		{
+			name:    "error: nil",
+			fl:      nil,
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.fl.Parse(c.o)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, c.fl)
+		})
+	}
+}
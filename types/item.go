@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -47,23 +48,16 @@ func (kd *KeyValueStoreData) FromBytes(b []byte) error {
 		return fmt.Errorf("failed to unmarshal key value store data: %w\n%s", err, invalidDataStructureErrorMessage)
 	}
 
-	if kd.Data == nil {
-		return fmt.Errorf("failed to unmarshal key value store data: invalid data structure\n%s", invalidDataStructureErrorMessage)
-	}
-
-	for _, item := range *kd.Data {
-		if item.Key == "" || item.Value == "" {
-			return fmt.Errorf("failed to unmarshal key value store data: invalid data structure\n%s", invalidDataStructureErrorMessage)
-		}
-	}
-
-	return nil
+	return kd.validate()
 }
 
 func (kd *KeyValueStoreData) ToItemList() *ItemList {
 	if kd == nil {
 		return nil
 	}
+	if kd.Data == nil {
+		return NewItemList(nil)
+	}
 
 	il := &ItemList{
 		Data:  []Item{},
@@ -78,6 +72,15 @@ func (kd *KeyValueStoreData) ToItemList() *ItemList {
 	return il
 }
 
+// ToBytes returns the key value store data in the format that can be used as an import source.
+func (kd *KeyValueStoreData) ToBytes() ([]byte, error) {
+	if kd == nil || kd.Data == nil {
+		return nil, fmt.Errorf("failed to marshal key value store data due to nil pointer")
+	}
+
+	return json.MarshalIndent(kd, "", "  ")
+}
+
 func (i *Item) Parse(o any) error {
 	if i == nil {
 		return fmt.Errorf("failed to parse Item due to nil pointer")
@@ -99,6 +102,17 @@ func (i *Item) Parse(o any) error {
 	}
 }
 
+// MinifyJSON removes insignificant white spaces from the JSON value,
+// so that it takes less of the value size limit.
+func MinifyJSON(value string) (string, error) {
+	b := bytes.Buffer{}
+	if err := json.Compact(&b, []byte(value)); err != nil {
+		return "", fmt.Errorf("failed to minify the value as JSON: %w", err)
+	}
+
+	return b.String(), nil
+}
+
 type ItemList struct {
 	Data  []Item
 	kvMap map[string]*Item
@@ -124,6 +138,27 @@ func NewItemList(items []Item) *ItemList {
 	return il
 }
 
+// Get returns the item of the key, and whether the key exists in the list.
+func (il *ItemList) Get(key string) (*Item, bool) {
+	if il == nil || il.kvMap == nil {
+		return nil, false
+	}
+
+	item, ok := il.kvMap[key]
+	return item, ok
+}
+
+func (il *ItemList) ToKeyValueStoreData() *KeyValueStoreData {
+	if il == nil {
+		return nil
+	}
+
+	data := make([]Item, len(il.Data))
+	copy(data, il.Data)
+
+	return &KeyValueStoreData{Data: &data}
+}
+
 func (il *ItemList) Parse(o *kvs.ListKeysOutput) error {
 	if il == nil {
 		return fmt.Errorf("failed to parse ItemList due to nil pointer")
@@ -154,14 +189,14 @@ func (il *ItemList) Parse(o *kvs.ListKeysOutput) error {
 }
 
 type ItemDiff struct {
-	Before *Item
-	After  *Item
+	Before *Item `json:"before"`
+	After  *Item `json:"after"`
 }
 
 type ItemListDiff struct {
-	Add    []ItemDiff
-	Update []ItemDiff
-	Delete []ItemDiff
+	Add    []ItemDiff `json:"add"`
+	Update []ItemDiff `json:"update"`
+	Delete []ItemDiff `json:"delete"`
 }
 
 func (il *ItemList) Diff(afterList *ItemList, delete bool) *ItemListDiff {
@@ -222,6 +257,36 @@ func (il *ItemList) Diff(afterList *ItemList, delete bool) *ItemListDiff {
 	return diff
 }
 
+// DeleteDiff returns the diff that deletes the items of the keys.
+// Keys that are not in the list are ignored, so that only existing items are deleted.
+func (il *ItemList) DeleteDiff(keys []string) *ItemListDiff {
+	diff := &ItemListDiff{
+		Add:    []ItemDiff{},
+		Update: []ItemDiff{},
+		Delete: []ItemDiff{},
+	}
+
+	if il == nil {
+		return diff
+	}
+
+	seen := map[string]bool{}
+	for _, key := range keys {
+		before, ok := il.kvMap[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		diff.Delete = append(diff.Delete, ItemDiff{
+			Before: before,
+			After:  nil,
+		})
+	}
+
+	return diff
+}
+
 // PutList returns a list of items to put.
 // This list uses for sync items.
 func (ild *ItemListDiff) PutList() []Item {
@@ -253,3 +318,52 @@ func (ild *ItemListDiff) DeleteList() []Item {
 	}
 	return items
 }
+
+// IsEmpty reports whether the diff has no change.
+func (ild *ItemListDiff) IsEmpty() bool {
+	if ild == nil {
+		return true
+	}
+	return len(ild.Add) == 0 && len(ild.Update) == 0 && len(ild.Delete) == 0
+}
+
+// IsSubsetOf reports whether every change in the diff is also in the other diff with the same values.
+// It is used to check that a diff recomputed after a conflict does not have any change that has not been approved.
+func (ild *ItemListDiff) IsSubsetOf(other *ItemListDiff) bool {
+	if ild == nil {
+		return true
+	}
+	if other == nil {
+		return ild.IsEmpty()
+	}
+
+	contains := func(diffs []ItemDiff, d ItemDiff) bool {
+		for _, o := range diffs {
+			if equalItem(o.Before, d.Before) && equalItem(o.After, d.After) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, pair := range []struct{ sub, all []ItemDiff }{
+		{ild.Add, other.Add},
+		{ild.Update, other.Update},
+		{ild.Delete, other.Delete},
+	} {
+		for _, d := range pair.sub {
+			if !contains(pair.all, d) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func equalItem(a, b *Item) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
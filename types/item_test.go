@@ -131,6 +131,11 @@ func Test_KeyValueStoreData_ToItemList(t *testing.T) {
 			},
 			expectData: []types.Item{},
 		},
+		{
+			name:       "nil data",
+			kvsd:       &types.KeyValueStoreData{},
+			expectData: []types.Item{},
+		},
 		{
 			name:       "nil",
 			kvsd:       nil,
@@ -158,6 +163,54 @@ func Test_KeyValueStoreData_ToItemList(t *testing.T) {
 	}
 }
 
+func Test_KeyValueStoreData_ToBytes(t *testing.T) {
+	cases := []struct {
+		name    string
+		kvsd    *types.KeyValueStoreData
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			kvsd: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{Key: "key1", Value: "value1"},
+					{Key: "key2", Value: "value2"},
+				},
+			},
+		},
+		{
+			name:    "nil data",
+			kvsd:    &types.KeyValueStoreData{},
+			wantErr: true,
+		},
+		{
+			name:    "nil",
+			kvsd:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			b, err := c.kvsd.ToBytes()
+			if c.wantErr {
+				asst.Error(err)
+				asst.Nil(b)
+				return
+			}
+
+			asst.NoError(err)
+
+			// the bytes can be read as an import source
+			got := types.KeyValueStoreData{}
+			asst.NoError(got.FromBytes(b))
+			asst.Equal(*c.kvsd, got)
+		})
+	}
+}
+
 func Test_Item_Parse(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -263,6 +316,94 @@ func Test_NewItemList(t *testing.T) {
 	}
 }
 
+func Test_ItemList_ToKeyValueStoreData(t *testing.T) {
+	cases := []struct {
+		name   string
+		il     *types.ItemList
+		expect *types.KeyValueStoreData
+	}{
+		{
+			name: "normal",
+			il: types.NewItemList([]types.Item{
+				{Key: "key1", Value: "value1"},
+				{Key: "key2", Value: "value2"},
+			}),
+			expect: &types.KeyValueStoreData{
+				Data: &[]types.Item{
+					{Key: "key1", Value: "value1"},
+					{Key: "key2", Value: "value2"},
+				},
+			},
+		},
+		{
+			name:   "empty",
+			il:     types.NewItemList(nil),
+			expect: &types.KeyValueStoreData{Data: &[]types.Item{}},
+		},
+		{
+			name:   "nil",
+			il:     nil,
+			expect: nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			kvsd := c.il.ToKeyValueStoreData()
+			asst.Equal(c.expect, kvsd)
+		})
+	}
+}
+
+func Test_ItemList_Get(t *testing.T) {
+	il := types.NewItemList([]types.Item{
+		{Key: "key1", Value: "value1"},
+		{Key: "key2", Value: "value2"},
+	})
+
+	cases := []struct {
+		name     string
+		il       *types.ItemList
+		key      string
+		expect   *types.Item
+		expectOK bool
+	}{
+		{
+			name:     "exists",
+			il:       il,
+			key:      "key2",
+			expect:   &types.Item{Key: "key2", Value: "value2"},
+			expectOK: true,
+		},
+		{
+			name:     "not exists",
+			il:       il,
+			key:      "key3",
+			expect:   nil,
+			expectOK: false,
+		},
+		{
+			name:     "nil",
+			il:       nil,
+			key:      "key1",
+			expect:   nil,
+			expectOK: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			item, ok := c.il.Get(c.key)
+			asst.Equal(c.expectOK, ok)
+			asst.Equal(c.expect, item)
+		})
+	}
+}
+
 func Test_ItemList_Parse(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -424,6 +565,39 @@ func Test_ItemList_Diff(t *testing.T) {
 	}
 }
 
+func Test_ItemList_DeleteDiff(t *testing.T) {
+	cases := []struct {
+		name   string
+		il     *types.ItemList
+		keys   []string
+		expect []types.Item
+	}{
+		{
+			name:   "ok",
+			il:     types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2"}, {Key: "k3", Value: "v3"}}),
+			keys:   []string{"k3", "k1", "k3", "not-found"},
+			expect: []types.Item{{Key: "k3", Value: "v3"}, {Key: "k1", Value: "v1"}},
+		},
+		{
+			name:   "nil",
+			il:     nil,
+			keys:   []string{"k1"},
+			expect: []types.Item{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			diff := c.il.DeleteDiff(c.keys)
+			asst.Empty(diff.Add)
+			asst.Empty(diff.Update)
+			asst.Equal(c.expect, diff.DeleteList())
+		})
+	}
+}
+
 func Test_ItemListDiff_PutList(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -527,3 +701,153 @@ func Test_ItemListDiff_DeleteList(t *testing.T) {
 		})
 	}
 }
+
+func Test_ItemListDiff_IsEmpty(t *testing.T) {
+	cases := []struct {
+		name   string
+		diff   *types.ItemListDiff
+		expect bool
+	}{
+		{
+			name:   "empty",
+			diff:   types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}}).Diff(types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}}), true),
+			expect: true,
+		},
+		{
+			name:   "has an update",
+			diff:   types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}}).Diff(types.NewItemList([]types.Item{{Key: "k1", Value: "v2"}}), true),
+			expect: false,
+		},
+		{
+			name:   "has a delete",
+			diff:   types.NewItemList([]types.Item{{Key: "k1", Value: "v1"}}).Diff(types.NewItemList(nil), true),
+			expect: false,
+		},
+		{
+			name:   "nil",
+			diff:   nil,
+			expect: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, c.diff.IsEmpty())
+		})
+	}
+}
+
+func Test_ItemListDiff_IsSubsetOf(t *testing.T) {
+	approved := &types.ItemListDiff{
+		Add:    []types.ItemDiff{{After: &types.Item{Key: "k1", Value: "v1"}}},
+		Update: []types.ItemDiff{{Before: &types.Item{Key: "k2", Value: "old"}, After: &types.Item{Key: "k2", Value: "new"}}},
+		Delete: []types.ItemDiff{{Before: &types.Item{Key: "k3", Value: "v3"}}},
+	}
+
+	cases := []struct {
+		name   string
+		ild    *types.ItemListDiff
+		other  *types.ItemListDiff
+		expect bool
+	}{
+		{
+			name:   "same",
+			ild:    approved,
+			other:  approved,
+			expect: true,
+		},
+		{
+			name: "some changes have been applied",
+			ild: &types.ItemListDiff{
+				Update: []types.ItemDiff{{Before: &types.Item{Key: "k2", Value: "old"}, After: &types.Item{Key: "k2", Value: "new"}}},
+			},
+			other:  approved,
+			expect: true,
+		},
+		{
+			name:   "empty",
+			ild:    &types.ItemListDiff{},
+			other:  approved,
+			expect: true,
+		},
+		{
+			name: "before value has been changed",
+			ild: &types.ItemListDiff{
+				Update: []types.ItemDiff{{Before: &types.Item{Key: "k2", Value: "changed"}, After: &types.Item{Key: "k2", Value: "new"}}},
+			},
+			other:  approved,
+			expect: false,
+		},
+		{
+			name: "added key has been put by another write",
+			ild: &types.ItemListDiff{
+				Update: []types.ItemDiff{{Before: &types.Item{Key: "k1", Value: "other"}, After: &types.Item{Key: "k1", Value: "v1"}}},
+			},
+			other:  approved,
+			expect: false,
+		},
+		{
+			name: "new key to delete",
+			ild: &types.ItemListDiff{
+				Delete: []types.ItemDiff{{Before: &types.Item{Key: "k4", Value: "v4"}}},
+			},
+			other:  approved,
+			expect: false,
+		},
+		{
+			name:   "nil",
+			ild:    nil,
+			other:  approved,
+			expect: true,
+		},
+		{
+			name:   "other is nil",
+			ild:    approved,
+			other:  nil,
+			expect: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, c.ild.IsSubsetOf(c.other))
+		})
+	}
+}
+
+func Test_MinifyJSON(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "ok",
+			value:  "{\n  \"a\": 1,\n  \"b\": [\"x y\", 2]\n}\n",
+			expect: `{"a":1,"b":["x y",2]}`,
+		},
+		{
+			name:    "error: not json",
+			value:   "<p>html</p>",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			v, err := types.MinifyJSON(c.value)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, v)
+		})
+	}
+}
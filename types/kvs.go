@@ -68,6 +68,18 @@ func (k *KVS) Parse(o any) error {
 		k.ARN = aws.ToString(o.KeyValueStore.ARN)
 		return nil
 
+	case *cf.UpdateKeyValueStoreOutput:
+		if o.KeyValueStore == nil {
+			return fmt.Errorf("KeyValueStore is nil")
+		}
+
+		k.Id = aws.ToString(o.KeyValueStore.Id)
+		k.Name = aws.ToString(o.KeyValueStore.Name)
+		k.Comment = aws.ToString(o.KeyValueStore.Comment)
+		k.Status = aws.ToString(o.KeyValueStore.Status)
+		k.ARN = aws.ToString(o.KeyValueStore.ARN)
+		return nil
+
 	case *cfTypes.KeyValueStore:
 		k.Id = aws.ToString(o.Id)
 		k.Name = aws.ToString(o.Name)
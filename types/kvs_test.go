@@ -59,6 +59,26 @@ func Test_KVS_Parse(t *testing.T) {
 				ARN:     "arn",
 			},
 		},
+		{
+			name: "CloudFront UpdateKeyValueStoreOutput",
+			k:    &types.KVS{},
+			o: &cf.UpdateKeyValueStoreOutput{
+				KeyValueStore: &cfTypes.KeyValueStore{
+					Id:      aws.String("id"),
+					Name:    aws.String("name"),
+					Comment: aws.String("comment"),
+					Status:  aws.String("status"),
+					ARN:     aws.String("arn"),
+				},
+			},
+			expect: types.KVS{
+				Id:      "id",
+				Name:    "name",
+				Comment: "comment",
+				Status:  "status",
+				ARN:     "arn",
+			},
+		},
 		{
 			name: "CloudFront KeyValueStore",
 			k:    &types.KVS{},
@@ -105,6 +125,14 @@ func Test_KVS_Parse(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "nil KeyValueStore on UpdateKeyValueStoreOutput",
+			k:    &types.KVS{},
+			o: &cf.UpdateKeyValueStoreOutput{
+				KeyValueStore: nil,
+			},
+			wantErr: true,
+		},
 		{
 			name: "nil KeyValueStore on DescribeKeyValueStoreOutput",
 			k:    &types.KVS{},
@@ -0,0 +1,152 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestVersion is the version of the manifest file format.
+const ManifestVersion = 1
+
+// Manifest declares key value stores and the sources of their items, to apply them all at once.
+type Manifest struct {
+	Version int             `yaml:"version"`
+	Stores  []ManifestStore `yaml:"stores"`
+}
+
+// ManifestStore is a key value store in the manifest.
+// The source is a file or an S3 object, like the flags of sync.
+type ManifestStore struct {
+	Name string `yaml:"name"`
+	// Comment is the comment of the key value store. It is not changed if nil.
+	Comment *string `yaml:"comment"`
+
+	File      string `yaml:"file"`
+	Bucket    string `yaml:"bucket"`
+	ObjectKey string `yaml:"objectKey"`
+	Format    string `yaml:"format"`
+
+	// Delete deletes items that are not in the source, in the scope of the filter.
+	Delete bool   `yaml:"delete"`
+	Prefix string `yaml:"prefix"`
+	Glob   string `yaml:"glob"`
+	Regex  string `yaml:"regex"`
+}
+
+// FromBytes reads the manifest in YAML. Unknown fields are errors, so that a typo never changes what is applied.
+func (m *Manifest) FromBytes(b []byte) error {
+	if m == nil {
+		return fmt.Errorf("failed to unmarshal manifest due to nil pointer")
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(m); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to unmarshal manifest: the manifest is empty")
+		}
+		return fmt.Errorf("failed to unmarshal manifest: %w", err)
+	}
+
+	return m.validate()
+}
+
+func (m *Manifest) validate() error {
+	if m.Version != ManifestVersion {
+		return fmt.Errorf("unsupported manifest version: %d", m.Version)
+	}
+	if len(m.Stores) == 0 {
+		return errors.New("the manifest has no stores")
+	}
+
+	names := map[string]bool{}
+	for i, s := range m.Stores {
+		if s.Name == "" {
+			return fmt.Errorf("stores[%d]: name is required", i)
+		}
+		if names[s.Name] {
+			return fmt.Errorf("stores[%d]: the key value store '%s' is declared more than once", i, s.Name)
+		}
+		names[s.Name] = true
+
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("stores[%d] (%s): %w", i, s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ManifestStore) validate() error {
+	hasObject := s.Bucket != "" || s.ObjectKey != ""
+	switch {
+	case s.File != "" && hasObject:
+		return errors.New("file cannot be specified with bucket and objectKey")
+	case s.File == "" && !hasObject:
+		return errors.New("file, or bucket and objectKey are required")
+	case s.File == "" && (s.Bucket == "" || s.ObjectKey == ""):
+		return errors.New("both bucket and objectKey are required")
+	}
+
+	if _, err := s.DataFormat(); err != nil {
+		return err
+	}
+	if _, err := s.KeyFilter(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DataFormat returns the format of the source. An empty format is DataFormatAuto.
+func (s *ManifestStore) DataFormat() (DataFormat, error) {
+	return ParseDataFormat(s.Format)
+}
+
+// KeyFilter returns the filter of the scope of the key value store, or nil if the whole key value store is in the scope.
+func (s *ManifestStore) KeyFilter() (*KeyFilter, error) {
+	return NewKeyFilter(s.Prefix, s.Glob, s.Regex)
+}
+
+// ManifestPlan is the combined plan of all key value stores in a manifest.
+type ManifestPlan struct {
+	Stores []ManifestStorePlan `json:"stores"`
+}
+
+// ManifestStorePlan is the changes to a key value store in the manifest.
+type ManifestStorePlan struct {
+	Name   string `json:"name"`
+	Create bool   `json:"create"`
+	// Comment is nil if the comment is not changed.
+	Comment *UpdatedComment `json:"comment"`
+	Diff    *DiffDocument   `json:"diff"`
+}
+
+// UpdatedComment is a comment of a key value store that is changed from Before to After.
+type UpdatedComment struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// IsEmpty reports whether the plan has no change.
+func (p *ManifestPlan) IsEmpty() bool {
+	if p == nil {
+		return true
+	}
+
+	for _, s := range p.Stores {
+		if !s.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmpty reports whether the plan of the key value store has no change.
+func (p *ManifestStorePlan) IsEmpty() bool {
+	return !p.Create && p.Comment == nil && (p.Diff == nil || p.Diff.Summary.Total == 0)
+}
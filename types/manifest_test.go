@@ -0,0 +1,178 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Manifest_FromBytes(t *testing.T) {
+	comment := "redirects of the site"
+
+	cases := []struct {
+		name    string
+		m       *types.Manifest
+		b       string
+		expect  *types.Manifest
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			m:    &types.Manifest{},
+			b: `version: 1
+stores:
+  - name: redirects
+    comment: redirects of the site
+    file: redirects.json
+    delete: true
+  - name: flags
+    bucket: bucket
+    objectKey: flags.csv
+    format: csv
+    prefix: flags/
+`,
+			expect: &types.Manifest{
+				Version: 1,
+				Stores: []types.ManifestStore{
+					{Name: "redirects", Comment: &comment, File: "redirects.json", Delete: true},
+					{Name: "flags", Bucket: "bucket", ObjectKey: "flags.csv", Format: "csv", Prefix: "flags/"},
+				},
+			},
+		},
+		{
+			name:    "nil manifest",
+			m:       nil,
+			b:       `version: 1`,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			m:       &types.Manifest{},
+			b:       ``,
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			m:       &types.Manifest{},
+			b:       `version: [`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n    file: a.json\n    delte: true\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			m:       &types.Manifest{},
+			b:       "version: 2\nstores:\n  - name: a\n    file: a.json\n",
+			wantErr: true,
+		},
+		{
+			name:    "no stores",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores: []\n",
+			wantErr: true,
+		},
+		{
+			name:    "no name",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - file: a.json\n",
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n    file: a.json\n  - name: a\n    file: b.json\n",
+			wantErr: true,
+		},
+		{
+			name:    "no source",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n",
+			wantErr: true,
+		},
+		{
+			name:    "file and bucket",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n    file: a.json\n    bucket: bucket\n    objectKey: a.json\n",
+			wantErr: true,
+		},
+		{
+			name:    "bucket without object key",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n    bucket: bucket\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n    file: a.json\n    format: xml\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			m:       &types.Manifest{},
+			b:       "version: 1\nstores:\n  - name: a\n    file: a.json\n    regex: '('\n",
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.m.FromBytes([]byte(c.b))
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+			asst.Equal(c.expect, c.m)
+		})
+	}
+}
+
+func Test_ManifestPlan_IsEmpty(t *testing.T) {
+	noChange := (&types.ItemListDiff{}).Document()
+	change := types.NewItemList(nil).Diff(types.NewItemList([]types.Item{{Key: "key", Value: "value"}}), false).Document()
+
+	cases := []struct {
+		name   string
+		p      *types.ManifestPlan
+		expect bool
+	}{
+		{name: "nil", p: nil, expect: true},
+		{name: "no stores", p: &types.ManifestPlan{}, expect: true},
+		{
+			name:   "no change",
+			p:      &types.ManifestPlan{Stores: []types.ManifestStorePlan{{Name: "a", Diff: noChange}, {Name: "b"}}},
+			expect: true,
+		},
+		{
+			name:   "create",
+			p:      &types.ManifestPlan{Stores: []types.ManifestStorePlan{{Name: "a", Diff: noChange}, {Name: "b", Create: true, Diff: noChange}}},
+			expect: false,
+		},
+		{
+			name:   "comment",
+			p:      &types.ManifestPlan{Stores: []types.ManifestStorePlan{{Name: "a", Comment: &types.UpdatedComment{Before: "", After: "new"}, Diff: noChange}}},
+			expect: false,
+		},
+		{
+			name:   "items",
+			p:      &types.ManifestPlan{Stores: []types.ManifestStorePlan{{Name: "a", Diff: change}}},
+			expect: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+			asst.Equal(c.expect, c.p.IsEmpty())
+		})
+	}
+}
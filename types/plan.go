@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PlanVersion is the version of the plan file format.
+const PlanVersion = 1
+
+// Plan is a diff of sync saved to a file, that is applied later
+// only if the key value store has not been changed since the plan was created.
+type Plan struct {
+	Version   int           `json:"version"`
+	KVSARN    string        `json:"kvsArn"`
+	ETag      string        `json:"eTag"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Diff      *ItemListDiff `json:"diff"`
+}
+
+func (p *Plan) ToBytes() ([]byte, error) {
+	if p == nil {
+		return nil, fmt.Errorf("failed to marshal plan due to nil pointer")
+	}
+
+	return json.MarshalIndent(p, "", "  ")
+}
+
+func (p *Plan) FromBytes(b []byte) error {
+	if p == nil {
+		return fmt.Errorf("failed to unmarshal plan due to nil pointer")
+	}
+
+	if err := json.Unmarshal(b, p); err != nil {
+		return fmt.Errorf("failed to unmarshal plan: %w", err)
+	}
+
+	if p.Version != PlanVersion {
+		return fmt.Errorf("unsupported plan version: %d", p.Version)
+	}
+	if p.KVSARN == "" || p.ETag == "" || p.Diff == nil {
+		return fmt.Errorf("failed to unmarshal plan: kvsArn, eTag and diff are required")
+	}
+
+	for _, diffs := range [][]ItemDiff{p.Diff.Add, p.Diff.Update} {
+		for _, d := range diffs {
+			if d.After == nil {
+				return fmt.Errorf("failed to unmarshal plan: after is required in add and update")
+			}
+		}
+	}
+	for _, d := range p.Diff.Delete {
+		if d.Before == nil {
+			return fmt.Errorf("failed to unmarshal plan: before is required in delete")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Plan_ToBytes_FromBytes(t *testing.T) {
+	plan := &types.Plan{
+		Version:   types.PlanVersion,
+		KVSARN:    "arn",
+		ETag:      "etag",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Diff: &types.ItemListDiff{
+			Add:    []types.ItemDiff{{After: &types.Item{Key: "key1", Value: "value1"}}},
+			Update: []types.ItemDiff{{Before: &types.Item{Key: "key2", Value: "old"}, After: &types.Item{Key: "key2", Value: "new"}}},
+			Delete: []types.ItemDiff{{Before: &types.Item{Key: "key3", Value: "value3"}}},
+		},
+	}
+
+	asst := assert.New(t)
+
+	b, err := plan.ToBytes()
+	asst.NoError(err)
+
+	got := types.Plan{}
+	asst.NoError(got.FromBytes(b))
+	asst.Equal(*plan, got)
+
+	var nilPlan *types.Plan
+	_, err = nilPlan.ToBytes()
+	asst.Error(err)
+}
+
+func Test_Plan_FromBytes(t *testing.T) {
+	cases := []struct {
+		name    string
+		p       *types.Plan
+		b       []byte
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			p:    &types.Plan{},
+			b:    []byte(`{"version":1,"kvsArn":"arn","eTag":"etag","diff":{"add":[],"update":[],"delete":[]}}`),
+		},
+		{
+			name:    "nil plan",
+			p:       nil,
+			b:       []byte(`{"version":1,"kvsArn":"arn","eTag":"etag","diff":{"add":[],"update":[],"delete":[]}}`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			p:       &types.Plan{},
+			b:       []byte(`invalid`),
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			p:       &types.Plan{},
+			b:       []byte(`{"version":2,"kvsArn":"arn","eTag":"etag","diff":{"add":[],"update":[],"delete":[]}}`),
+			wantErr: true,
+		},
+		{
+			name:    "eTag is empty",
+			p:       &types.Plan{},
+			b:       []byte(`{"version":1,"kvsArn":"arn","diff":{"add":[],"update":[],"delete":[]}}`),
+			wantErr: true,
+		},
+		{
+			name:    "diff is empty",
+			p:       &types.Plan{},
+			b:       []byte(`{"version":1,"kvsArn":"arn","eTag":"etag"}`),
+			wantErr: true,
+		},
+		{
+			name:    "after is empty in add",
+			p:       &types.Plan{},
+			b:       []byte(`{"version":1,"kvsArn":"arn","eTag":"etag","diff":{"add":[{"before":null,"after":null}]}}`),
+			wantErr: true,
+		},
+		{
+			name:    "before is empty in delete",
+			p:       &types.Plan{},
+			b:       []byte(`{"version":1,"kvsArn":"arn","eTag":"etag","diff":{"delete":[{"before":null,"after":null}]}}`),
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.p.FromBytes(c.b)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
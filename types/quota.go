@@ -0,0 +1,23 @@
+package types
+
+// Quotas of CloudFront KeyValueStore.
+// https://docs.aws.amazon.com/AmazonCloudFront/latest/DeveloperGuide/cloudfront-limits.html#limits-keyvaluestores
+const (
+	// MaxKeyValueStores is the maximum number of key value stores per account.
+	MaxKeyValueStores = 50
+
+	// MaxKeySize is the maximum size in bytes of a key.
+	MaxKeySize = 512
+
+	// MaxValueSize is the maximum size in bytes of a value.
+	MaxValueSize = 1024
+
+	// MaxKeyValueStoreSize is the maximum total size in bytes of the keys and values in a key value store.
+	MaxKeyValueStoreSize = 5 * 1024 * 1024
+
+	// MaxItemsPerUpdateKeys is the maximum number of key-value pairs (puts and deletes) in a single UpdateKeys request.
+	MaxItemsPerUpdateKeys = 50
+
+	// MaxUpdateKeysRequestSize is the maximum data size in bytes of a single UpdateKeys request.
+	MaxUpdateKeysRequestSize = 3 * 1024 * 1024
+)
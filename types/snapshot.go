@@ -0,0 +1,114 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot file format.
+const SnapshotVersion = 1
+
+// SnapshotIDLayout is the time layout of snapshot IDs, that sort in the order of creation.
+const SnapshotIDLayout = "20060102T150405.000Z"
+
+// Snapshot is a copy of the items in a key value store at a point in time, to restore them later.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ETag is the ETag of the key value store when the items were listed.
+	ETag          string            `json:"eTag"`
+	KeyValueStore KeyValueStoreFull `json:"keyValueStore"`
+	// Checksum is the checksum of the items, to detect a broken or edited snapshot.
+	Checksum string `json:"checksum"`
+	Items    []Item `json:"items"`
+}
+
+// SnapshotSummary is a snapshot in a list, without reading its content.
+type SnapshotSummary struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Size      int64     `json:"size"`
+	Location  string    `json:"location"`
+}
+
+type SnapshotSummaryList []SnapshotSummary
+
+// NewSnapshot returns a snapshot of the items, whose ID is the time of creation.
+func NewSnapshot(kvs *KeyValueStoreFull, eTag string, items *ItemList, createdAt time.Time) *Snapshot {
+	s := &Snapshot{
+		Version:   SnapshotVersion,
+		ID:        createdAt.UTC().Format(SnapshotIDLayout),
+		CreatedAt: createdAt.UTC(),
+		ETag:      eTag,
+		Items:     []Item{},
+	}
+	if kvs != nil {
+		s.KeyValueStore = *kvs
+	}
+	if items != nil {
+		s.Items = append(s.Items, items.Data...)
+	}
+	sort.Slice(s.Items, func(i, j int) bool { return s.Items[i].Key < s.Items[j].Key })
+	s.Checksum = ItemsChecksum(s.Items)
+
+	return s
+}
+
+// ItemsChecksum returns the SHA-256 checksum of the JSON form of the items, that does not depend on their order.
+func ItemsChecksum(items []Item) string {
+	sorted := append([]Item{}, items...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+
+	// marshaling a slice of Item never fails
+	b, _ := json.Marshal(sorted)
+	sum := sha256.Sum256(b)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ItemList returns the items in the snapshot.
+func (s *Snapshot) ItemList() *ItemList {
+	if s == nil {
+		return nil
+	}
+
+	items := make([]Item, len(s.Items))
+	copy(items, s.Items)
+
+	return NewItemList(items)
+}
+
+func (s *Snapshot) ToBytes() ([]byte, error) {
+	if s == nil {
+		return nil, fmt.Errorf("failed to marshal snapshot due to nil pointer")
+	}
+
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func (s *Snapshot) FromBytes(b []byte) error {
+	if s == nil {
+		return fmt.Errorf("failed to unmarshal snapshot due to nil pointer")
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return fmt.Errorf("failed to unmarshal snapshot: %w", err)
+	}
+
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version: %d", s.Version)
+	}
+	if s.ID == "" || s.Checksum == "" || s.Items == nil {
+		return fmt.Errorf("failed to unmarshal snapshot: id, checksum and items are required")
+	}
+	if checksum := ItemsChecksum(s.Items); checksum != s.Checksum {
+		return fmt.Errorf("the checksum of the snapshot %s does not match its items (checksum in the snapshot: %s, checksum of the items: %s)", s.ID, s.Checksum, checksum)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package types_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewSnapshot(t *testing.T) {
+	asst := assert.New(t)
+
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6e6, time.FixedZone("JST", 9*60*60))
+	kvs := &types.KeyValueStoreFull{Name: "name", ARN: "arn"}
+	items := types.NewItemList([]types.Item{
+		{Key: "key2", Value: "value2"},
+		{Key: "key1", Value: "value1"},
+	})
+
+	s := types.NewSnapshot(kvs, "etag", items, createdAt)
+	asst.Equal(types.SnapshotVersion, s.Version)
+	asst.Equal("20240101T180405.006Z", s.ID)
+	asst.Equal(createdAt.UTC(), s.CreatedAt)
+	asst.Equal("etag", s.ETag)
+	asst.Equal(*kvs, s.KeyValueStore)
+	asst.Equal([]types.Item{{Key: "key1", Value: "value1"}, {Key: "key2", Value: "value2"}}, s.Items)
+	asst.Equal(types.ItemsChecksum(items.Data), s.Checksum)
+
+	// the items of the list are not changed
+	asst.Equal("key2", items.Data[0].Key)
+
+	empty := types.NewSnapshot(nil, "etag", nil, createdAt)
+	asst.Equal([]types.Item{}, empty.Items)
+	asst.Equal(types.ItemsChecksum(nil), empty.Checksum)
+}
+
+func Test_ItemsChecksum(t *testing.T) {
+	asst := assert.New(t)
+
+	a := types.ItemsChecksum([]types.Item{{Key: "key1", Value: "value1"}, {Key: "key2", Value: "value2"}})
+	b := types.ItemsChecksum([]types.Item{{Key: "key2", Value: "value2"}, {Key: "key1", Value: "value1"}})
+	c := types.ItemsChecksum([]types.Item{{Key: "key1", Value: "value1"}, {Key: "key2", Value: "changed"}})
+
+	asst.Regexp(`^sha256:[0-9a-f]{64}$`, a)
+	asst.Equal(a, b)
+	asst.NotEqual(a, c)
+}
+
+func Test_Snapshot_ToBytes_FromBytes(t *testing.T) {
+	asst := assert.New(t)
+
+	s := types.NewSnapshot(
+		&types.KeyValueStoreFull{Name: "name", ARN: "arn", ItemCount: 1},
+		"etag",
+		types.NewItemList([]types.Item{{Key: "key1", Value: "value1"}}),
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	b, err := s.ToBytes()
+	asst.NoError(err)
+
+	got := types.Snapshot{}
+	asst.NoError(got.FromBytes(b))
+	asst.Equal(*s, got)
+	asst.Equal(types.NewItemList([]types.Item{{Key: "key1", Value: "value1"}}), got.ItemList())
+
+	var nilSnapshot *types.Snapshot
+	_, err = nilSnapshot.ToBytes()
+	asst.Error(err)
+	asst.Nil(nilSnapshot.ItemList())
+}
+
+func Test_Snapshot_FromBytes(t *testing.T) {
+	checksum := types.ItemsChecksum([]types.Item{{Key: "key1", Value: "value1"}})
+
+	cases := []struct {
+		name    string
+		s       *types.Snapshot
+		b       string
+		wantErr bool
+	}{
+		{
+			name: "normal",
+			s:    &types.Snapshot{},
+			b:    `{"version":1,"id":"20240101T000000.000Z","checksum":"` + checksum + `","items":[{"key":"key1","value":"value1"}]}`,
+		},
+		{
+			name:    "nil snapshot",
+			s:       nil,
+			b:       `{}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			s:       &types.Snapshot{},
+			b:       `invalid`,
+			wantErr: true,
+		},
+		{
+			name:    "unsupported version",
+			s:       &types.Snapshot{},
+			b:       `{"version":2,"id":"20240101T000000.000Z","checksum":"` + checksum + `","items":[{"key":"key1","value":"value1"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "no items",
+			s:       &types.Snapshot{},
+			b:       `{"version":1,"id":"20240101T000000.000Z","checksum":"` + checksum + `"}`,
+			wantErr: true,
+		},
+		{
+			name:    "checksum mismatch",
+			s:       &types.Snapshot{},
+			b:       `{"version":1,"id":"20240101T000000.000Z","checksum":"` + checksum + `","items":[{"key":"key1","value":"edited"}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := c.s.FromBytes([]byte(c.b))
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}
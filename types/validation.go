@@ -0,0 +1,102 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InvalidItem is an item in the key value store data that cannot be put to the key value store.
+type InvalidItem struct {
+	// Index is the index of the item in the data, starting from 0.
+	Index  int
+	Key    string
+	Reason string
+}
+
+// ValidationError reports all invalid items in the key value store data,
+// and the total size of the data if it exceeds the limit.
+type ValidationError struct {
+	Items     []InvalidItem
+	TotalSize int
+}
+
+func (e *ValidationError) Error() string {
+	b := strings.Builder{}
+	b.WriteString("invalid key value store data:")
+	for _, item := range e.Items {
+		b.WriteString(fmt.Sprintf("\n  data[%d] (key: %q): %s", item.Index, truncate(item.Key, 64), item.Reason))
+	}
+	if e.TotalSize > MaxKeyValueStoreSize {
+		b.WriteString(fmt.Sprintf("\n  the total size of keys and values is %d bytes, it exceeds the limit of %d bytes", e.TotalSize, MaxKeyValueStoreSize))
+	}
+
+	return b.String()
+}
+
+// validate checks every item against the quotas of CloudFront KeyValueStore,
+// so that invalid data is rejected before any request to the key value store.
+func (kd *KeyValueStoreData) validate() error {
+	if kd.Data == nil {
+		return fmt.Errorf("failed to unmarshal key value store data: invalid data structure\n%s", invalidDataStructureErrorMessage)
+	}
+
+	vErr := &ValidationError{Items: []InvalidItem{}}
+	seen := map[string]int{}
+	for i, item := range *kd.Data {
+		vErr.TotalSize += len(item.Key) + len(item.Value)
+
+		invalid := func(format string, a ...any) {
+			vErr.Items = append(vErr.Items, InvalidItem{Index: i, Key: item.Key, Reason: fmt.Sprintf(format, a...)})
+		}
+
+		switch {
+		case item.Key == "":
+			invalid("key is empty")
+		case len(item.Key) > MaxKeySize:
+			invalid("key is %d bytes, it exceeds the limit of %d bytes", len(item.Key), MaxKeySize)
+		}
+
+		switch {
+		case item.Value == "":
+			invalid("value is empty")
+		case len(item.Value) > MaxValueSize:
+			invalid("value is %d bytes, it exceeds the limit of %d bytes", len(item.Value), MaxValueSize)
+		}
+
+		if item.Key == "" {
+			continue
+		}
+		if first, ok := seen[item.Key]; ok {
+			invalid("key is duplicated with data[%d]", first)
+			continue
+		}
+		seen[item.Key] = i
+	}
+
+	if len(vErr.Items) > 0 || vErr.TotalSize > MaxKeyValueStoreSize {
+		return vErr
+	}
+
+	return nil
+}
+
+// ValidateValue checks the value of an item against the quotas of CloudFront KeyValueStore.
+func ValidateValue(value string) error {
+	switch {
+	case value == "":
+		return fmt.Errorf("value is empty")
+	case len(value) > MaxValueSize:
+		return fmt.Errorf("value is %d bytes, it exceeds the limit of %d bytes", len(value), MaxValueSize)
+	}
+
+	return nil
+}
+
+// truncate shortens s to n characters, so that a multi-byte character is never cut in the middle.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
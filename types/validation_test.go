@@ -0,0 +1,128 @@
+package types_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/michimani/cfkvs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeyValueStoreData_FromBytes_validation(t *testing.T) {
+	longKey := strings.Repeat("k", types.MaxKeySize+1)
+	longValue := strings.Repeat("v", types.MaxValueSize+1)
+
+	// 6000 items of about 1000 bytes exceed the total size limit, while each item is valid.
+	tooLarge := make([]string, 6000)
+	for i := range tooLarge {
+		tooLarge[i] = fmt.Sprintf(`{"key":"key-%d","value":"%s"}`, i, strings.Repeat("v", 990))
+	}
+
+	cases := []struct {
+		name           string
+		input          string
+		expectItems    []types.InvalidItem
+		expectTooLarge bool
+	}{
+		{
+			name:  "all invalid items are reported",
+			input: `{"data":[{"key":"","value":"v0"},{"key":"k1","value":"v1"},{"key":"k2","value":""},{"key":"","value":""}]}`,
+			expectItems: []types.InvalidItem{
+				{Index: 0, Key: "", Reason: "key is empty"},
+				{Index: 2, Key: "k2", Reason: "value is empty"},
+				{Index: 3, Key: "", Reason: "key is empty"},
+				{Index: 3, Key: "", Reason: "value is empty"},
+			},
+		},
+		{
+			name:  "key and value exceed the limits",
+			input: fmt.Sprintf(`{"data":[{"key":"%s","value":"v0"},{"key":"k1","value":"%s"}]}`, longKey, longValue),
+			expectItems: []types.InvalidItem{
+				{Index: 0, Key: longKey, Reason: fmt.Sprintf("key is %d bytes, it exceeds the limit of %d bytes", types.MaxKeySize+1, types.MaxKeySize)},
+				{Index: 1, Key: "k1", Reason: fmt.Sprintf("value is %d bytes, it exceeds the limit of %d bytes", types.MaxValueSize+1, types.MaxValueSize)},
+			},
+		},
+		{
+			name:  "duplicated key",
+			input: `{"data":[{"key":"k0","value":"v0"},{"key":"k1","value":"v1"},{"key":"k0","value":"v2"}]}`,
+			expectItems: []types.InvalidItem{
+				{Index: 2, Key: "k0", Reason: "key is duplicated with data[0]"},
+			},
+		},
+		{
+			name:           "total size exceeds the limit",
+			input:          `{"data":[` + strings.Join(tooLarge, ",") + `]}`,
+			expectItems:    []types.InvalidItem{},
+			expectTooLarge: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			kvsd := &types.KeyValueStoreData{}
+			err := kvsd.FromBytes([]byte(c.input))
+
+			var vErr *types.ValidationError
+			if !asst.True(errors.As(err, &vErr), err) {
+				return
+			}
+			asst.Equal(c.expectItems, vErr.Items)
+			asst.Equal(c.expectTooLarge, vErr.TotalSize > types.MaxKeyValueStoreSize)
+			for _, item := range c.expectItems {
+				asst.Contains(err.Error(), fmt.Sprintf("data[%d]", item.Index))
+			}
+		})
+	}
+}
+
+func Test_ValidationError_Error(t *testing.T) {
+	cases := []struct {
+		name   string
+		key    string
+		expect string
+	}{
+		{name: "short key", key: "key", expect: `(key: "key")`},
+		{name: "long key", key: strings.Repeat("k", 100), expect: `(key: "` + strings.Repeat("k", 64) + `...")`},
+		{name: "long key of multi-byte characters", key: strings.Repeat("あ", 100), expect: `(key: "` + strings.Repeat("あ", 64) + `...")`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := &types.ValidationError{Items: []types.InvalidItem{{Index: 0, Key: c.key, Reason: "reason"}}}
+			asst.Contains(err.Error(), c.expect)
+		})
+	}
+}
+
+func Test_ValidateValue(t *testing.T) {
+	cases := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "ok", value: "value"},
+		{name: "ok: limit", value: strings.Repeat("v", types.MaxValueSize)},
+		{name: "error: empty", value: "", wantErr: true},
+		{name: "error: too large", value: strings.Repeat("v", types.MaxValueSize+1), wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(tt *testing.T) {
+			asst := assert.New(tt)
+
+			err := types.ValidateValue(c.value)
+			if c.wantErr {
+				asst.Error(err)
+				return
+			}
+
+			asst.NoError(err)
+		})
+	}
+}